@@ -0,0 +1,121 @@
+-- +goose Up
+-- +goose StatementBegin
+-- System groups (names match the user_role enum)
+INSERT INTO groups (name, display_name, description, is_system, priority)
+VALUES
+    ('admin', 'Administrators', 'Full access to the platform', true, 100),
+    ('instructor', 'Instructors', 'Can author and teach courses', true, 50),
+    ('student', 'Students', 'Can enroll in and take courses', true, 10)
+ON CONFLICT (name) DO NOTHING;
+
+-- Base permissions
+INSERT INTO permissions (resource, action, scope, description)
+VALUES
+    ('courses', 'read', 'all', 'View any course'),
+    ('courses', 'create', 'own', 'Create courses owned by the user'),
+    ('courses', 'update', 'own', 'Update courses owned by the user'),
+    ('courses', 'update', 'assigned', 'Update courses the user is on the staff of'),
+    ('courses', 'update', 'all', 'Update any course'),
+    ('courses', 'delete', 'own', 'Delete courses owned by the user'),
+    ('courses', 'delete', 'all', 'Delete any course'),
+    ('enrollments', 'create', 'own', 'Enroll in courses'),
+    ('enrollments', 'read', 'own', 'View own enrollments'),
+    ('enrollments', 'read', 'assigned', 'View enrollments of taught courses'),
+    ('enrollments', 'read', 'all', 'View all enrollments'),
+    ('forum', 'create', 'own', 'Create forum threads and posts'),
+    ('forum', 'update', 'own', 'Edit own forum content'),
+    ('forum', 'delete', 'assigned', 'Moderate forums of taught courses'),
+    ('forum', 'delete', 'all', 'Moderate all forums'),
+    ('analytics', 'read', 'own', 'View own learning analytics'),
+    ('analytics', 'read', 'assigned', 'View analytics of taught courses'),
+    ('analytics', 'read', 'all', 'View all analytics'),
+    ('users', 'read', 'own', 'View own profile'),
+    ('users', 'update', 'own', 'Update own profile'),
+    ('users', 'read', 'all', 'View any user'),
+    ('users', 'update', 'all', 'Update any user'),
+    ('users', 'delete', 'all', 'Delete any user'),
+    ('settings', 'update', 'all', 'Change system settings')
+ON CONFLICT (resource, action, scope) DO NOTHING;
+
+-- Admins get every permission
+INSERT INTO group_permissions (group_id, permission_id)
+SELECT groups.id, permissions.id
+FROM groups
+    CROSS JOIN permissions
+WHERE groups.name = 'admin'
+ON CONFLICT (group_id, permission_id) DO NOTHING;
+
+-- Instructors
+INSERT INTO group_permissions (group_id, permission_id)
+SELECT groups.id, permissions.id
+FROM groups
+    JOIN permissions ON (permissions.resource, permissions.action, permissions.scope) IN (
+        ('courses', 'read', 'all'),
+        ('courses', 'create', 'own'),
+        ('courses', 'update', 'own'),
+        ('courses', 'update', 'assigned'),
+        ('courses', 'delete', 'own'),
+        ('enrollments', 'create', 'own'),
+        ('enrollments', 'read', 'own'),
+        ('enrollments', 'read', 'assigned'),
+        ('forum', 'create', 'own'),
+        ('forum', 'update', 'own'),
+        ('forum', 'delete', 'assigned'),
+        ('analytics', 'read', 'own'),
+        ('analytics', 'read', 'assigned'),
+        ('users', 'read', 'own'),
+        ('users', 'update', 'own')
+    )
+WHERE groups.name = 'instructor'
+ON CONFLICT (group_id, permission_id) DO NOTHING;
+
+-- Students
+INSERT INTO group_permissions (group_id, permission_id)
+SELECT groups.id, permissions.id
+FROM groups
+    JOIN permissions ON (permissions.resource, permissions.action, permissions.scope) IN (
+        ('courses', 'read', 'all'),
+        ('enrollments', 'create', 'own'),
+        ('enrollments', 'read', 'own'),
+        ('forum', 'create', 'own'),
+        ('forum', 'update', 'own'),
+        ('analytics', 'read', 'own'),
+        ('users', 'read', 'own'),
+        ('users', 'update', 'own')
+    )
+WHERE groups.name = 'student'
+ON CONFLICT (group_id, permission_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM groups WHERE name IN ('admin', 'instructor', 'student') AND is_system = true;
+-- Only the seeded permissions; those added since are kept
+DELETE FROM permissions
+WHERE (resource, action, scope) IN (
+        ('courses', 'read', 'all'),
+        ('courses', 'create', 'own'),
+        ('courses', 'update', 'own'),
+        ('courses', 'update', 'assigned'),
+        ('courses', 'update', 'all'),
+        ('courses', 'delete', 'own'),
+        ('courses', 'delete', 'all'),
+        ('enrollments', 'create', 'own'),
+        ('enrollments', 'read', 'own'),
+        ('enrollments', 'read', 'assigned'),
+        ('enrollments', 'read', 'all'),
+        ('forum', 'create', 'own'),
+        ('forum', 'update', 'own'),
+        ('forum', 'delete', 'assigned'),
+        ('forum', 'delete', 'all'),
+        ('analytics', 'read', 'own'),
+        ('analytics', 'read', 'assigned'),
+        ('analytics', 'read', 'all'),
+        ('users', 'read', 'own'),
+        ('users', 'update', 'own'),
+        ('users', 'read', 'all'),
+        ('users', 'update', 'all'),
+        ('users', 'delete', 'all'),
+        ('settings', 'update', 'all')
+    );
+-- +goose StatementEnd
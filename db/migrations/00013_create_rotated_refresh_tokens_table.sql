@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Refresh tokens that have been rotated out of a session. Presenting one of
+-- these again means the token was stolen, so the whole session is revoked.
+CREATE TABLE rotated_refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rotated_refresh_tokens_session ON rotated_refresh_tokens(session_id);
+
+-- Tokens used to be stored in plain text; hash them in place (SHA-256, hex)
+UPDATE user_sessions
+SET refresh_token_hash = encode(digest(refresh_token_hash, 'sha256'), 'hex'),
+    access_token_hash = encode(digest(access_token_hash, 'sha256'), 'hex');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rotated_refresh_tokens;
+-- +goose StatementEnd
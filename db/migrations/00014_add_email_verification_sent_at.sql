@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tracks when the current verification token was sent, for expiry and resend throttling
+ALTER TABLE users ADD COLUMN email_verification_sent_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_email_verification_token ON users(email_verification_token) WHERE email_verification_token IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_email_verification_token;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verification_sent_at;
+-- +goose StatementEnd
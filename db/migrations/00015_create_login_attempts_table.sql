@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every password login attempt, used to throttle clients by IP address
+-- independently of the per-account lockout kept on users
+CREATE TABLE login_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    ip_address INET NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, attempted_at);
+CREATE INDEX idx_login_attempts_user ON login_attempts(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Publish session and account state changes on the auth_revocations channel
+-- so every API instance can drop cached session checks immediately.
+-- Payloads are 'session:<id>' or 'user:<id>'.
+CREATE OR REPLACE FUNCTION notify_session_revocation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('auth_revocations', 'session:' || OLD.id::text);
+        RETURN OLD;
+    END IF;
+
+    IF NEW.is_active IS DISTINCT FROM OLD.is_active
+        OR NEW.revoked_at IS DISTINCT FROM OLD.revoked_at THEN
+        PERFORM pg_notify('auth_revocations', 'session:' || NEW.id::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_sessions_revocation
+AFTER UPDATE OR DELETE ON user_sessions
+FOR EACH ROW EXECUTE FUNCTION notify_session_revocation();
+
+CREATE OR REPLACE FUNCTION notify_user_revocation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('auth_revocations', 'user:' || OLD.id::text);
+        RETURN OLD;
+    END IF;
+
+    IF NEW.is_active IS DISTINCT FROM OLD.is_active
+        OR NEW.suspended_at IS DISTINCT FROM OLD.suspended_at
+        OR NEW.deleted_at IS DISTINCT FROM OLD.deleted_at THEN
+        PERFORM pg_notify('auth_revocations', 'user:' || NEW.id::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_revocation
+AFTER UPDATE OR DELETE ON users
+FOR EACH ROW EXECUTE FUNCTION notify_user_revocation();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_users_revocation ON users;
+DROP FUNCTION IF EXISTS notify_user_revocation();
+DROP TRIGGER IF EXISTS trg_user_sessions_revocation ON user_sessions;
+DROP FUNCTION IF EXISTS notify_session_revocation();
+-- +goose StatementEnd
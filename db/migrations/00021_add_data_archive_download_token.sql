@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Hash of the secret in the emailed download link of a completed export.
+-- Completed exports move to status 'expired' once the link expires.
+ALTER TABLE data_archives ADD COLUMN download_token_hash VARCHAR(64);
+
+CREATE UNIQUE INDEX idx_data_archives_download_token ON data_archives(download_token_hash) WHERE download_token_hash IS NOT NULL;
+CREATE INDEX idx_data_archives_pending ON data_archives(requested_at) WHERE status IN ('pending', 'processing');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_data_archives_pending;
+DROP INDEX IF EXISTS idx_data_archives_download_token;
+ALTER TABLE data_archives DROP COLUMN IF EXISTS download_token_hash;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleting an account sets deleted_at; once the grace period has passed the
+-- personal data is overwritten and anonymized_at is set. The row itself is
+-- kept as the "Deleted user" that authored content and grades still point at.
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_pending_anonymization ON users(deleted_at) WHERE deleted_at IS NOT NULL AND anonymized_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_pending_anonymization;
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
+-- +goose StatementEnd
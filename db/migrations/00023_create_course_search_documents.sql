@@ -0,0 +1,96 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Maps a course language tag such as 'en' or 'pt-BR' to the text search
+-- configuration used to stem its content. Unknown languages fall back to
+-- 'simple', which only lowercases.
+CREATE OR REPLACE FUNCTION course_search_config(language TEXT) RETURNS regconfig AS $$
+    SELECT CASE lower(split_part(COALESCE(language, 'en'), '-', 1))
+        WHEN 'ar' THEN 'arabic'
+        WHEN 'da' THEN 'danish'
+        WHEN 'de' THEN 'german'
+        WHEN 'en' THEN 'english'
+        WHEN 'es' THEN 'spanish'
+        WHEN 'fi' THEN 'finnish'
+        WHEN 'fr' THEN 'french'
+        WHEN 'hu' THEN 'hungarian'
+        WHEN 'id' THEN 'indonesian'
+        WHEN 'it' THEN 'italian'
+        WHEN 'nl' THEN 'dutch'
+        WHEN 'no' THEN 'norwegian'
+        WHEN 'nb' THEN 'norwegian'
+        WHEN 'pt' THEN 'portuguese'
+        WHEN 'ro' THEN 'romanian'
+        WHEN 'ru' THEN 'russian'
+        WHEN 'sv' THEN 'swedish'
+        WHEN 'tr' THEN 'turkish'
+        ELSE 'simple'
+    END::regconfig;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Weighted search document of a course: title A, tags B, description and
+-- learning outcomes C, syllabus D
+CREATE OR REPLACE FUNCTION course_search_document(
+    config regconfig,
+    title TEXT,
+    description TEXT,
+    syllabus TEXT,
+    tags TEXT[],
+    learning_outcomes TEXT[]
+) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector(config, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector(config, COALESCE(array_to_string(tags, ' '), '')), 'B')
+        || setweight(to_tsvector(config, COALESCE(description, '')), 'C')
+        || setweight(to_tsvector(config, COALESCE(array_to_string(learning_outcomes, ' '), '')), 'C')
+        || setweight(to_tsvector(config, COALESCE(syllabus, '')), 'D');
+$$ LANGUAGE sql STABLE;
+
+-- Search documents live in their own table so course queries do not carry
+-- the tsvector around
+CREATE TABLE course_search_documents (
+    course_id UUID PRIMARY KEY REFERENCES courses(id) ON DELETE CASCADE,
+    config regconfig NOT NULL,
+    document tsvector NOT NULL
+);
+
+CREATE INDEX idx_course_search_documents_document ON course_search_documents USING gin(document);
+
+CREATE OR REPLACE FUNCTION refresh_course_search_document() RETURNS TRIGGER AS $$
+DECLARE
+    cfg regconfig := course_search_config(NEW.language);
+BEGIN
+    INSERT INTO course_search_documents (course_id, config, document)
+    VALUES (
+        NEW.id,
+        cfg,
+        course_search_document(cfg, NEW.title, NEW.description, NEW.syllabus, NEW.tags, NEW.learning_outcomes)
+    )
+    ON CONFLICT (course_id) DO UPDATE
+    SET config = EXCLUDED.config,
+        document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_courses_search_document
+AFTER INSERT OR UPDATE OF title, description, syllabus, tags, learning_outcomes, language ON courses
+FOR EACH ROW EXECUTE FUNCTION refresh_course_search_document();
+
+INSERT INTO course_search_documents (course_id, config, document)
+SELECT id,
+    course_search_config(language),
+    course_search_document(course_search_config(language), title, description, syllabus, tags, learning_outcomes)
+FROM courses;
+
+-- Replaced by course_search_documents
+DROP INDEX IF EXISTS idx_courses_search;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX idx_courses_search ON courses USING gin(to_tsvector('english', title || ' ' || COALESCE(description, '')));
+DROP TRIGGER IF EXISTS trg_courses_search_document ON courses;
+DROP FUNCTION IF EXISTS refresh_course_search_document();
+DROP TABLE IF EXISTS course_search_documents;
+DROP FUNCTION IF EXISTS course_search_document(regconfig, TEXT, TEXT, TEXT, TEXT[], TEXT[]);
+DROP FUNCTION IF EXISTS course_search_config(TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Time step of the last accepted TOTP code. Codes for this step or an
+-- earlier one are refused so that an observed code cannot be replayed
+-- within its validity window (RFC 6238 section 5.2).
+ALTER TABLE users ADD COLUMN two_factor_last_counter BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_last_counter;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When a worker last claimed the export. Exports are taken over once their
+-- claim goes stale, however long they waited in the queue before it.
+ALTER TABLE data_archives ADD COLUMN claimed_at TIMESTAMPTZ;
+
+UPDATE data_archives
+SET claimed_at = NOW()
+WHERE status = 'processing';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE data_archives DROP COLUMN IF EXISTS claimed_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Single-use emailed links confirming the deletion of an account, for users
+-- who sign in without a password and so cannot confirm with one
+CREATE TABLE account_deletion_confirmations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_account_deletion_confirmations_user ON account_deletion_confirmations(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_deletion_confirmations;
+-- +goose StatementEnd
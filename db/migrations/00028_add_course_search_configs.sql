@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every configuration course_search_config can return. Searches parse the
+-- query once per configuration and join on it, so the per-row document
+-- match can use the GIN index.
+CREATE OR REPLACE FUNCTION course_search_configs() RETURNS SETOF regconfig AS $$
+    SELECT unnest(ARRAY[
+        'arabic', 'danish', 'german', 'english', 'spanish', 'finnish',
+        'french', 'hungarian', 'indonesian', 'italian', 'dutch', 'norwegian',
+        'portuguese', 'romanian', 'russian', 'swedish', 'turkish', 'simple'
+    ]::regconfig[]);
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS course_search_configs();
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Lets the retention job find expired attempts without a full scan
+CREATE INDEX idx_login_attempts_attempted_at ON login_attempts(attempted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_login_attempts_attempted_at;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- name: IsCourseInstructor :one
+SELECT (
+        EXISTS (
+            SELECT 1
+            FROM courses
+            WHERE courses.id = sqlc.arg(course_id)
+                AND courses.instructor_id = sqlc.arg(user_id)
+                AND courses.deleted_at IS NULL
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM course_staff
+            WHERE course_staff.course_id = sqlc.arg(course_id)
+                AND course_staff.user_id = sqlc.arg(user_id)
+        )
+    )::boolean AS is_instructor;
+
+-- name: IsEnrolled :one
+SELECT EXISTS (
+        SELECT 1
+        FROM enrollments
+        WHERE course_id = $1
+            AND user_id = $2
+            AND status IN ('active', 'completed')
+    )::boolean AS is_enrolled;
+
+-- name: GetModuleCourseID :one
+SELECT course_id
+FROM modules
+WHERE id = $1;
+
+-- name: GetLessonCourseID :one
+SELECT modules.course_id
+FROM lessons
+    JOIN modules ON modules.id = lessons.module_id
+WHERE lessons.id = $1;
+
+-- name: GetQuizCourseID :one
+SELECT modules.course_id
+FROM quizzes
+    JOIN modules ON modules.id = quizzes.module_id
+WHERE quizzes.id = $1;
+
+-- name: GetThreadCourseID :one
+SELECT course_id
+FROM forum_threads
+WHERE id = $1;
+
+-- name: GetPostCourseID :one
+SELECT forum_threads.course_id
+FROM forum_posts
+    JOIN forum_threads ON forum_threads.id = forum_posts.thread_id
+WHERE forum_posts.id = $1;
@@ -0,0 +1,146 @@
+-- name: ScheduleUserDeletion :execrows
+UPDATE users
+SET deleted_at = $1,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NULL;
+
+-- name: CancelUserDeletion :execrows
+-- Restores an account whose grace period has not run out yet
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NOT NULL
+    AND anonymized_at IS NULL;
+
+-- name: CountOwnedCourses :one
+-- Courses that have to be handed to another instructor before the account
+-- can be deleted
+SELECT COUNT(*)
+FROM courses
+WHERE instructor_id = $1
+    AND deleted_at IS NULL;
+
+-- name: ListUsersDueForAnonymization :many
+SELECT id
+FROM users
+WHERE deleted_at <= $1
+    AND anonymized_at IS NULL
+ORDER BY deleted_at
+LIMIT $2;
+
+-- name: AnonymizeUser :execrows
+-- Overwrites the personal data of a deleted account. The row stays behind as
+-- the "Deleted user" placeholder for forum content and grades.
+UPDATE users
+SET email = 'deleted-' || id::text || '@deleted.invalid',
+    email_verified = FALSE,
+    email_verification_token = NULL,
+    email_verified_at = NULL,
+    email_verification_sent_at = NULL,
+    password_hash = '',
+    first_name = 'Deleted',
+    last_name = 'user',
+    display_name = 'Deleted user',
+    avatar_url = NULL,
+    bio = NULL,
+    phone = NULL,
+    date_of_birth = NULL,
+    gender = NULL,
+    country = NULL,
+    timezone = NULL,
+    preferred_language = NULL,
+    is_active = FALSE,
+    two_factor_enabled = FALSE,
+    two_factor_secret = NULL,
+    backup_codes = NULL,
+    metadata = NULL,
+    anonymized_at = $1,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NOT NULL
+    AND anonymized_at IS NULL;
+
+-- name: AnonymizeUserSessions :execrows
+UPDATE user_sessions
+SET ip_address = NULL,
+    device_name = NULL,
+    device_type = NULL,
+    browser = NULL,
+    browser_version = NULL,
+    os = NULL,
+    os_version = NULL,
+    location = NULL,
+    is_active = FALSE,
+    revoked_at = COALESCE(revoked_at, $1),
+    revoked_reason = COALESCE(revoked_reason, 'Account deleted')
+WHERE user_id = $2;
+
+-- name: AnonymizeUserAnalyticsEvents :execrows
+-- Detaches events from the account so only aggregate statistics remain
+UPDATE analytics_events
+SET user_id = NULL,
+    session_id = NULL,
+    ip_address = NULL,
+    user_agent = NULL,
+    referrer_url = NULL
+WHERE user_id = $1;
+
+-- name: PurgeUserCredentials :exec
+-- Removes sign-in methods and login history that identify the account
+WITH identities AS (
+    DELETE FROM user_identities
+    WHERE user_identities.user_id = $1
+),
+access_tokens AS (
+    DELETE FROM personal_access_tokens
+    WHERE personal_access_tokens.user_id = $1
+),
+links AS (
+    DELETE FROM magic_links
+    WHERE magic_links.user_id = $1
+),
+attempts AS (
+    DELETE FROM login_attempts
+    WHERE login_attempts.user_id = $1
+),
+deletion_confirmations AS (
+    DELETE FROM account_deletion_confirmations
+    WHERE account_deletion_confirmations.user_id = $1
+)
+DELETE FROM password_history
+WHERE password_history.user_id = $1;
+
+-- name: DeleteUserAvatarFiles :many
+UPDATE file_uploads
+SET deleted_at = $1
+WHERE uploaded_by = $2
+    AND file_type = 'avatar'
+    AND deleted_at IS NULL
+RETURNING storage_path;
+
+-- name: DeleteUserDataArchives :many
+-- Exports hold a copy of the personal data, so they go too
+DELETE FROM data_archives
+WHERE user_id = $1
+RETURNING file_path;
+
+-- name: CreateAccountDeletionConfirmation :exec
+INSERT INTO account_deletion_confirmations (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: InvalidateAccountDeletionConfirmations :exec
+UPDATE account_deletion_confirmations
+SET used_at = $1
+WHERE user_id = $2
+    AND used_at IS NULL;
+
+-- name: ConsumeAccountDeletionConfirmation :execrows
+-- Uses up an unexpired confirmation issued to the user
+UPDATE account_deletion_confirmations
+SET used_at = sqlc.arg(now)
+WHERE token_hash = sqlc.arg(token_hash)
+    AND user_id = sqlc.arg(user_id)
+    AND used_at IS NULL
+    AND expires_at > sqlc.arg(now);
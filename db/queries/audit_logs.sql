@@ -0,0 +1,12 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (
+        user_id,
+        action,
+        resource_type,
+        resource_id,
+        old_values,
+        new_values,
+        ip_address,
+        user_agent
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
@@ -0,0 +1,233 @@
+-- name: SearchCourses :many
+-- A page of the public catalog matching the search text and filters. The
+-- text is parsed once per search configuration and matched against the
+-- courses using that configuration.
+-- Without search text rank is 0 and the highlights are NULL. max_price is
+-- exclusive so it lines up with the price range facets.
+SELECT sqlc.embed(c),
+    page.rank,
+    ts_headline(
+        page.config,
+        c.title,
+        page.query,
+        'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'
+    ) AS title_highlight,
+    ts_headline(
+        page.config,
+        COALESCE(c.description, ''),
+        page.query,
+        'MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" ... ", StartSel=<mark>, StopSel=</mark>'
+    ) AS description_highlight
+FROM (
+        SELECT c.id,
+            d.config,
+            q.query,
+            COALESCE(ts_rank_cd(d.document, q.query, 32), 0)::real AS rank,
+            row_number() OVER (
+                ORDER BY CASE
+                        WHEN sqlc.arg(sort)::text = 'relevance' THEN ts_rank_cd(d.document, q.query, 32)
+                    END DESC NULLS LAST,
+                    CASE
+                        WHEN sqlc.arg(sort)::text = 'rating' THEN c.rating_average
+                    END DESC NULLS LAST,
+                    CASE
+                        WHEN sqlc.arg(sort)::text = 'popular' THEN c.enrolled_count
+                    END DESC NULLS LAST,
+                    c.published_at DESC NULLS LAST,
+                    c.id
+            ) AS position
+        FROM courses c
+            JOIN course_search_documents d ON d.course_id = c.id
+            JOIN (
+                SELECT config,
+                    websearch_to_tsquery(config, sqlc.narg(query)::text) AS query
+                FROM course_search_configs() AS config
+            ) q ON q.config = d.config
+        WHERE c.is_published = TRUE
+            AND c.archived_at IS NULL
+            AND c.deleted_at IS NULL
+            AND (
+                sqlc.narg(query)::text IS NULL
+                OR d.document @@ q.query
+            )
+            AND (
+                sqlc.narg(category)::text IS NULL
+                OR c.category = sqlc.narg(category)
+            )
+            AND (
+                sqlc.narg(sub_category)::text IS NULL
+                OR c.sub_category = sqlc.narg(sub_category)
+            )
+            AND (
+                sqlc.narg(level)::text IS NULL
+                OR c.level = sqlc.narg(level)
+            )
+            AND (
+                sqlc.narg(language)::text IS NULL
+                OR c.language = sqlc.narg(language)
+            )
+            AND (
+                sqlc.narg(is_free)::boolean IS NULL
+                OR c.is_free = sqlc.narg(is_free)
+            )
+            AND (
+                sqlc.narg(min_price)::numeric IS NULL
+                OR COALESCE(c.price, 0) >= sqlc.narg(min_price)
+            )
+            AND (
+                sqlc.narg(max_price)::numeric IS NULL
+                OR COALESCE(c.price, 0) < sqlc.narg(max_price)
+            )
+        ORDER BY position
+        LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset)
+    ) page
+    JOIN courses c ON c.id = page.id
+ORDER BY page.position;
+
+-- name: SearchCourseFacets :many
+-- Result counts per facet value for the same search as SearchCourses. Each
+-- facet applies every filter except its own, so clients can offer the other
+-- values of a facet that is already filtered on. The 'total' row counts the
+-- results with every filter applied.
+WITH matches AS (
+    SELECT c.category,
+        c.sub_category,
+        c.level,
+        c.language,
+        c.is_free,
+        CASE
+            WHEN COALESCE(c.price, 0) < 25 THEN '0-25'
+            WHEN c.price < 50 THEN '25-50'
+            WHEN c.price < 100 THEN '50-100'
+            WHEN c.price < 200 THEN '100-200'
+            ELSE '200-'
+        END AS price_range,
+        (
+            sqlc.narg(category)::text IS NULL
+            OR c.category = sqlc.narg(category)
+        ) AS category_match,
+        (
+            sqlc.narg(sub_category)::text IS NULL
+            OR c.sub_category = sqlc.narg(sub_category)
+        ) AS sub_category_match,
+        (
+            sqlc.narg(level)::text IS NULL
+            OR c.level = sqlc.narg(level)
+        ) AS level_match,
+        (
+            sqlc.narg(language)::text IS NULL
+            OR c.language = sqlc.narg(language)
+        ) AS language_match,
+        (
+            sqlc.narg(is_free)::boolean IS NULL
+            OR c.is_free = sqlc.narg(is_free)
+        ) AS is_free_match,
+        (
+            (
+                sqlc.narg(min_price)::numeric IS NULL
+                OR COALESCE(c.price, 0) >= sqlc.narg(min_price)
+            )
+            AND (
+                sqlc.narg(max_price)::numeric IS NULL
+                OR COALESCE(c.price, 0) < sqlc.narg(max_price)
+            )
+        ) AS price_match
+    FROM courses c
+        JOIN course_search_documents d ON d.course_id = c.id
+        JOIN (
+            SELECT config,
+                websearch_to_tsquery(config, sqlc.narg(query)::text) AS query
+            FROM course_search_configs() AS config
+        ) q ON q.config = d.config
+    WHERE c.is_published = TRUE
+        AND c.archived_at IS NULL
+        AND c.deleted_at IS NULL
+        AND (
+            sqlc.narg(query)::text IS NULL
+            OR d.document @@ q.query
+        )
+)
+SELECT 'total'::text AS facet,
+    ''::text AS value,
+    count(*) AS count
+FROM matches
+WHERE category_match
+    AND sub_category_match
+    AND level_match
+    AND language_match
+    AND is_free_match
+    AND price_match
+UNION ALL
+SELECT 'category',
+    category,
+    count(*)
+FROM matches
+WHERE category IS NOT NULL
+    AND sub_category_match
+    AND level_match
+    AND language_match
+    AND is_free_match
+    AND price_match
+GROUP BY category
+UNION ALL
+SELECT 'sub_category',
+    sub_category,
+    count(*)
+FROM matches
+WHERE sub_category IS NOT NULL
+    AND category_match
+    AND level_match
+    AND language_match
+    AND is_free_match
+    AND price_match
+GROUP BY sub_category
+UNION ALL
+SELECT 'level',
+    level,
+    count(*)
+FROM matches
+WHERE level IS NOT NULL
+    AND category_match
+    AND sub_category_match
+    AND language_match
+    AND is_free_match
+    AND price_match
+GROUP BY level
+UNION ALL
+SELECT 'language',
+    language,
+    count(*)
+FROM matches
+WHERE language IS NOT NULL
+    AND category_match
+    AND sub_category_match
+    AND level_match
+    AND is_free_match
+    AND price_match
+GROUP BY language
+UNION ALL
+SELECT 'is_free',
+    is_free::text,
+    count(*)
+FROM matches
+WHERE is_free IS NOT NULL
+    AND category_match
+    AND sub_category_match
+    AND level_match
+    AND language_match
+    AND price_match
+GROUP BY is_free
+UNION ALL
+SELECT 'price_range',
+    price_range,
+    count(*)
+FROM matches
+WHERE category_match
+    AND sub_category_match
+    AND level_match
+    AND language_match
+    AND is_free_match
+GROUP BY price_range
+ORDER BY facet,
+    count DESC,
+    value;
@@ -0,0 +1,183 @@
+-- name: CreateCourse :one
+INSERT INTO courses (
+        code,
+        title,
+        slug,
+        description,
+        syllabus,
+        instructor_id,
+        category,
+        sub_category,
+        level,
+        language,
+        thumbnail_url,
+        intro_video_url,
+        duration_hours,
+        price,
+        currency,
+        is_free,
+        enrollment_type,
+        max_students,
+        prerequisites,
+        tags,
+        learning_outcomes,
+        requirements,
+        target_audience,
+        completion_certificate,
+        allow_discussion,
+        allow_download
+    )
+VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        $17,
+        $18,
+        $19,
+        $20,
+        $21,
+        $22,
+        $23,
+        $24,
+        $25,
+        $26
+    )
+RETURNING *;
+
+-- name: GetCourseByID :one
+SELECT *
+FROM courses
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: GetCourseBySlug :one
+SELECT *
+FROM courses
+WHERE slug = $1
+    AND deleted_at IS NULL;
+
+-- name: ListCourseSlugs :many
+-- Slugs taken by a base slug and its numbered variants. Deleted courses keep
+-- their slug, so they are included.
+SELECT slug
+FROM courses
+WHERE slug = sqlc.arg(slug)::text
+    OR slug LIKE sqlc.arg(slug)::text || '-%';
+
+-- name: CourseCodeExists :one
+SELECT EXISTS (
+        SELECT 1
+        FROM courses
+        WHERE UPPER(code) = UPPER(sqlc.arg(code)::text)
+            AND id <> sqlc.arg(exclude_id)
+    )::boolean AS code_exists;
+
+-- name: ListInstructorCourses :many
+-- Courses the user owns or is on the staff of, drafts and archived included.
+-- Sorted and paged through pagination.Request.
+SELECT *
+FROM courses
+WHERE deleted_at IS NULL
+    AND (
+        instructor_id = $1
+        OR id IN (
+            SELECT course_id
+            FROM course_staff
+            WHERE user_id = $1
+        )
+    );
+
+-- name: UpdateCourse :one
+UPDATE courses
+SET code = $1,
+    title = $2,
+    description = $3,
+    syllabus = $4,
+    category = $5,
+    sub_category = $6,
+    level = $7,
+    language = $8,
+    thumbnail_url = $9,
+    intro_video_url = $10,
+    duration_hours = $11,
+    price = $12,
+    currency = $13,
+    is_free = $14,
+    enrollment_type = $15,
+    max_students = $16,
+    prerequisites = $17,
+    tags = $18,
+    learning_outcomes = $19,
+    requirements = $20,
+    target_audience = $21,
+    completion_certificate = $22,
+    allow_discussion = $23,
+    allow_download = $24,
+    updated_at = $25
+WHERE id = $26
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishCourse :one
+-- published_at keeps the date the course was first published
+UPDATE courses
+SET is_published = TRUE,
+    published_at = COALESCE(published_at, sqlc.arg(now)),
+    updated_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id)
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UnpublishCourse :one
+UPDATE courses
+SET is_published = FALSE,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ArchiveCourse :one
+UPDATE courses
+SET archived_at = COALESCE(archived_at, sqlc.arg(now)),
+    updated_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id)
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UnarchiveCourse :one
+UPDATE courses
+SET archived_at = NULL,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteCourse :execrows
+UPDATE courses
+SET is_published = FALSE,
+    deleted_at = $1,
+    updated_at = $1
+WHERE id = $2
+    AND deleted_at IS NULL;
+
+-- name: LockCourse :one
+-- Serialises changes to a course's outline: new modules and lessons are
+-- appended after the current last one and reordering rewrites every position
+SELECT id
+FROM courses
+WHERE id = sqlc.arg(course_id)
+    AND deleted_at IS NULL
+FOR UPDATE;
@@ -0,0 +1,84 @@
+-- name: CreateDataArchive :one
+INSERT INTO data_archives (user_id, archive_type, status)
+VALUES ($1, $2, 'pending')
+RETURNING *;
+
+-- name: GetOpenDataArchive :one
+-- The user's export that is still queued or being built, if any
+SELECT *
+FROM data_archives
+WHERE user_id = $1
+    AND archive_type = $2
+    AND status IN ('pending', 'processing')
+ORDER BY requested_at DESC
+LIMIT 1;
+
+-- name: GetUserDataArchive :one
+SELECT *
+FROM data_archives
+WHERE id = $1
+    AND user_id = $2;
+
+-- name: ClaimDataArchive :one
+-- Takes the oldest queued export. Exports claimed over an hour ago and still
+-- processing are assumed abandoned by a crashed worker and taken again.
+UPDATE data_archives
+SET status = 'processing',
+    claimed_at = NOW()
+WHERE id = (
+        SELECT id
+        FROM data_archives
+        WHERE archive_type = $1
+            AND (
+                status = 'pending'
+                OR (
+                    status = 'processing'
+                    AND claimed_at < NOW() - INTERVAL '1 hour'
+                )
+            )
+        ORDER BY requested_at
+        LIMIT 1 FOR UPDATE SKIP LOCKED
+    )
+RETURNING *;
+
+-- name: CompleteDataArchive :exec
+UPDATE data_archives
+SET status = 'completed',
+    file_path = $1,
+    file_size = $2,
+    completed_at = $3,
+    expires_at = $4,
+    download_token_hash = $5,
+    download_count = 0,
+    error_message = NULL
+WHERE id = $6;
+
+-- name: FailDataArchive :exec
+UPDATE data_archives
+SET status = 'failed',
+    completed_at = $1,
+    error_message = $2
+WHERE id = $3;
+
+-- name: GetDataArchiveByToken :one
+SELECT *
+FROM data_archives
+WHERE download_token_hash = $1
+    AND status = 'completed';
+
+-- name: RecordDataArchiveDownload :execrows
+-- Counts a download unless the link has expired or is used up
+UPDATE data_archives
+SET download_count = download_count + 1
+WHERE id = sqlc.arg(id)
+    AND expires_at > sqlc.arg(now)
+    AND download_count < sqlc.arg(max_downloads)::int;
+
+-- name: ExpireDataArchives :many
+-- Retires completed exports past their expiry, returning the files to delete
+UPDATE data_archives
+SET status = 'expired',
+    download_token_hash = NULL
+WHERE status = 'completed'
+    AND expires_at <= $1
+RETURNING file_path;
@@ -0,0 +1,75 @@
+-- Each query returns one JSON object per row for the GDPR data export
+
+-- name: ExportUserCourseRatings :many
+SELECT to_jsonb(course_ratings) AS data
+FROM course_ratings
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportUserEnrollments :many
+SELECT to_jsonb(enrollments) AS data
+FROM enrollments
+WHERE user_id = $1
+ORDER BY enrolled_at;
+
+-- name: ExportUserFileUploads :many
+SELECT to_jsonb(file_uploads) AS data
+FROM file_uploads
+WHERE uploaded_by = $1
+ORDER BY uploaded_at;
+
+-- name: ExportUserForumPosts :many
+SELECT to_jsonb(forum_posts) AS data
+FROM forum_posts
+WHERE author_id = $1
+ORDER BY created_at;
+
+-- name: ExportUserForumThreads :many
+SELECT to_jsonb(forum_threads) AS data
+FROM forum_threads
+WHERE author_id = $1
+ORDER BY created_at;
+
+-- name: ExportUserForumVotes :many
+SELECT to_jsonb(forum_votes) AS data
+FROM forum_votes
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportUserLessonProgress :many
+SELECT to_jsonb(lesson_progress) AS data
+FROM lesson_progress
+WHERE user_id = $1
+ORDER BY started_at;
+
+-- name: ExportUserNotifications :many
+SELECT to_jsonb(notifications) AS data
+FROM notifications
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportUserProfile :many
+-- Profile without credentials or second factor secrets
+SELECT to_jsonb(users) - 'password_hash' - 'two_factor_secret' - 'backup_codes' - 'email_verification_token' AS data
+FROM users
+WHERE id = $1;
+
+-- name: ExportUserQuizAnswers :many
+SELECT to_jsonb(student_answers) AS data
+FROM student_answers
+    JOIN quiz_attempts ON quiz_attempts.id = student_answers.attempt_id
+WHERE quiz_attempts.user_id = $1
+ORDER BY student_answers.created_at;
+
+-- name: ExportUserQuizAttempts :many
+SELECT to_jsonb(quiz_attempts) AS data
+FROM quiz_attempts
+WHERE user_id = $1
+ORDER BY started_at;
+
+-- name: ExportUserSessions :many
+-- Sessions without token hashes
+SELECT to_jsonb(user_sessions) - 'refresh_token_hash' - 'access_token_hash' AS data
+FROM user_sessions
+WHERE user_id = $1
+ORDER BY created_at;
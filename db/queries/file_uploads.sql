@@ -0,0 +1,57 @@
+-- name: CreateFileUpload :exec
+INSERT INTO file_uploads (
+        id,
+        uploaded_by,
+        file_name,
+        file_size,
+        file_type,
+        mime_type,
+        storage_path,
+        storage_provider,
+        url,
+        thumbnail_url,
+        metadata
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: DeleteOtherUserFiles :many
+-- Soft deletes the user's other files of a type, e.g. replaced avatars
+UPDATE file_uploads
+SET deleted_at = $1
+WHERE uploaded_by = $2
+    AND file_type = $3
+    AND id <> $4
+    AND deleted_at IS NULL
+RETURNING storage_path;
+
+-- name: ListCourseFileUploads :many
+-- The files among ids that lessons of the course may use: files uploaded by
+-- the caller or one of the course's instructors, or already used by one of
+-- its lessons
+SELECT f.id,
+    f.mime_type
+FROM file_uploads f
+WHERE f.id = ANY(sqlc.arg(ids)::uuid [])
+    AND f.deleted_at IS NULL
+    AND (
+        f.uploaded_by = sqlc.arg(user_id)
+        OR f.uploaded_by IN (
+            SELECT courses.instructor_id
+            FROM courses
+            WHERE courses.id = sqlc.arg(course_id)
+            UNION
+            SELECT course_staff.user_id
+            FROM course_staff
+            WHERE course_staff.course_id = sqlc.arg(course_id)
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM lessons l
+                JOIN modules m ON m.id = l.module_id
+            WHERE m.course_id = sqlc.arg(course_id)
+                AND f.id::text IN (
+                    l.content->>'fileUploadId',
+                    l.content#>>'{source,fileUploadId}'
+                )
+        )
+    );
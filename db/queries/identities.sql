@@ -0,0 +1,29 @@
+-- name: GetUserIdentity :one
+SELECT *
+FROM user_identities
+WHERE provider = $1
+    AND subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: UpdateUserIdentityLogin :exec
+UPDATE user_identities
+SET email = $1,
+    last_login_at = $2
+WHERE id = $3;
+
+-- name: CreateOIDCLoginCode :exec
+INSERT INTO oidc_login_codes (user_id, provider, code_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: ConsumeOIDCLoginCode :one
+-- Marks an unexpired code used and returns its user, so a code is redeemed
+-- at most once
+UPDATE oidc_login_codes
+SET used_at = $1
+WHERE code_hash = $2
+    AND used_at IS NULL
+    AND expires_at > $1
+RETURNING user_id;
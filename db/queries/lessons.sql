@@ -0,0 +1,113 @@
+-- name: ListModuleLessons :many
+SELECT *
+FROM lessons
+WHERE module_id = $1
+ORDER BY order_index;
+
+-- name: ListPublishedModuleLessons :many
+SELECT *
+FROM lessons
+WHERE module_id = $1
+    AND is_published = TRUE
+ORDER BY order_index;
+
+-- name: ListCourseLessonPlacements :many
+-- Which module each lesson of the course is in
+SELECT lessons.id,
+    lessons.module_id
+FROM lessons
+    JOIN modules ON modules.id = lessons.module_id
+WHERE modules.course_id = $1;
+
+-- name: GetLesson :one
+SELECT *
+FROM lessons
+WHERE id = $1;
+
+-- name: CreateLesson :one
+-- Appends the lesson after the module's last lesson. Callers hold LockCourse.
+INSERT INTO lessons (
+        module_id,
+        title,
+        description,
+        content_type,
+        content,
+        order_index,
+        duration_minutes,
+        is_preview,
+        is_published,
+        allow_comments,
+        attachments,
+        transcript
+    )
+VALUES (
+        sqlc.arg(module_id),
+        sqlc.arg(title),
+        sqlc.narg(description),
+        sqlc.arg(content_type),
+        sqlc.arg(content),
+        (
+            SELECT COALESCE(MAX(order_index) + 1, 0)
+            FROM lessons
+            WHERE module_id = sqlc.arg(module_id)
+        ),
+        sqlc.narg(duration_minutes),
+        sqlc.arg(is_preview),
+        sqlc.arg(is_published),
+        sqlc.arg(allow_comments),
+        sqlc.arg(attachments),
+        sqlc.narg(transcript)
+    )
+RETURNING *;
+
+-- name: UpdateLesson :one
+-- Replaces the lesson's attributes. The flags keep their stored values when
+-- NULL, so an update that leaves them out does not change them.
+UPDATE lessons
+SET title = sqlc.arg(title),
+    description = sqlc.arg(description),
+    content_type = sqlc.arg(content_type),
+    content = sqlc.arg(content),
+    duration_minutes = sqlc.arg(duration_minutes),
+    is_preview = COALESCE(sqlc.narg(is_preview)::boolean, is_preview),
+    is_published = COALESCE(sqlc.narg(is_published)::boolean, is_published),
+    allow_comments = COALESCE(sqlc.narg(allow_comments)::boolean, allow_comments),
+    attachments = sqlc.arg(attachments),
+    transcript = sqlc.arg(transcript),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteLesson :execrows
+DELETE FROM lessons
+WHERE id = $1;
+
+-- name: ParkCourseLessons :exec
+-- Moves every lesson of the course to a negative position so SetLessonOrder
+-- can assign the new positions without hitting UNIQUE(module_id, order_index)
+UPDATE lessons
+SET order_index = -order_index - 1
+WHERE module_id IN (
+        SELECT id
+        FROM modules
+        WHERE course_id = $1
+    );
+
+-- name: SetLessonOrder :execrows
+-- Places each lesson in module_ids[i] at positions[i]. Lessons move between
+-- modules of the same course this way.
+UPDATE lessons
+SET module_id = new_order.module_id,
+    order_index = new_order.position,
+    updated_at = sqlc.arg(updated_at)
+FROM unnest(
+        sqlc.arg(lesson_ids)::uuid[],
+        sqlc.arg(module_ids)::uuid[],
+        sqlc.arg(positions)::integer[]
+    ) AS new_order(id, module_id, position)
+WHERE lessons.id = new_order.id
+    AND lessons.module_id IN (
+        SELECT id
+        FROM modules
+        WHERE course_id = sqlc.arg(course_id)
+    );
@@ -0,0 +1,17 @@
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempts (email, user_id, ip_address, succeeded)
+VALUES ($1, $2, $3, $4);
+
+-- name: CountRecentFailedLoginsByIP :one
+SELECT COUNT(*)
+FROM login_attempts
+WHERE ip_address = $1
+    AND succeeded = FALSE
+    AND attempted_at > $2;
+
+
+-- name: PruneLoginAttempts :execrows
+-- Deletes attempts older than the retention period; they are only needed
+-- within the throttling window
+DELETE FROM login_attempts
+WHERE attempted_at < $1;
@@ -0,0 +1,28 @@
+-- name: CreateMagicLink :exec
+INSERT INTO magic_links (user_id, token_hash, device_hash, ip_address, user_agent, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: CountRecentMagicLinks :one
+SELECT COUNT(*)
+FROM magic_links
+WHERE user_id = $1
+    AND created_at > $2;
+
+-- name: GetValidMagicLink :one
+SELECT *
+FROM magic_links
+WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > NOW();
+
+-- name: MarkMagicLinkUsed :execrows
+UPDATE magic_links
+SET used_at = $1
+WHERE id = $2
+    AND used_at IS NULL;
+
+-- name: InvalidateUserMagicLinks :exec
+UPDATE magic_links
+SET used_at = $1
+WHERE user_id = $2
+    AND used_at IS NULL;
@@ -0,0 +1,90 @@
+-- name: ListCourseModules :many
+SELECT *
+FROM modules
+WHERE course_id = $1
+ORDER BY order_index;
+
+-- name: ListPublishedCourseModules :many
+SELECT *
+FROM modules
+WHERE course_id = $1
+    AND is_published = TRUE
+ORDER BY order_index;
+
+-- name: GetModule :one
+SELECT *
+FROM modules
+WHERE id = $1;
+
+-- name: CreateModule :one
+-- Appends the module after the course's last module. Callers hold LockCourse.
+INSERT INTO modules (
+        course_id,
+        title,
+        description,
+        order_index,
+        is_published,
+        unlock_type,
+        unlock_date,
+        prerequisites,
+        estimated_duration_minutes
+    )
+VALUES (
+        sqlc.arg(course_id),
+        sqlc.arg(title),
+        sqlc.narg(description),
+        (
+            SELECT COALESCE(MAX(order_index) + 1, 0)
+            FROM modules
+            WHERE course_id = sqlc.arg(course_id)
+        ),
+        sqlc.arg(is_published),
+        sqlc.arg(unlock_type),
+        sqlc.narg(unlock_date),
+        sqlc.arg(prerequisites),
+        sqlc.narg(estimated_duration_minutes)
+    )
+RETURNING *;
+
+-- name: UpdateModule :one
+-- Replaces the module's attributes. is_published keeps its stored value when
+-- NULL, so an update that leaves the flag out does not publish a draft.
+UPDATE modules
+SET title = sqlc.arg(title),
+    description = sqlc.arg(description),
+    is_published = COALESCE(sqlc.narg(is_published)::boolean, is_published),
+    unlock_type = sqlc.arg(unlock_type),
+    unlock_date = sqlc.arg(unlock_date),
+    prerequisites = sqlc.arg(prerequisites),
+    estimated_duration_minutes = sqlc.arg(estimated_duration_minutes),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteModule :execrows
+-- Deletes the module and, through the foreign key, its lessons
+DELETE FROM modules
+WHERE id = $1;
+
+-- name: RemoveModulePrerequisite :exec
+-- Drops a deleted module from the prerequisites of the other modules
+UPDATE modules
+SET prerequisites = array_remove(prerequisites, sqlc.arg(module_id)::uuid)
+WHERE course_id = sqlc.arg(course_id)
+    AND sqlc.arg(module_id)::uuid = ANY(prerequisites);
+
+-- name: ParkCourseModules :exec
+-- Moves every module of the course to a negative position so SetModuleOrder
+-- can assign the new positions without hitting UNIQUE(course_id, order_index)
+UPDATE modules
+SET order_index = -order_index - 1
+WHERE course_id = $1;
+
+-- name: SetModuleOrder :execrows
+-- Places the modules in the order of module_ids, starting at 0
+UPDATE modules
+SET order_index = new_order.position - 1,
+    updated_at = sqlc.arg(updated_at)
+FROM unnest(sqlc.arg(module_ids)::uuid[]) WITH ORDINALITY AS new_order(id, position)
+WHERE modules.id = new_order.id
+    AND modules.course_id = sqlc.arg(course_id);
@@ -0,0 +1,21 @@
+-- name: CreatePasswordHistory :exec
+INSERT INTO password_history (user_id, password_hash)
+VALUES ($1, $2);
+
+-- name: ListRecentPasswordHashes :many
+SELECT password_hash
+FROM password_history
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: PrunePasswordHistory :exec
+DELETE FROM password_history
+WHERE password_history.user_id = $1
+    AND password_history.id NOT IN (
+        SELECT recent.id
+        FROM password_history AS recent
+        WHERE recent.user_id = $1
+        ORDER BY recent.created_at DESC
+        LIMIT $2
+    );
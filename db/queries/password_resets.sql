@@ -0,0 +1,22 @@
+-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetValidPasswordReset :one
+SELECT *
+FROM password_resets
+WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > NOW();
+
+-- name: MarkPasswordResetUsed :execrows
+UPDATE password_resets
+SET used_at = $1
+WHERE id = $2
+    AND used_at IS NULL;
+
+-- name: InvalidateUserPasswordResets :exec
+UPDATE password_resets
+SET used_at = $1
+WHERE user_id = $2
+    AND used_at IS NULL;
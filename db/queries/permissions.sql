@@ -0,0 +1,27 @@
+-- name: GetUserPermissions :many
+SELECT permissions.resource,
+    permissions.action,
+    permissions.scope,
+    group_permissions.constraints,
+    groups.name AS group_name,
+    groups.priority
+FROM user_groups
+    JOIN groups ON groups.id = user_groups.group_id
+    JOIN group_permissions ON group_permissions.group_id = groups.id
+    JOIN permissions ON permissions.id = group_permissions.permission_id
+WHERE user_groups.user_id = $1
+    AND (
+        user_groups.expires_at IS NULL
+        OR user_groups.expires_at > NOW()
+    )
+ORDER BY groups.priority DESC;
+
+-- name: AddUserToGroup :exec
+INSERT INTO user_groups (user_id, group_id, assigned_by, expires_at)
+SELECT sqlc.arg(user_id),
+    groups.id,
+    sqlc.narg(assigned_by),
+    sqlc.narg(expires_at)
+FROM groups
+WHERE groups.name = sqlc.arg(group_name)
+ON CONFLICT (user_id, group_id) DO NOTHING;
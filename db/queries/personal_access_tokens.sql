@@ -0,0 +1,47 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT personal_access_tokens.id,
+    personal_access_tokens.user_id,
+    personal_access_tokens.scopes,
+    personal_access_tokens.expires_at,
+    personal_access_tokens.last_used_at,
+    users.email,
+    users.email_verified,
+    users.must_change_password
+FROM personal_access_tokens
+    JOIN users ON users.id = personal_access_tokens.user_id
+WHERE personal_access_tokens.token_hash = $1
+    AND personal_access_tokens.revoked_at IS NULL
+    AND users.deleted_at IS NULL
+    AND users.suspended_at IS NULL
+    AND users.is_active IS NOT FALSE;
+
+-- name: ListUserPersonalAccessTokens :many
+SELECT *
+FROM personal_access_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = $1
+WHERE id = $2
+    AND revoked_at IS NULL;
+
+-- name: RevokeUserPersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = $1
+WHERE id = $2
+    AND user_id = $3
+    AND revoked_at IS NULL;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = $1,
+    last_used_ip = $2
+WHERE id = $3;
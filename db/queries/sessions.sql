@@ -70,3 +70,58 @@ SELECT *
 FROM user_sessions
 WHERE refresh_token_hash = $1
     AND is_active = TRUE;
+
+-- name: RotateRefreshToken :execrows
+UPDATE user_sessions
+SET refresh_token_hash = sqlc.arg(new_refresh_token_hash),
+    access_token_hash = sqlc.arg(access_token_hash),
+    last_accessed_at = sqlc.arg(last_accessed_at),
+    expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id)
+    AND refresh_token_hash = sqlc.arg(refresh_token_hash)
+    AND is_active = TRUE;
+
+-- name: CreateRotatedRefreshToken :exec
+INSERT INTO rotated_refresh_tokens (session_id, token_hash)
+VALUES ($1, $2);
+
+-- name: GetRotatedRefreshToken :one
+SELECT *
+FROM rotated_refresh_tokens
+WHERE token_hash = $1;
+
+-- name: RevokeUserSessions :exec
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE user_id = $3
+    AND is_active = TRUE;
+
+-- name: RevokeUserSession :execrows
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE id = $3
+    AND user_id = $4
+    AND is_active = TRUE;
+
+-- name: RevokeOtherUserSessions :execrows
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE user_id = $3
+    AND id <> $4
+    AND is_active = TRUE;
+
+-- name: GetSessionStatus :one
+SELECT user_sessions.user_id,
+    user_sessions.is_active AS session_active,
+    users.is_active AS user_active,
+    users.suspended_at,
+    users.deleted_at
+FROM user_sessions
+    JOIN users ON users.id = user_sessions.user_id
+WHERE user_sessions.id = $1;
@@ -11,3 +11,140 @@ SELECT * FROM users WHERE id = $1;
 INSERT INTO users (id, email, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16);
 
+-- name: GetUserPreferredLanguage :one
+SELECT preferred_language FROM users WHERE id = $1;
+
+-- name: GetUserRole :one
+SELECT groups.name
+FROM user_groups
+    JOIN groups ON groups.id = user_groups.group_id
+WHERE user_groups.user_id = $1
+    AND groups.name IN ('admin', 'instructor', 'student')
+    AND (
+        user_groups.expires_at IS NULL
+        OR user_groups.expires_at > NOW()
+    )
+ORDER BY groups.priority DESC
+LIMIT 1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $1,
+    password_changed_at = $2,
+    must_change_password = FALSE
+WHERE id = $3;
+
+-- name: UpdateUserPasswordHash :exec
+-- Replaces the hash of an unchanged password, e.g. with a stronger algorithm
+UPDATE users
+SET password_hash = sqlc.arg(new_password_hash)
+WHERE id = sqlc.arg(id)
+    AND password_hash = sqlc.arg(old_password_hash);
+
+-- name: UpdateUserProfile :execrows
+UPDATE users
+SET first_name = $1,
+    last_name = $2,
+    display_name = $3,
+    bio = $4,
+    phone = $5,
+    date_of_birth = $6,
+    gender = $7,
+    country = $8,
+    timezone = $9,
+    preferred_language = $10
+WHERE id = $11
+    AND deleted_at IS NULL;
+
+-- name: UpdateUserAvatar :exec
+UPDATE users
+SET avatar_url = $1
+WHERE id = $2;
+
+-- name: GetUserByEmailVerificationToken :one
+SELECT *
+FROM users
+WHERE email_verification_token = $1
+    AND deleted_at IS NULL;
+
+-- name: SetEmailVerificationToken :exec
+UPDATE users
+SET email_verification_token = $1,
+    email_verification_sent_at = $2
+WHERE id = $3;
+
+-- name: MarkEmailVerified :exec
+UPDATE users
+SET email_verified = TRUE,
+    email_verified_at = $1,
+    email_verification_token = NULL,
+    email_verification_sent_at = NULL
+WHERE id = $2;
+
+-- name: SetTwoFactorSecret :exec
+UPDATE users
+SET two_factor_secret = $1,
+    two_factor_enabled = FALSE,
+    backup_codes = NULL
+WHERE id = $2;
+
+-- name: EnableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled = TRUE,
+    backup_codes = $1
+WHERE id = $2;
+
+-- name: DisableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled = FALSE,
+    two_factor_secret = NULL,
+    backup_codes = NULL
+WHERE id = $1;
+
+-- name: AcceptTOTPCounter :execrows
+-- Records the time step of an accepted TOTP code. No row is updated when a
+-- code for this step or a later one was accepted before, i.e. on replay.
+UPDATE users
+SET two_factor_last_counter = sqlc.arg(counter)
+WHERE id = sqlc.arg(id)
+    AND (
+        two_factor_last_counter IS NULL
+        OR two_factor_last_counter < sqlc.arg(counter)
+    );
+
+-- name: UpdateBackupCodes :exec
+UPDATE users
+SET backup_codes = $1
+WHERE id = $2;
+
+-- name: ConsumeBackupCode :execrows
+UPDATE users
+SET backup_codes = array_remove(backup_codes, sqlc.arg(code_hash)::text)
+WHERE id = sqlc.arg(id)
+    AND sqlc.arg(code_hash)::text = ANY(backup_codes);
+
+-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = COALESCE(failed_login_attempts, 0) + 1
+WHERE id = $1
+RETURNING failed_login_attempts;
+
+-- name: LockUserAccount :exec
+UPDATE users
+SET failed_login_locked_until = $1
+WHERE id = $2;
+
+-- name: RecordSuccessfulLogin :exec
+UPDATE users
+SET failed_login_attempts = 0,
+    failed_login_locked_until = NULL,
+    login_count = COALESCE(login_count, 0) + 1,
+    last_login_at = $1
+WHERE id = $2;
+
+-- name: UnlockUserAccount :execrows
+UPDATE users
+SET failed_login_attempts = 0,
+    failed_login_locked_until = NULL
+WHERE id = $1
+    AND deleted_at IS NULL;
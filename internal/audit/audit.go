@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Entry is one record of the audit trail
+type Entry struct {
+	ActorID      uuid.UUID // The user who performed the action
+	Action       string    // e.g. impersonation.start
+	ResourceType string
+	ResourceID   uuid.UUID
+	OldValues    any // Encoded as JSON when set
+	NewValues    any // Encoded as JSON when set
+	IPAddress    string
+	UserAgent    string
+}
+
+// FromRequest returns a copy of the entry with the client IP and user agent
+// of r filled in
+func (e Entry) FromRequest(r *http.Request) Entry {
+	e.IPAddress = utils.GetClientIP(r)
+	e.UserAgent = r.UserAgent()
+	return e
+}
+
+// Logger writes entries to the audit_logs table
+type Logger struct {
+	queries *database.Queries
+}
+
+// New creates a new Logger instance
+func New(queries *database.Queries) *Logger {
+	return &Logger{queries: queries}
+}
+
+// WithTx returns a Logger that writes within the given transaction
+func (l *Logger) WithTx(tx *sql.Tx) *Logger {
+	return &Logger{queries: l.queries.WithTx(tx)}
+}
+
+// Record writes an entry to the audit trail
+func (l *Logger) Record(ctx context.Context, entry Entry) error {
+	oldValues, err := encode(entry.OldValues)
+	if err != nil {
+		return err
+	}
+	newValues, err := encode(entry.NewValues)
+	if err != nil {
+		return err
+	}
+
+	err = l.queries.CreateAuditLog(ctx, database.CreateAuditLogParams{
+		UserID:       uuid.NullUUID{UUID: entry.ActorID, Valid: entry.ActorID != uuid.Nil},
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   uuid.NullUUID{UUID: entry.ResourceID, Valid: entry.ResourceID != uuid.Nil},
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IpAddress:    utils.InetFromIP(net.ParseIP(entry.IPAddress)),
+		UserAgent:    sql.NullString{String: entry.UserAgent, Valid: entry.UserAgent != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("error writing audit log %s: %w", entry.Action, err)
+	}
+	return nil
+}
+
+func encode(values any) (pqtype.NullRawMessage, error) {
+	if values == nil {
+		return pqtype.NullRawMessage{}, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return pqtype.NullRawMessage{}, fmt.Errorf("error encoding audit values: %w", err)
+	}
+	return pqtype.NullRawMessage{RawMessage: encoded, Valid: true}, nil
+}
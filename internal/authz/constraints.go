@@ -0,0 +1,79 @@
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Constraints narrow a group permission beyond its scope. They are stored in
+// group_permissions.constraints, e.g.
+//
+//	{"valid_until": "2025-12-31T23:59:59Z", "ip_ranges": ["10.0.0.0/8"]}
+//
+// An empty object places no restriction on the grant.
+type Constraints struct {
+	// ValidFrom and ValidUntil limit the grant to a time window
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// IPRanges limits the grant to requests from the given CIDR blocks
+	IPRanges []string `json:"ip_ranges,omitempty"`
+	// ResourceIDs limits the grant to specific resources, e.g. course IDs
+	ResourceIDs []uuid.UUID `json:"resource_ids,omitempty"`
+
+	networks []*net.IPNet
+}
+
+// ParseConstraints decodes a constraints document. Unknown keys are rejected
+// so that a constraint this code does not understand never widens a grant.
+func ParseConstraints(raw []byte) (Constraints, error) {
+	var c Constraints
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return c, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return Constraints{}, fmt.Errorf("error decoding constraints: %w", err)
+	}
+
+	for _, cidr := range c.IPRanges {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return Constraints{}, fmt.Errorf("invalid ip range %q: %w", cidr, err)
+		}
+		c.networks = append(c.networks, network)
+	}
+
+	return c, nil
+}
+
+// Allow reports whether a request with the given attributes satisfies the constraints
+func (c Constraints) Allow(attrs Attributes) bool {
+	now := time.Now()
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return false
+	}
+	if c.ValidUntil != nil && now.After(*c.ValidUntil) {
+		return false
+	}
+
+	if len(c.networks) > 0 {
+		ip := net.ParseIP(attrs.ClientIP)
+		if ip == nil || !slices.ContainsFunc(c.networks, func(n *net.IPNet) bool { return n.Contains(ip) }) {
+			return false
+		}
+	}
+
+	if len(c.ResourceIDs) > 0 && !slices.Contains(c.ResourceIDs, attrs.ResourceID) {
+		return false
+	}
+
+	return true
+}
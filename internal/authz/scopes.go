@@ -0,0 +1,68 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TokenScope limits an API key to a permission, written as
+// "resource:action:scope" after a row of the permissions table
+type TokenScope struct {
+	Resource string
+	Action   string
+	Scope    string
+}
+
+// ParseTokenScope parses a "resource:action:scope" string
+func ParseTokenScope(value string) (TokenScope, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return TokenScope{}, fmt.Errorf("invalid scope %q: expected resource:action:scope", value)
+	}
+	if _, ok := scopeRank[parts[2]]; !ok {
+		return TokenScope{}, fmt.Errorf("invalid scope %q: unknown permission scope %q", value, parts[2])
+	}
+	return TokenScope{Resource: parts[0], Action: parts[1], Scope: parts[2]}, nil
+}
+
+// String formats the scope as "resource:action:scope"
+func (s TokenScope) String() string {
+	return s.Resource + ":" + s.Action + ":" + s.Scope
+}
+
+type tokenScopesKey struct{}
+
+// WithTokenScopes returns a copy of ctx in which permission checks are
+// limited to the given scopes, on top of what the user's groups grant.
+// Unparseable scopes grant nothing.
+func WithTokenScopes(ctx context.Context, scopes []string) context.Context {
+	parsed := make([]TokenScope, 0, len(scopes))
+	for _, value := range scopes {
+		if scope, err := ParseTokenScope(value); err == nil {
+			parsed = append(parsed, scope)
+		}
+	}
+	return context.WithValue(ctx, tokenScopesKey{}, parsed)
+}
+
+// limitToTokenScopes narrows a granted scope to the widest token scope for
+// resource and action. Without token scopes in ctx the grant is unchanged.
+func limitToTokenScopes(ctx context.Context, resource, action, granted string) string {
+	scopes, ok := ctx.Value(tokenScopesKey{}).([]TokenScope)
+	if !ok {
+		return granted
+	}
+
+	allowed := ""
+	for _, scope := range scopes {
+		if scope.Resource == resource && scope.Action == action && scopeRank[scope.Scope] > scopeRank[allowed] {
+			allowed = scope.Scope
+		}
+	}
+
+	if scopeRank[allowed] < scopeRank[granted] {
+		return allowed
+	}
+	return granted
+}
@@ -5,21 +5,27 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	Storage  StorageConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	Auth       AuthConfig
+	Storage    StorageConfig
+	Mail       MailConfig
+	Export     ExportConfig
+	Pagination PaginationConfig
+	OIDC       OIDCConfig
 }
 
 type ServerConfig struct {
 	Port         string
 	Environment  string
+	AppURL       string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 }
@@ -34,17 +40,120 @@ type DatabaseConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret          string
 	JWTExpiry          time.Duration
-	RefreshTokenExpiry time.Duration
-	BcryptCost         int
+	RefreshTokenExpiry time.Duration // Idle timeout, extended on every refresh
+	SessionMaxAge      time.Duration // Absolute lifetime, never extended
+	PasswordResetTTL   time.Duration
+
+	// Password hashing
+	PasswordHashAlgorithm string // bcrypt, argon2id
+	BcryptCost            int
+	Argon2Memory          int // KiB
+	Argon2Iterations      int
+	Argon2Parallelism     int
+
+	// Password policy
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordHistorySize   int    // Previous passwords that may not be reused
+	PasswordBreachCheck   bool   // Reject passwords found in the Pwned Passwords corpus
+	PasswordBreachAPIURL  string // Base URL of a Pwned Passwords compatible range API
+
+	// Token signing
+	JWTAlgorithm           string // RS256, EdDSA
+	JWTKeyDir              string
+	JWTKeyRotationInterval time.Duration
+	JWTIssuer              string
+	JWTAudience            []string
+
+	// Email verification
+	EmailVerificationTTL            time.Duration
+	EmailVerificationResendInterval time.Duration
+	UnverifiedEmailPolicy           string // allow, read_only, block
+
+	// Two-factor authentication
+	TwoFactorEncryptionKey string // base64 encoded 32-byte AES key
+	TwoFactorIssuer        string
+	MFATokenTTL            time.Duration
+
+	// Brute-force protection
+	LoginMaxAttempts        int           // Failed attempts before an account is locked
+	LoginLockoutDuration    time.Duration // First lockout, doubled for every further failure
+	LoginLockoutMaxDuration time.Duration
+	LoginIPMaxAttempts      int // Failed attempts per client IP within LoginIPWindow
+	LoginIPWindow           time.Duration
+	LoginAttemptRetention   time.Duration // How long attempts, with their email and IP, are kept
+
+	// How long a session check is trusted when no revocation notification arrives
+	SessionCacheTTL time.Duration
+
+	// Passwordless login
+	MagicLinkEnabled       bool
+	MagicLinkTTL           time.Duration
+	MagicLinkMaxPerWindow  int // Links sent per account within MagicLinkWindow
+	MagicLinkWindow        time.Duration
+	MagicLinkRequireDevice bool // Links only work on the device that requested them
+
+	// How long an admin impersonation session lasts
+	ImpersonationTTL time.Duration
+
+	// How long a deleted account can be restored before its personal data
+	// is anonymised
+	AccountDeletionGracePeriod time.Duration
 }
 
+// Policies for accounts whose email address is not verified yet
+const (
+	UnverifiedEmailAllow    = "allow"
+	UnverifiedEmailReadOnly = "read_only"
+	UnverifiedEmailBlock    = "block"
+)
+
 type StorageConfig struct {
+	Driver     string // local
 	UploadPath string
+	BaseURL    string
 	MaxSize    int64
 }
 
+type MailConfig struct {
+	Driver  string // log, file
+	From    string
+	FileDir string
+}
+
+// ExportConfig controls GDPR data exports
+type ExportConfig struct {
+	Path         string        // Directory archives are written to; never served directly
+	LinkTTL      time.Duration // How long a download link stays valid
+	MaxDownloads int           // Downloads allowed per link
+	PollInterval time.Duration // How often the worker looks for queued exports
+}
+
+// PaginationConfig controls cursor pagination of list endpoints
+type PaginationConfig struct {
+	CursorSecret string // Signs cursors; a random key is used when empty
+	DefaultLimit int    // Page size when the request sets no limit
+	MaxLimit     int    // Largest page size a request may ask for
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+}
+
+// OIDCProviderConfig describes one OpenID Connect identity provider
+type OIDCProviderConfig struct {
+	Name         string // Used in login URLs: /api/v1/auth/oidc/{name}/login
+	Issuer       string
+	ClientID     string
+	ClientSecret string // Empty for public clients
+	RedirectURL  string
+	Scopes       []string
+}
+
 // Load loads configuration from .env file
 func Load() (*Config, error) {
 	// Load .env file
@@ -57,6 +166,7 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"),
 			Environment:  getEnv("ENVIRONMENT", "development"),
+			AppURL:       getEnv("APP_URL", "http://localhost:3000"),
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 15*time.Second),
 		},
@@ -69,20 +179,110 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		Auth: AuthConfig{
-			JWTSecret:          mustGetEnv("JWT_SECRET"),
 			JWTExpiry:          getDurationEnv("JWT_EXPIRY", 15*time.Minute),
 			RefreshTokenExpiry: getDurationEnv("REFRESH_TOKEN_EXPIRY", 7*24*time.Hour),
-			BcryptCost:         getIntEnv("BCRYPT_COST", 12),
+			SessionMaxAge:      getDurationEnv("SESSION_MAX_AGE", 30*24*time.Hour),
+			PasswordResetTTL:   getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+
+			PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:            getIntEnv("BCRYPT_COST", 12),
+			Argon2Memory:          getIntEnv("ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:      getIntEnv("ARGON2_ITERATIONS", 3),
+			Argon2Parallelism:     getIntEnv("ARGON2_PARALLELISM", 2),
+
+			PasswordMinLength:     getIntEnv("PASSWORD_MIN_LENGTH", 10),
+			PasswordRequireUpper:  getBoolEnv("PASSWORD_REQUIRE_UPPER", true),
+			PasswordRequireLower:  getBoolEnv("PASSWORD_REQUIRE_LOWER", true),
+			PasswordRequireDigit:  getBoolEnv("PASSWORD_REQUIRE_DIGIT", true),
+			PasswordRequireSymbol: getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+			PasswordHistorySize:   getIntEnv("PASSWORD_HISTORY_SIZE", 5),
+			PasswordBreachCheck:   getBoolEnv("PASSWORD_BREACH_CHECK", false),
+			PasswordBreachAPIURL:  getEnv("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com"),
+
+			JWTAlgorithm:           getEnv("JWT_ALGORITHM", "RS256"),
+			JWTKeyDir:              getEnv("JWT_KEY_DIR", "./keys"),
+			JWTKeyRotationInterval: getDurationEnv("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+			JWTIssuer:              getEnv("JWT_ISSUER", "lms-api"),
+			JWTAudience:            getListEnv("JWT_AUDIENCE", []string{"lms-web", "lms-mobile"}),
+
+			EmailVerificationTTL:            getDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			EmailVerificationResendInterval: getDurationEnv("EMAIL_VERIFICATION_RESEND_INTERVAL", 2*time.Minute),
+			UnverifiedEmailPolicy:           getEnv("UNVERIFIED_EMAIL_POLICY", UnverifiedEmailAllow),
+
+			TwoFactorEncryptionKey: getEnv("TWO_FACTOR_ENCRYPTION_KEY", ""),
+			TwoFactorIssuer:        getEnv("TWO_FACTOR_ISSUER", "LMS"),
+			MFATokenTTL:            getDurationEnv("MFA_TOKEN_TTL", 5*time.Minute),
+
+			LoginMaxAttempts:        getIntEnv("LOGIN_MAX_ATTEMPTS", 5),
+			LoginLockoutDuration:    getDurationEnv("LOGIN_LOCKOUT_DURATION", time.Minute),
+			LoginLockoutMaxDuration: getDurationEnv("LOGIN_LOCKOUT_MAX_DURATION", time.Hour),
+			LoginIPMaxAttempts:      getIntEnv("LOGIN_IP_MAX_ATTEMPTS", 20),
+			LoginIPWindow:           getDurationEnv("LOGIN_IP_WINDOW", 15*time.Minute),
+			LoginAttemptRetention:   getDurationEnv("LOGIN_ATTEMPT_RETENTION", 24*time.Hour),
+
+			SessionCacheTTL: getDurationEnv("SESSION_CACHE_TTL", 30*time.Second),
+
+			MagicLinkEnabled:       getBoolEnv("MAGIC_LINK_ENABLED", false),
+			MagicLinkTTL:           getDurationEnv("MAGIC_LINK_TTL", 15*time.Minute),
+			MagicLinkMaxPerWindow:  getIntEnv("MAGIC_LINK_MAX_PER_WINDOW", 5),
+			MagicLinkWindow:        getDurationEnv("MAGIC_LINK_WINDOW", time.Hour),
+			MagicLinkRequireDevice: getBoolEnv("MAGIC_LINK_REQUIRE_DEVICE", true),
+
+			ImpersonationTTL: getDurationEnv("IMPERSONATION_TTL", 30*time.Minute),
+
+			AccountDeletionGracePeriod: getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
 		},
 		Storage: StorageConfig{
+			Driver:     getEnv("STORAGE_DRIVER", "local"),
 			UploadPath: getEnv("UPLOAD_PATH", "./uploads"),
+			BaseURL:    getEnv("UPLOAD_BASE_URL", "/uploads"),
 			MaxSize:    getInt64Env("MAX_UPLOAD_SIZE", 10*1024*1024), // 10MB
 		},
+		Mail: MailConfig{
+			Driver:  getEnv("MAIL_DRIVER", "log"),
+			From:    getEnv("MAIL_FROM", "no-reply@lms.local"),
+			FileDir: getEnv("MAIL_FILE_DIR", "./tmp/mail"),
+		},
+		Export: ExportConfig{
+			Path:         getEnv("EXPORT_PATH", "./exports"),
+			LinkTTL:      getDurationEnv("EXPORT_LINK_TTL", 7*24*time.Hour),
+			MaxDownloads: getIntEnv("EXPORT_MAX_DOWNLOADS", 3),
+			PollInterval: getDurationEnv("EXPORT_POLL_INTERVAL", time.Minute),
+		},
+		Pagination: PaginationConfig{
+			CursorSecret: getEnv("PAGINATION_CURSOR_SECRET", ""),
+			DefaultLimit: getIntEnv("PAGINATION_DEFAULT_LIMIT", 20),
+			MaxLimit:     getIntEnv("PAGINATION_MAX_LIMIT", 100),
+		},
+		OIDC: loadOIDCConfig(),
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// loadOIDCConfig reads the providers listed in OIDC_PROVIDERS. Each provider
+// NAME is configured through OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and OIDC_<NAME>_SCOPES.
+func loadOIDCConfig() OIDCConfig {
+	var cfg OIDCConfig
+	for _, name := range getListEnv("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg.Providers = append(cfg.Providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       getListEnv(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		})
+	}
+	return cfg
+}
+
 func (c DatabaseConfig) DSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.Database, c.SSLMode)
@@ -104,6 +304,21 @@ func mustGetEnv(key string) string {
 	return value
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
@@ -122,6 +337,15 @@ func getInt64Env(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
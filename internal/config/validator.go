@@ -1 +1,119 @@
 package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+)
+
+// Validate checks configuration values that have a fixed set of options
+func (c *Config) Validate() error {
+	switch c.Auth.UnverifiedEmailPolicy {
+	case UnverifiedEmailAllow, UnverifiedEmailReadOnly, UnverifiedEmailBlock:
+	default:
+		return fmt.Errorf("invalid UNVERIFIED_EMAIL_POLICY %q: must be one of %s, %s, %s",
+			c.Auth.UnverifiedEmailPolicy, UnverifiedEmailAllow, UnverifiedEmailReadOnly, UnverifiedEmailBlock)
+	}
+
+	switch c.Auth.JWTAlgorithm {
+	case "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("invalid JWT_ALGORITHM %q: must be RS256 or EdDSA", c.Auth.JWTAlgorithm)
+	}
+	if len(c.Auth.JWTAudience) == 0 {
+		return fmt.Errorf("JWT_AUDIENCE must list at least one audience")
+	}
+
+	switch c.Auth.PasswordHashAlgorithm {
+	case "bcrypt":
+		if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+			return fmt.Errorf("invalid BCRYPT_COST %d: must be between 4 and 31", c.Auth.BcryptCost)
+		}
+	case "argon2id":
+		if c.Auth.Argon2Iterations < 1 || c.Auth.Argon2Parallelism < 1 || c.Auth.Argon2Parallelism > 255 {
+			return fmt.Errorf("ARGON2_ITERATIONS must be positive and ARGON2_PARALLELISM between 1 and 255")
+		}
+		if c.Auth.Argon2Memory < 8*c.Auth.Argon2Parallelism {
+			return fmt.Errorf("ARGON2_MEMORY must be at least 8 KiB per unit of ARGON2_PARALLELISM")
+		}
+	default:
+		return fmt.Errorf("invalid PASSWORD_HASH_ALGORITHM %q: must be bcrypt or argon2id", c.Auth.PasswordHashAlgorithm)
+	}
+
+	if c.Auth.RefreshTokenExpiry <= 0 || c.Auth.SessionMaxAge < c.Auth.RefreshTokenExpiry {
+		return fmt.Errorf("REFRESH_TOKEN_EXPIRY must be positive and SESSION_MAX_AGE at least as long")
+	}
+
+	if c.Auth.PasswordMinLength < 8 || c.Auth.PasswordMinLength > 72 {
+		return fmt.Errorf("invalid PASSWORD_MIN_LENGTH %d: must be between 8 and 72", c.Auth.PasswordMinLength)
+	}
+	if c.Auth.PasswordHistorySize < 0 {
+		return fmt.Errorf("PASSWORD_HISTORY_SIZE must not be negative")
+	}
+	if c.Auth.PasswordBreachCheck && c.Auth.PasswordBreachAPIURL == "" {
+		return fmt.Errorf("PASSWORD_BREACH_API_URL is required when PASSWORD_BREACH_CHECK is enabled")
+	}
+
+	if c.Auth.LoginLockoutDuration <= 0 || c.Auth.LoginLockoutMaxDuration < c.Auth.LoginLockoutDuration {
+		return fmt.Errorf("LOGIN_LOCKOUT_DURATION must be positive and LOGIN_LOCKOUT_MAX_DURATION at least as long")
+	}
+	if c.Auth.LoginAttemptRetention < c.Auth.LoginIPWindow {
+		return fmt.Errorf("LOGIN_ATTEMPT_RETENTION must be at least as long as LOGIN_IP_WINDOW")
+	}
+
+	if c.Auth.TwoFactorEncryptionKey != "" {
+		if _, err := c.Auth.TwoFactorKey(); err != nil {
+			return err
+		}
+	}
+
+	if c.Auth.MagicLinkEnabled && (c.Auth.MagicLinkTTL <= 0 || c.Auth.MagicLinkMaxPerWindow <= 0) {
+		return fmt.Errorf("MAGIC_LINK_TTL and MAGIC_LINK_MAX_PER_WINDOW must be positive")
+	}
+
+	if c.Auth.ImpersonationTTL <= 0 {
+		return fmt.Errorf("IMPERSONATION_TTL must be positive")
+	}
+
+	if c.Auth.AccountDeletionGracePeriod < 0 {
+		return fmt.Errorf("ACCOUNT_DELETION_GRACE_PERIOD must not be negative")
+	}
+
+	if c.Storage.MaxSize <= 0 {
+		return fmt.Errorf("MAX_UPLOAD_SIZE must be positive")
+	}
+
+	if c.Export.LinkTTL <= 0 || c.Export.MaxDownloads <= 0 || c.Export.PollInterval <= 0 {
+		return fmt.Errorf("EXPORT_LINK_TTL, EXPORT_MAX_DOWNLOADS and EXPORT_POLL_INTERVAL must be positive")
+	}
+
+	if c.Pagination.DefaultLimit < 1 || c.Pagination.DefaultLimit > c.Pagination.MaxLimit {
+		return fmt.Errorf("PAGINATION_DEFAULT_LIMIT must be between 1 and PAGINATION_MAX_LIMIT")
+	}
+	if c.Pagination.CursorSecret != "" && len(c.Pagination.CursorSecret) < 32 {
+		return fmt.Errorf("PAGINATION_CURSOR_SECRET must be at least 32 characters")
+	}
+
+	for _, provider := range c.OIDC.Providers {
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return fmt.Errorf("OIDC provider %q needs an issuer, client ID and redirect URL", provider.Name)
+		}
+		if !slices.Contains(provider.Scopes, "openid") {
+			return fmt.Errorf("OIDC provider %q must request the openid scope", provider.Name)
+		}
+	}
+
+	return nil
+}
+
+// TwoFactorKey decodes the key used to encrypt TOTP secrets at rest
+func (c AuthConfig) TwoFactorKey() ([]byte, error) {
+	if c.TwoFactorEncryptionKey == "" {
+		return nil, fmt.Errorf("TWO_FACTOR_ENCRYPTION_KEY is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.TwoFactorEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid TWO_FACTOR_ENCRYPTION_KEY: must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
@@ -0,0 +1,189 @@
+// Package content defines the typed content of lessons. A lesson's
+// content_type selects the shape of its content document: each type has
+// its own struct, validated with the same tags as request payloads.
+//
+// Content is parsed strictly on write, so fields of another type are
+// rejected, and stored re-encoded from the struct, so it reads back with
+// the same fields in the same order whatever the client sent.
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Type is a lesson content type, as stored in lessons.content_type
+type Type string
+
+// Content types
+const (
+	TypeVideo       Type = "video"
+	TypeText        Type = "text"
+	TypePDF         Type = "pdf"
+	TypeAudio       Type = "audio"
+	TypeInteractive Type = "interactive"
+)
+
+// Source providers of video and audio content
+const (
+	ProviderUpload  = "upload"
+	ProviderURL     = "url"
+	ProviderYouTube = "youtube"
+	ProviderVimeo   = "vimeo"
+)
+
+// Content is the document of one content type
+type Content interface {
+	// Type is the content type the document belongs to
+	Type() Type
+	// FileUploads lists the uploaded files the document refers to
+	FileUploads() []FileUpload
+	// check validates what the struct tags cannot express
+	check() error
+}
+
+// FileUpload is a reference from a content document to an uploaded file
+type FileUpload struct {
+	// Field is the path of the reference below the content
+	Field string
+	ID    uuid.UUID
+	// MimeType is the MIME type the file must have, or a top-level type
+	// followed by a slash such as "video/" to accept any of its subtypes
+	MimeType string
+}
+
+// Accepts reports whether a file of the given MIME type may be referenced.
+// Parameters such as charset are ignored.
+func (f FileUpload) Accepts(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if strings.HasSuffix(f.MimeType, "/") {
+		return strings.HasPrefix(mimeType, f.MimeType) && len(mimeType) > len(f.MimeType)
+	}
+	return mimeType == f.MimeType
+}
+
+// Error is content that does not match its type. Field is the path of the
+// offending value below the content, or empty for the document itself.
+type Error struct {
+	Field   string
+	Tag     string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Parse decodes the content of a lesson of type typ. Unknown fields, and so
+// fields belonging to other types, are rejected with an *Error. The result
+// still needs validating against its struct tags.
+func Parse(typ Type, data json.RawMessage) (Content, error) {
+	var c Content
+	switch typ {
+	case TypeVideo:
+		c = &Video{}
+	case TypeText:
+		c = &Text{}
+	case TypePDF:
+		c = &PDF{}
+	case TypeAudio:
+		c = &Audio{}
+	case TypeInteractive:
+		c = &Interactive{}
+	default:
+		return nil, &Error{Tag: "oneof", Message: fmt.Sprintf("unknown content type %q", typ)}
+	}
+
+	if data := bytes.TrimSpace(data); len(data) == 0 || data[0] != '{' {
+		return nil, &Error{Tag: "object", Message: "content must be a JSON object"}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return nil, decodeError(typ, err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, &Error{Tag: "object", Message: "content must be a single JSON object"}
+	}
+
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Encode serialises content for storage
+func Encode(c Content) (json.RawMessage, error) {
+	return json.Marshal(c)
+}
+
+// Normalize re-encodes stored content through its type, so documents
+// written before a field was added read back in the current shape. Content
+// that no longer parses is returned unchanged with the error.
+func Normalize(typ Type, data json.RawMessage) (json.RawMessage, error) {
+	c, err := Parse(typ, data)
+	if err != nil {
+		return data, err
+	}
+	return Encode(c)
+}
+
+// decodeError describes a JSON decoding failure of typ content
+func decodeError(typ Type, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Field:   typeErr.Field,
+			Tag:     "type",
+			Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, jsonType(typeErr.Type.Kind().String())),
+		}
+	}
+
+	// encoding/json reports unknown fields only by message
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name = strings.Trim(name, `"`)
+		return &Error{
+			Field:   name,
+			Tag:     "unknown",
+			Message: fmt.Sprintf("%s is not a field of %s content", name, typ),
+		}
+	}
+
+	return &Error{Tag: "object", Message: "content is not valid JSON"}
+}
+
+// jsonType names a Go kind the way clients know it
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "slice":
+		return "array"
+	case kind == "struct", kind == "map":
+		return "object"
+	case kind == "bool":
+		return "boolean"
+	default:
+		return kind
+	}
+}
+
+// fileUpload converts a validated upload reference, skipping empty ones
+func fileUpload(field, id, mimeType string) []FileUpload {
+	value, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return []FileUpload{{Field: field, ID: value, MimeType: mimeType}}
+}
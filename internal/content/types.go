@@ -0,0 +1,147 @@
+package content
+
+import "fmt"
+
+// Source is where video or audio is played from: an uploaded file, a
+// direct URL or a hosting provider's page
+type Source struct {
+	Provider     string `json:"provider" validate:"required,oneof=upload url youtube vimeo"`
+	FileUploadID string `json:"fileUploadId,omitempty" validate:"required_if=Provider upload,excluded_unless=Provider upload,omitempty,uuid"`
+	URL          string `json:"url,omitempty" validate:"required_unless=Provider upload,excluded_if=Provider upload,omitempty,url,max=2048"`
+}
+
+// CaptionTrack is a WebVTT subtitle file for one language
+type CaptionTrack struct {
+	Language string `json:"language" validate:"required,bcp47_language_tag"`
+	Label    string `json:"label,omitempty" validate:"max=100"`
+	URL      string `json:"url" validate:"required,url,max=2048"`
+	Default  bool   `json:"default,omitempty"`
+}
+
+// Chapter marks a named position in video or audio
+type Chapter struct {
+	Title        string `json:"title" validate:"required,max=255"`
+	StartSeconds int    `json:"startSeconds" validate:"gte=0"`
+}
+
+// Video is the content of video lessons
+type Video struct {
+	Source          Source         `json:"source"`
+	DurationSeconds int            `json:"durationSeconds,omitempty" validate:"gte=0"`
+	Captions        []CaptionTrack `json:"captions,omitempty" validate:"max=50,dive"`
+	Chapters        []Chapter      `json:"chapters,omitempty" validate:"max=200,dive"`
+	ThumbnailURL    string         `json:"thumbnailUrl,omitempty" validate:"omitempty,url,max=2048"`
+}
+
+// Type implements Content
+func (*Video) Type() Type { return TypeVideo }
+
+// FileUploads implements Content
+func (v *Video) FileUploads() []FileUpload {
+	return fileUpload("source.fileUploadId", v.Source.FileUploadID, "video/")
+}
+
+func (v *Video) check() error {
+	defaults := 0
+	for _, track := range v.Captions {
+		if track.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return &Error{Field: "captions", Tag: "default", Message: "at most one caption track can be the default"}
+	}
+	return checkChapters(v.Chapters, v.DurationSeconds)
+}
+
+// Text is the content of text lessons, written in Markdown
+type Text struct {
+	Markdown string `json:"markdown" validate:"required,max=200000"`
+}
+
+// Type implements Content
+func (*Text) Type() Type { return TypeText }
+
+// FileUploads implements Content
+func (*Text) FileUploads() []FileUpload { return nil }
+
+func (*Text) check() error { return nil }
+
+// PDF is the content of PDF lessons
+type PDF struct {
+	FileUploadID  string `json:"fileUploadId" validate:"required,uuid"`
+	PageCount     int    `json:"pageCount,omitempty" validate:"gte=0"`
+	AllowDownload bool   `json:"allowDownload"`
+}
+
+// Type implements Content
+func (*PDF) Type() Type { return TypePDF }
+
+// FileUploads implements Content
+func (p *PDF) FileUploads() []FileUpload {
+	return fileUpload("fileUploadId", p.FileUploadID, "application/pdf")
+}
+
+func (*PDF) check() error { return nil }
+
+// Audio is the content of audio lessons. The transcript is kept on the
+// lesson itself.
+type Audio struct {
+	Source          Source    `json:"source"`
+	DurationSeconds int       `json:"durationSeconds,omitempty" validate:"gte=0"`
+	Chapters        []Chapter `json:"chapters,omitempty" validate:"max=200,dive"`
+}
+
+// Type implements Content
+func (*Audio) Type() Type { return TypeAudio }
+
+// FileUploads implements Content
+func (a *Audio) FileUploads() []FileUpload {
+	return fileUpload("source.fileUploadId", a.Source.FileUploadID, "audio/")
+}
+
+func (a *Audio) check() error {
+	switch a.Source.Provider {
+	case ProviderYouTube, ProviderVimeo:
+		return &Error{Field: "source.provider", Tag: "oneof", Message: "audio must be uploaded or served from a URL"}
+	}
+	return checkChapters(a.Chapters, a.DurationSeconds)
+}
+
+// Interactive is the content of interactive lessons, embedded from an
+// external tool
+type Interactive struct {
+	Kind   string `json:"kind" validate:"required,oneof=embed h5p scorm"`
+	URL    string `json:"url" validate:"required,url,max=2048"`
+	Height int    `json:"height,omitempty" validate:"omitempty,gte=100,lte=4000"`
+}
+
+// Type implements Content
+func (*Interactive) Type() Type { return TypeInteractive }
+
+// FileUploads implements Content
+func (*Interactive) FileUploads() []FileUpload { return nil }
+
+func (*Interactive) check() error { return nil }
+
+// checkChapters requires chapters in playback order and, when the duration
+// is known, within it
+func checkChapters(chapters []Chapter, duration int) error {
+	for i, chapter := range chapters {
+		if i > 0 && chapter.StartSeconds <= chapters[i-1].StartSeconds {
+			return &Error{
+				Field:   fmt.Sprintf("chapters[%d].startSeconds", i),
+				Tag:     "order",
+				Message: "chapters must be in order of their start time",
+			}
+		}
+		if duration > 0 && chapter.StartSeconds >= duration {
+			return &Error{
+				Field:   fmt.Sprintf("chapters[%d].startSeconds", i),
+				Tag:     "duration",
+				Message: "chapters must start before the end of the media",
+			}
+		}
+	}
+	return nil
+}
@@ -268,6 +268,15 @@ func AllUserRoleValues() []UserRole {
 	}
 }
 
+type AccountDeletionConfirmation struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"userId"`
+	TokenHash string       `json:"tokenHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
 type AccessCode struct {
 	ID          uuid.UUID      `json:"id"`
 	CourseID    uuid.UUID      `json:"courseId"`
@@ -442,6 +451,12 @@ type CourseRating struct {
 	UpdatedAt            sql.NullTime   `json:"updatedAt"`
 }
 
+type CourseSearchDocument struct {
+	CourseID uuid.UUID   `json:"courseId"`
+	Config   interface{} `json:"config"`
+	Document interface{} `json:"document"`
+}
+
 type CourseStaff struct {
 	ID          uuid.UUID             `json:"id"`
 	CourseID    uuid.UUID             `json:"courseId"`
@@ -453,17 +468,19 @@ type CourseStaff struct {
 }
 
 type DataArchive struct {
-	ID            uuid.UUID      `json:"id"`
-	UserID        uuid.NullUUID  `json:"userId"`
-	ArchiveType   string         `json:"archiveType"`
-	Status        sql.NullString `json:"status"`
-	FilePath      sql.NullString `json:"filePath"`
-	FileSize      sql.NullInt64  `json:"fileSize"`
-	RequestedAt   sql.NullTime   `json:"requestedAt"`
-	CompletedAt   sql.NullTime   `json:"completedAt"`
-	ExpiresAt     sql.NullTime   `json:"expiresAt"`
-	DownloadCount sql.NullInt32  `json:"downloadCount"`
-	ErrorMessage  sql.NullString `json:"errorMessage"`
+	ID                uuid.UUID      `json:"id"`
+	UserID            uuid.NullUUID  `json:"userId"`
+	ArchiveType       string         `json:"archiveType"`
+	Status            sql.NullString `json:"status"`
+	FilePath          sql.NullString `json:"filePath"`
+	FileSize          sql.NullInt64  `json:"fileSize"`
+	RequestedAt       sql.NullTime   `json:"requestedAt"`
+	CompletedAt       sql.NullTime   `json:"completedAt"`
+	ExpiresAt         sql.NullTime   `json:"expiresAt"`
+	DownloadCount     sql.NullInt32  `json:"downloadCount"`
+	ErrorMessage      sql.NullString `json:"errorMessage"`
+	DownloadTokenHash sql.NullString `json:"downloadTokenHash"`
+	ClaimedAt         sql.NullTime   `json:"claimedAt"`
 }
 
 type Enrollment struct {
@@ -645,6 +662,27 @@ type LessonProgress struct {
 	Bookmarks            pqtype.NullRawMessage `json:"bookmarks"`
 }
 
+type LoginAttempt struct {
+	ID          uuid.UUID     `json:"id"`
+	Email       string        `json:"email"`
+	UserID      uuid.NullUUID `json:"userId"`
+	IpAddress   pqtype.Inet   `json:"ipAddress"`
+	Succeeded   bool          `json:"succeeded"`
+	AttemptedAt time.Time     `json:"attemptedAt"`
+}
+
+type MagicLink struct {
+	ID         uuid.UUID      `json:"id"`
+	UserID     uuid.UUID      `json:"userId"`
+	TokenHash  string         `json:"tokenHash"`
+	DeviceHash string         `json:"deviceHash"`
+	IpAddress  pqtype.Inet    `json:"ipAddress"`
+	UserAgent  sql.NullString `json:"userAgent"`
+	ExpiresAt  time.Time      `json:"expiresAt"`
+	UsedAt     sql.NullTime   `json:"usedAt"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
 type Module struct {
 	ID                       uuid.UUID      `json:"id"`
 	CourseID                 uuid.UUID      `json:"courseId"`
@@ -706,6 +744,23 @@ type NotificationPreference struct {
 	UpdatedAt       sql.NullTime          `json:"updatedAt"`
 }
 
+type OidcLoginCode struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"userId"`
+	Provider  string       `json:"provider"`
+	CodeHash  string       `json:"codeHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type PasswordHistory struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"userId"`
+	PasswordHash string    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
 type PasswordReset struct {
 	ID        uuid.UUID    `json:"id"`
 	UserID    uuid.UUID    `json:"userId"`
@@ -724,6 +779,20 @@ type Permission struct {
 	CreatedAt   sql.NullTime   `json:"createdAt"`
 }
 
+type PersonalAccessToken struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"userId"`
+	Name        string       `json:"name"`
+	TokenPrefix string       `json:"tokenPrefix"`
+	TokenHash   string       `json:"tokenHash"`
+	Scopes      []string     `json:"scopes"`
+	ExpiresAt   sql.NullTime `json:"expiresAt"`
+	LastUsedAt  sql.NullTime `json:"lastUsedAt"`
+	LastUsedIp  pqtype.Inet  `json:"lastUsedIp"`
+	RevokedAt   sql.NullTime `json:"revokedAt"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
 type QuestionBank struct {
 	ID                  uuid.UUID             `json:"id"`
 	CreatedBy           uuid.UUID             `json:"createdBy"`
@@ -808,6 +877,13 @@ type QuizQuestion struct {
 	UpdatedAt        sql.NullTime          `json:"updatedAt"`
 }
 
+type RotatedRefreshToken struct {
+	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"sessionId"`
+	TokenHash string    `json:"tokenHash"`
+	RotatedAt time.Time `json:"rotatedAt"`
+}
+
 type StudentAnswer struct {
 	ID               uuid.UUID      `json:"id"`
 	AttemptID        uuid.UUID      `json:"attemptId"`
@@ -844,39 +920,42 @@ type SystemLog struct {
 }
 
 type User struct {
-	ID                     uuid.UUID             `json:"id"`
-	Email                  string                `json:"email"`
-	EmailVerified          sql.NullBool          `json:"emailVerified"`
-	EmailVerificationToken sql.NullString        `json:"emailVerificationToken"`
-	EmailVerifiedAt        sql.NullTime          `json:"emailVerifiedAt"`
-	PasswordHash           string                `json:"passwordHash"`
-	FirstName              string                `json:"firstName"`
-	LastName               string                `json:"lastName"`
-	DisplayName            sql.NullString        `json:"displayName"`
-	AvatarUrl              sql.NullString        `json:"avatarUrl"`
-	Bio                    sql.NullString        `json:"bio"`
-	Phone                  sql.NullString        `json:"phone"`
-	DateOfBirth            sql.NullTime          `json:"dateOfBirth"`
-	Gender                 sql.NullString        `json:"gender"`
-	Country                sql.NullString        `json:"country"`
-	Timezone               sql.NullString        `json:"timezone"`
-	PreferredLanguage      sql.NullString        `json:"preferredLanguage"`
-	IsActive               sql.NullBool          `json:"isActive"`
-	SuspendedAt            sql.NullTime          `json:"suspendedAt"`
-	SuspendedReason        sql.NullString        `json:"suspendedReason"`
-	LastLoginAt            sql.NullTime          `json:"lastLoginAt"`
-	LoginCount             sql.NullInt32         `json:"loginCount"`
-	FailedLoginAttempts    sql.NullInt32         `json:"failedLoginAttempts"`
-	FailedLoginLockedUntil sql.NullTime          `json:"failedLoginLockedUntil"`
-	PasswordChangedAt      sql.NullTime          `json:"passwordChangedAt"`
-	MustChangePassword     sql.NullBool          `json:"mustChangePassword"`
-	TwoFactorEnabled       sql.NullBool          `json:"twoFactorEnabled"`
-	TwoFactorSecret        sql.NullString        `json:"twoFactorSecret"`
-	BackupCodes            []string              `json:"backupCodes"`
-	Metadata               pqtype.NullRawMessage `json:"metadata"`
-	CreatedAt              sql.NullTime          `json:"createdAt"`
-	UpdatedAt              sql.NullTime          `json:"updatedAt"`
-	DeletedAt              sql.NullTime          `json:"deletedAt"`
+	ID                      uuid.UUID             `json:"id"`
+	Email                   string                `json:"email"`
+	EmailVerified           sql.NullBool          `json:"emailVerified"`
+	EmailVerificationToken  sql.NullString        `json:"emailVerificationToken"`
+	EmailVerifiedAt         sql.NullTime          `json:"emailVerifiedAt"`
+	PasswordHash            string                `json:"passwordHash"`
+	FirstName               string                `json:"firstName"`
+	LastName                string                `json:"lastName"`
+	DisplayName             sql.NullString        `json:"displayName"`
+	AvatarUrl               sql.NullString        `json:"avatarUrl"`
+	Bio                     sql.NullString        `json:"bio"`
+	Phone                   sql.NullString        `json:"phone"`
+	DateOfBirth             sql.NullTime          `json:"dateOfBirth"`
+	Gender                  sql.NullString        `json:"gender"`
+	Country                 sql.NullString        `json:"country"`
+	Timezone                sql.NullString        `json:"timezone"`
+	PreferredLanguage       sql.NullString        `json:"preferredLanguage"`
+	IsActive                sql.NullBool          `json:"isActive"`
+	SuspendedAt             sql.NullTime          `json:"suspendedAt"`
+	SuspendedReason         sql.NullString        `json:"suspendedReason"`
+	LastLoginAt             sql.NullTime          `json:"lastLoginAt"`
+	LoginCount              sql.NullInt32         `json:"loginCount"`
+	FailedLoginAttempts     sql.NullInt32         `json:"failedLoginAttempts"`
+	FailedLoginLockedUntil  sql.NullTime          `json:"failedLoginLockedUntil"`
+	PasswordChangedAt       sql.NullTime          `json:"passwordChangedAt"`
+	MustChangePassword      sql.NullBool          `json:"mustChangePassword"`
+	TwoFactorEnabled        sql.NullBool          `json:"twoFactorEnabled"`
+	TwoFactorSecret         sql.NullString        `json:"twoFactorSecret"`
+	BackupCodes             []string              `json:"backupCodes"`
+	Metadata                pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt               sql.NullTime          `json:"createdAt"`
+	UpdatedAt               sql.NullTime          `json:"updatedAt"`
+	DeletedAt               sql.NullTime          `json:"deletedAt"`
+	EmailVerificationSentAt sql.NullTime          `json:"emailVerificationSentAt"`
+	AnonymizedAt            sql.NullTime          `json:"anonymizedAt"`
+	TwoFactorLastCounter    sql.NullInt64         `json:"twoFactorLastCounter"`
 }
 
 type UserGroup struct {
@@ -888,6 +967,16 @@ type UserGroup struct {
 	ExpiresAt  sql.NullTime  `json:"expiresAt"`
 }
 
+type UserIdentity struct {
+	ID          uuid.UUID      `json:"id"`
+	UserID      uuid.UUID      `json:"userId"`
+	Provider    string         `json:"provider"`
+	Subject     string         `json:"subject"`
+	Email       sql.NullString `json:"email"`
+	LastLoginAt sql.NullTime   `json:"lastLoginAt"`
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
 type UserSession struct {
 	ID               uuid.UUID             `json:"id"`
 	UserID           uuid.UUID             `json:"userId"`
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+
+	"github.com/Abdelrahiim/lms/internal/pagination"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// This file is not generated. It runs sqlc queries one keyset page at a
+// time: pagination.Request wraps the generated SQL with the filters, sort
+// order and cursor of the request, and the rows are scanned like the
+// generated method would.
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// ListInstructorCoursesPage returns one page of ListInstructorCourses
+func (q *Queries) ListInstructorCoursesPage(ctx context.Context, page *pagination.Request, instructorID uuid.UUID) ([]Course, error) {
+	query, args := page.Query(listInstructorCourses, instructorID)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Course{}
+	for rows.Next() {
+		i, err := scanCourse(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountInstructorCourses counts the rows of ListInstructorCourses matching
+// the filters of page
+func (q *Queries) CountInstructorCourses(ctx context.Context, page *pagination.Request, instructorID uuid.UUID) (int64, error) {
+	query, args := page.CountQuery(listInstructorCourses, instructorID)
+	var count int64
+	err := q.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// scanCourse reads a row with the columns of the courses table
+func scanCourse(row scanner) (Course, error) {
+	var i Course
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Title,
+		&i.Slug,
+		&i.Description,
+		&i.Syllabus,
+		&i.InstructorID,
+		&i.Category,
+		&i.SubCategory,
+		&i.Level,
+		&i.Language,
+		&i.ThumbnailUrl,
+		&i.IntroVideoUrl,
+		&i.DurationHours,
+		&i.Price,
+		&i.Currency,
+		&i.IsFree,
+		&i.IsPublished,
+		&i.PublishedAt,
+		&i.IsFeatured,
+		&i.EnrollmentType,
+		&i.MaxStudents,
+		pq.Array(&i.Prerequisites),
+		pq.Array(&i.Tags),
+		pq.Array(&i.LearningOutcomes),
+		pq.Array(&i.Requirements),
+		&i.TargetAudience,
+		&i.CompletionCertificate,
+		&i.AllowDiscussion,
+		&i.AllowDownload,
+		&i.Metadata,
+		&i.Settings,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.EnrolledCount,
+		&i.CompletedCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
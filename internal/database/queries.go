@@ -6,20 +6,156 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptTOTPCounter(ctx context.Context, arg AcceptTOTPCounterParams) (int64, error)
+	AddUserToGroup(ctx context.Context, arg AddUserToGroupParams) error
+	AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (int64, error)
+	AnonymizeUserAnalyticsEvents(ctx context.Context, userID uuid.NullUUID) (int64, error)
+	AnonymizeUserSessions(ctx context.Context, arg AnonymizeUserSessionsParams) (int64, error)
+	ArchiveCourse(ctx context.Context, arg ArchiveCourseParams) (Course, error)
+	CancelUserDeletion(ctx context.Context, arg CancelUserDeletionParams) (int64, error)
+	ClaimDataArchive(ctx context.Context, archiveType string) (DataArchive, error)
+	CompleteDataArchive(ctx context.Context, arg CompleteDataArchiveParams) error
+	ConsumeAccountDeletionConfirmation(ctx context.Context, arg ConsumeAccountDeletionConfirmationParams) (int64, error)
+	ConsumeBackupCode(ctx context.Context, arg ConsumeBackupCodeParams) (int64, error)
+	ConsumeOIDCLoginCode(ctx context.Context, arg ConsumeOIDCLoginCodeParams) (uuid.UUID, error)
+	CountOwnedCourses(ctx context.Context, instructorID uuid.UUID) (int64, error)
+	CountRecentFailedLoginsByIP(ctx context.Context, arg CountRecentFailedLoginsByIPParams) (int64, error)
+	CountRecentMagicLinks(ctx context.Context, arg CountRecentMagicLinksParams) (int64, error)
+	CourseCodeExists(ctx context.Context, arg CourseCodeExistsParams) (bool, error)
+	CreateAccountDeletionConfirmation(ctx context.Context, arg CreateAccountDeletionConfirmationParams) error
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateCourse(ctx context.Context, arg CreateCourseParams) (Course, error)
+	CreateDataArchive(ctx context.Context, arg CreateDataArchiveParams) (DataArchive, error)
+	CreateFileUpload(ctx context.Context, arg CreateFileUploadParams) error
+	CreateLesson(ctx context.Context, arg CreateLessonParams) (Lesson, error)
+	CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) error
+	CreateMagicLink(ctx context.Context, arg CreateMagicLinkParams) error
+	CreateModule(ctx context.Context, arg CreateModuleParams) (Module, error)
+	CreateOIDCLoginCode(ctx context.Context, arg CreateOIDCLoginCodeParams) error
+	CreatePasswordHistory(ctx context.Context, arg CreatePasswordHistoryParams) error
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) error
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreateRotatedRefreshToken(ctx context.Context, arg CreateRotatedRefreshTokenParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error
+	DeleteLesson(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteModule(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteOtherUserFiles(ctx context.Context, arg DeleteOtherUserFilesParams) ([]string, error)
+	DeleteUserAvatarFiles(ctx context.Context, arg DeleteUserAvatarFilesParams) ([]string, error)
+	DeleteUserDataArchives(ctx context.Context, userID uuid.NullUUID) ([]sql.NullString, error)
+	DisableTwoFactor(ctx context.Context, id uuid.UUID) error
+	EnableTwoFactor(ctx context.Context, arg EnableTwoFactorParams) error
+	ExpireDataArchives(ctx context.Context, expiresAt sql.NullTime) ([]sql.NullString, error)
+	ExportUserCourseRatings(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserEnrollments(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserFileUploads(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserForumPosts(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserForumThreads(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserForumVotes(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserLessonProgress(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserNotifications(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserProfile(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserQuizAnswers(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserQuizAttempts(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	ExportUserSessions(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+	FailDataArchive(ctx context.Context, arg FailDataArchiveParams) error
 	GetActiveSessions(ctx context.Context, arg GetActiveSessionsParams) ([]UserSession, error)
+	GetCourseByID(ctx context.Context, id uuid.UUID) (Course, error)
+	GetCourseBySlug(ctx context.Context, slug string) (Course, error)
+	GetDataArchiveByToken(ctx context.Context, downloadTokenHash sql.NullString) (DataArchive, error)
+	GetLesson(ctx context.Context, id uuid.UUID) (Lesson, error)
+	GetLessonCourseID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	GetModule(ctx context.Context, id uuid.UUID) (Module, error)
+	GetModuleCourseID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	GetOpenDataArchive(ctx context.Context, arg GetOpenDataArchiveParams) (DataArchive, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (GetPersonalAccessTokenByHashRow, error)
+	GetPostCourseID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	GetQuizCourseID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	GetRotatedRefreshToken(ctx context.Context, tokenHash string) (RotatedRefreshToken, error)
 	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (UserSession, error)
 	GetSessionByUserID(ctx context.Context, arg GetSessionByUserIDParams) (UserSession, error)
+	GetSessionStatus(ctx context.Context, id uuid.UUID) (GetSessionStatusRow, error)
+	GetThreadCourseID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
 	GetUser(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByEmailVerificationToken(ctx context.Context, emailVerificationToken sql.NullString) (User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetUserDataArchive(ctx context.Context, arg GetUserDataArchiveParams) (DataArchive, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]GetUserPermissionsRow, error)
+	GetUserPreferredLanguage(ctx context.Context, id uuid.UUID) (sql.NullString, error)
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	GetValidMagicLink(ctx context.Context, tokenHash string) (MagicLink, error)
+	GetValidPasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	InvalidateAccountDeletionConfirmations(ctx context.Context, arg InvalidateAccountDeletionConfirmationsParams) error
+	InvalidateUserMagicLinks(ctx context.Context, arg InvalidateUserMagicLinksParams) error
+	InvalidateUserPasswordResets(ctx context.Context, arg InvalidateUserPasswordResetsParams) error
+	IsCourseInstructor(ctx context.Context, arg IsCourseInstructorParams) (bool, error)
+	IsEnrolled(ctx context.Context, arg IsEnrolledParams) (bool, error)
+	ListCourseFileUploads(ctx context.Context, arg ListCourseFileUploadsParams) ([]ListCourseFileUploadsRow, error)
+	ListCourseLessonPlacements(ctx context.Context, courseID uuid.UUID) ([]ListCourseLessonPlacementsRow, error)
+	ListCourseModules(ctx context.Context, courseID uuid.UUID) ([]Module, error)
+	ListCourseSlugs(ctx context.Context, slug string) ([]string, error)
+	ListInstructorCourses(ctx context.Context, instructorID uuid.UUID) ([]Course, error)
+	ListModuleLessons(ctx context.Context, moduleID uuid.UUID) ([]Lesson, error)
+	ListPublishedCourseModules(ctx context.Context, courseID uuid.UUID) ([]Module, error)
+	ListPublishedModuleLessons(ctx context.Context, moduleID uuid.UUID) ([]Lesson, error)
+	ListRecentPasswordHashes(ctx context.Context, arg ListRecentPasswordHashesParams) ([]string, error)
+	ListUserPersonalAccessTokens(ctx context.Context, userID uuid.UUID) ([]PersonalAccessToken, error)
+	ListUsersDueForAnonymization(ctx context.Context, arg ListUsersDueForAnonymizationParams) ([]uuid.UUID, error)
+	LockCourse(ctx context.Context, courseID uuid.UUID) (uuid.UUID, error)
+	LockUserAccount(ctx context.Context, arg LockUserAccountParams) error
+	MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) error
+	MarkMagicLinkUsed(ctx context.Context, arg MarkMagicLinkUsedParams) (int64, error)
+	MarkPasswordResetUsed(ctx context.Context, arg MarkPasswordResetUsedParams) (int64, error)
+	ParkCourseLessons(ctx context.Context, courseID uuid.UUID) error
+	ParkCourseModules(ctx context.Context, courseID uuid.UUID) error
+	PruneLoginAttempts(ctx context.Context, attemptedAt time.Time) (int64, error)
+	PrunePasswordHistory(ctx context.Context, arg PrunePasswordHistoryParams) error
+	PublishCourse(ctx context.Context, arg PublishCourseParams) (Course, error)
+	PurgeUserCredentials(ctx context.Context, userID uuid.UUID) error
+	RecordDataArchiveDownload(ctx context.Context, arg RecordDataArchiveDownloadParams) (int64, error)
+	RecordFailedLogin(ctx context.Context, id uuid.UUID) (sql.NullInt32, error)
+	RecordSuccessfulLogin(ctx context.Context, arg RecordSuccessfulLoginParams) error
+	RemoveModulePrerequisite(ctx context.Context, arg RemoveModulePrerequisiteParams) error
+	RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
 	RevokeSession(ctx context.Context, arg RevokeSessionParams) error
+	RevokeUserPersonalAccessToken(ctx context.Context, arg RevokeUserPersonalAccessTokenParams) (int64, error)
+	RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error)
+	RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) error
+	RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (int64, error)
+	ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (int64, error)
+	SearchCourseFacets(ctx context.Context, arg SearchCourseFacetsParams) ([]SearchCourseFacetsRow, error)
+	SearchCourses(ctx context.Context, arg SearchCoursesParams) ([]SearchCoursesRow, error)
+	SetEmailVerificationToken(ctx context.Context, arg SetEmailVerificationTokenParams) error
+	SetLessonOrder(ctx context.Context, arg SetLessonOrderParams) (int64, error)
+	SetModuleOrder(ctx context.Context, arg SetModuleOrderParams) (int64, error)
+	SetTwoFactorSecret(ctx context.Context, arg SetTwoFactorSecretParams) error
+	SoftDeleteCourse(ctx context.Context, arg SoftDeleteCourseParams) (int64, error)
+	TouchPersonalAccessToken(ctx context.Context, arg TouchPersonalAccessTokenParams) error
+	UnarchiveCourse(ctx context.Context, arg UnarchiveCourseParams) (Course, error)
+	UnlockUserAccount(ctx context.Context, id uuid.UUID) (int64, error)
+	UnpublishCourse(ctx context.Context, arg UnpublishCourseParams) (Course, error)
+	UpdateBackupCodes(ctx context.Context, arg UpdateBackupCodesParams) error
+	UpdateCourse(ctx context.Context, arg UpdateCourseParams) (Course, error)
+	UpdateLesson(ctx context.Context, arg UpdateLessonParams) (Lesson, error)
+	UpdateModule(ctx context.Context, arg UpdateModuleParams) (Module, error)
 	UpdateSessionLastAccessedAt(ctx context.Context, arg UpdateSessionLastAccessedAtParams) error
+	UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) error
+	UpdateUserIdentityLogin(ctx context.Context, arg UpdateUserIdentityLoginParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
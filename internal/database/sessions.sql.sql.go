@@ -14,6 +14,21 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+const createRotatedRefreshToken = `-- name: CreateRotatedRefreshToken :exec
+INSERT INTO rotated_refresh_tokens (session_id, token_hash)
+VALUES ($1, $2)
+`
+
+type CreateRotatedRefreshTokenParams struct {
+	SessionID uuid.UUID `json:"sessionId"`
+	TokenHash string    `json:"tokenHash"`
+}
+
+func (q *Queries) CreateRotatedRefreshToken(ctx context.Context, arg CreateRotatedRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRotatedRefreshToken, arg.SessionID, arg.TokenHash)
+	return err
+}
+
 const createSession = `-- name: CreateSession :exec
 INSERT INTO user_sessions (
         id,
@@ -156,6 +171,24 @@ func (q *Queries) GetActiveSessions(ctx context.Context, arg GetActiveSessionsPa
 	return items, nil
 }
 
+const getRotatedRefreshToken = `-- name: GetRotatedRefreshToken :one
+SELECT id, session_id, token_hash, rotated_at
+FROM rotated_refresh_tokens
+WHERE token_hash = $1
+`
+
+func (q *Queries) GetRotatedRefreshToken(ctx context.Context, tokenHash string) (RotatedRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRotatedRefreshToken, tokenHash)
+	var i RotatedRefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.TokenHash,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
 const getSessionByRefreshToken = `-- name: GetSessionByRefreshToken :one
 SELECT id, user_id, refresh_token_hash, access_token_hash, device_name, device_type, browser, browser_version, os, os_version, ip_address, location, is_active, last_accessed_at, expires_at, revoked_at, revoked_reason, created_at
 FROM user_sessions
@@ -228,6 +261,68 @@ func (q *Queries) GetSessionByUserID(ctx context.Context, arg GetSessionByUserID
 	return i, err
 }
 
+const getSessionStatus = `-- name: GetSessionStatus :one
+SELECT user_sessions.user_id,
+    user_sessions.is_active AS session_active,
+    users.is_active AS user_active,
+    users.suspended_at,
+    users.deleted_at
+FROM user_sessions
+    JOIN users ON users.id = user_sessions.user_id
+WHERE user_sessions.id = $1
+`
+
+type GetSessionStatusRow struct {
+	UserID        uuid.UUID    `json:"userId"`
+	SessionActive sql.NullBool `json:"sessionActive"`
+	UserActive    sql.NullBool `json:"userActive"`
+	SuspendedAt   sql.NullTime `json:"suspendedAt"`
+	DeletedAt     sql.NullTime `json:"deletedAt"`
+}
+
+func (q *Queries) GetSessionStatus(ctx context.Context, id uuid.UUID) (GetSessionStatusRow, error) {
+	row := q.db.QueryRowContext(ctx, getSessionStatus, id)
+	var i GetSessionStatusRow
+	err := row.Scan(
+		&i.UserID,
+		&i.SessionActive,
+		&i.UserActive,
+		&i.SuspendedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const revokeOtherUserSessions = `-- name: RevokeOtherUserSessions :execrows
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE user_id = $3
+    AND id <> $4
+    AND is_active = TRUE
+`
+
+type RevokeOtherUserSessionsParams struct {
+	RevokedAt     sql.NullTime   `json:"revokedAt"`
+	RevokedReason sql.NullString `json:"revokedReason"`
+	UserID        uuid.UUID      `json:"userId"`
+	ID            uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeOtherUserSessions,
+		arg.RevokedAt,
+		arg.RevokedReason,
+		arg.UserID,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const revokeSession = `-- name: RevokeSession :exec
 UPDATE user_sessions
 SET is_active = FALSE,
@@ -247,6 +342,91 @@ func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) er
 	return err
 }
 
+const revokeUserSession = `-- name: RevokeUserSession :execrows
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE id = $3
+    AND user_id = $4
+    AND is_active = TRUE
+`
+
+type RevokeUserSessionParams struct {
+	RevokedAt     sql.NullTime   `json:"revokedAt"`
+	RevokedReason sql.NullString `json:"revokedReason"`
+	ID            uuid.UUID      `json:"id"`
+	UserID        uuid.UUID      `json:"userId"`
+}
+
+func (q *Queries) RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserSession,
+		arg.RevokedAt,
+		arg.RevokedReason,
+		arg.ID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE user_sessions
+SET is_active = FALSE,
+    revoked_at = $1,
+    revoked_reason = $2
+WHERE user_id = $3
+    AND is_active = TRUE
+`
+
+type RevokeUserSessionsParams struct {
+	RevokedAt     sql.NullTime   `json:"revokedAt"`
+	RevokedReason sql.NullString `json:"revokedReason"`
+	UserID        uuid.UUID      `json:"userId"`
+}
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserSessions, arg.RevokedAt, arg.RevokedReason, arg.UserID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE user_sessions
+SET refresh_token_hash = $1,
+    access_token_hash = $2,
+    last_accessed_at = $3,
+    expires_at = $4
+WHERE id = $5
+    AND refresh_token_hash = $6
+    AND is_active = TRUE
+`
+
+type RotateRefreshTokenParams struct {
+	NewRefreshTokenHash string         `json:"newRefreshTokenHash"`
+	AccessTokenHash     sql.NullString `json:"accessTokenHash"`
+	LastAccessedAt      sql.NullTime   `json:"lastAccessedAt"`
+	ExpiresAt           time.Time      `json:"expiresAt"`
+	ID                  uuid.UUID      `json:"id"`
+	RefreshTokenHash    string         `json:"refreshTokenHash"`
+}
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken,
+		arg.NewRefreshTokenHash,
+		arg.AccessTokenHash,
+		arg.LastAccessedAt,
+		arg.ExpiresAt,
+		arg.ID,
+		arg.RefreshTokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateSessionLastAccessedAt = `-- name: UpdateSessionLastAccessedAt :exec
 UPDATE user_sessions
 SET last_accessed_at = $1
@@ -13,6 +13,51 @@ import (
 	"github.com/lib/pq"
 )
 
+const acceptTOTPCounter = `-- name: AcceptTOTPCounter :execrows
+UPDATE users
+SET two_factor_last_counter = $1
+WHERE id = $2
+    AND (
+        two_factor_last_counter IS NULL
+        OR two_factor_last_counter < $1
+    )
+`
+
+type AcceptTOTPCounterParams struct {
+	Counter int64     `json:"counter"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// Records the time step of an accepted TOTP code. No row is updated when a
+// code for this step or a later one was accepted before, i.e. on replay.
+func (q *Queries) AcceptTOTPCounter(ctx context.Context, arg AcceptTOTPCounterParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acceptTOTPCounter, arg.Counter, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const consumeBackupCode = `-- name: ConsumeBackupCode :execrows
+UPDATE users
+SET backup_codes = array_remove(backup_codes, $1::text)
+WHERE id = $2
+    AND $1::text = ANY(backup_codes)
+`
+
+type ConsumeBackupCodeParams struct {
+	CodeHash string    `json:"codeHash"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) ConsumeBackupCode(ctx context.Context, arg ConsumeBackupCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, consumeBackupCode, arg.CodeHash, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :exec
 INSERT INTO users (id, email, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
@@ -59,8 +104,38 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 	return err
 }
 
+const disableTwoFactor = `-- name: DisableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled = FALSE,
+    two_factor_secret = NULL,
+    backup_codes = NULL
+WHERE id = $1
+`
+
+func (q *Queries) DisableTwoFactor(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTwoFactor, id)
+	return err
+}
+
+const enableTwoFactor = `-- name: EnableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled = TRUE,
+    backup_codes = $1
+WHERE id = $2
+`
+
+type EnableTwoFactorParams struct {
+	BackupCodes []string  `json:"backupCodes"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func (q *Queries) EnableTwoFactor(ctx context.Context, arg EnableTwoFactorParams) error {
+	_, err := q.db.ExecContext(ctx, enableTwoFactor, pq.Array(arg.BackupCodes), arg.ID)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at FROM users WHERE id = $1
+SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at, email_verification_sent_at, anonymized_at, two_factor_last_counter FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -100,12 +175,15 @@ func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.EmailVerificationSentAt,
+		&i.AnonymizedAt,
+		&i.TwoFactorLastCounter,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at FROM users WHERE email = $1
+SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at, email_verification_sent_at, anonymized_at, two_factor_last_counter FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -145,6 +223,380 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.EmailVerificationSentAt,
+		&i.AnonymizedAt,
+		&i.TwoFactorLastCounter,
 	)
 	return i, err
 }
+
+const getUserByEmailVerificationToken = `-- name: GetUserByEmailVerificationToken :one
+SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at, email_verification_sent_at, anonymized_at, two_factor_last_counter
+FROM users
+WHERE email_verification_token = $1
+    AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByEmailVerificationToken(ctx context.Context, emailVerificationToken sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmailVerificationToken, emailVerificationToken)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.EmailVerified,
+		&i.EmailVerificationToken,
+		&i.EmailVerifiedAt,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.Phone,
+		&i.DateOfBirth,
+		&i.Gender,
+		&i.Country,
+		&i.Timezone,
+		&i.PreferredLanguage,
+		&i.IsActive,
+		&i.SuspendedAt,
+		&i.SuspendedReason,
+		&i.LastLoginAt,
+		&i.LoginCount,
+		&i.FailedLoginAttempts,
+		&i.FailedLoginLockedUntil,
+		&i.PasswordChangedAt,
+		&i.MustChangePassword,
+		&i.TwoFactorEnabled,
+		&i.TwoFactorSecret,
+		pq.Array(&i.BackupCodes),
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.EmailVerificationSentAt,
+		&i.AnonymizedAt,
+		&i.TwoFactorLastCounter,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, email_verified, email_verification_token, email_verified_at, password_hash, first_name, last_name, display_name, avatar_url, bio, phone, date_of_birth, gender, country, timezone, preferred_language, is_active, suspended_at, suspended_reason, last_login_at, login_count, failed_login_attempts, failed_login_locked_until, password_changed_at, must_change_password, two_factor_enabled, two_factor_secret, backup_codes, metadata, created_at, updated_at, deleted_at, email_verification_sent_at, anonymized_at, two_factor_last_counter FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.EmailVerified,
+		&i.EmailVerificationToken,
+		&i.EmailVerifiedAt,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.Phone,
+		&i.DateOfBirth,
+		&i.Gender,
+		&i.Country,
+		&i.Timezone,
+		&i.PreferredLanguage,
+		&i.IsActive,
+		&i.SuspendedAt,
+		&i.SuspendedReason,
+		&i.LastLoginAt,
+		&i.LoginCount,
+		&i.FailedLoginAttempts,
+		&i.FailedLoginLockedUntil,
+		&i.PasswordChangedAt,
+		&i.MustChangePassword,
+		&i.TwoFactorEnabled,
+		&i.TwoFactorSecret,
+		pq.Array(&i.BackupCodes),
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.EmailVerificationSentAt,
+		&i.AnonymizedAt,
+		&i.TwoFactorLastCounter,
+	)
+	return i, err
+}
+
+const getUserPreferredLanguage = `-- name: GetUserPreferredLanguage :one
+SELECT preferred_language FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserPreferredLanguage(ctx context.Context, id uuid.UUID) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getUserPreferredLanguage, id)
+	var preferred_language sql.NullString
+	err := row.Scan(&preferred_language)
+	return preferred_language, err
+}
+
+const getUserRole = `-- name: GetUserRole :one
+SELECT groups.name
+FROM user_groups
+    JOIN groups ON groups.id = user_groups.group_id
+WHERE user_groups.user_id = $1
+    AND groups.name IN ('admin', 'instructor', 'student')
+    AND (
+        user_groups.expires_at IS NULL
+        OR user_groups.expires_at > NOW()
+    )
+ORDER BY groups.priority DESC
+LIMIT 1
+`
+
+func (q *Queries) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserRole, userID)
+	var name string
+	err := row.Scan(&name)
+	return name, err
+}
+
+const lockUserAccount = `-- name: LockUserAccount :exec
+UPDATE users
+SET failed_login_locked_until = $1
+WHERE id = $2
+`
+
+type LockUserAccountParams struct {
+	FailedLoginLockedUntil sql.NullTime `json:"failedLoginLockedUntil"`
+	ID                     uuid.UUID    `json:"id"`
+}
+
+func (q *Queries) LockUserAccount(ctx context.Context, arg LockUserAccountParams) error {
+	_, err := q.db.ExecContext(ctx, lockUserAccount, arg.FailedLoginLockedUntil, arg.ID)
+	return err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :exec
+UPDATE users
+SET email_verified = TRUE,
+    email_verified_at = $1,
+    email_verification_token = NULL,
+    email_verification_sent_at = NULL
+WHERE id = $2
+`
+
+type MarkEmailVerifiedParams struct {
+	EmailVerifiedAt sql.NullTime `json:"emailVerifiedAt"`
+	ID              uuid.UUID    `json:"id"`
+}
+
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) error {
+	_, err := q.db.ExecContext(ctx, markEmailVerified, arg.EmailVerifiedAt, arg.ID)
+	return err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = COALESCE(failed_login_attempts, 0) + 1
+WHERE id = $1
+RETURNING failed_login_attempts
+`
+
+func (q *Queries) RecordFailedLogin(ctx context.Context, id uuid.UUID) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin, id)
+	var failed_login_attempts sql.NullInt32
+	err := row.Scan(&failed_login_attempts)
+	return failed_login_attempts, err
+}
+
+const recordSuccessfulLogin = `-- name: RecordSuccessfulLogin :exec
+UPDATE users
+SET failed_login_attempts = 0,
+    failed_login_locked_until = NULL,
+    login_count = COALESCE(login_count, 0) + 1,
+    last_login_at = $1
+WHERE id = $2
+`
+
+type RecordSuccessfulLoginParams struct {
+	LastLoginAt sql.NullTime `json:"lastLoginAt"`
+	ID          uuid.UUID    `json:"id"`
+}
+
+func (q *Queries) RecordSuccessfulLogin(ctx context.Context, arg RecordSuccessfulLoginParams) error {
+	_, err := q.db.ExecContext(ctx, recordSuccessfulLogin, arg.LastLoginAt, arg.ID)
+	return err
+}
+
+const setEmailVerificationToken = `-- name: SetEmailVerificationToken :exec
+UPDATE users
+SET email_verification_token = $1,
+    email_verification_sent_at = $2
+WHERE id = $3
+`
+
+type SetEmailVerificationTokenParams struct {
+	EmailVerificationToken  sql.NullString `json:"emailVerificationToken"`
+	EmailVerificationSentAt sql.NullTime   `json:"emailVerificationSentAt"`
+	ID                      uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) SetEmailVerificationToken(ctx context.Context, arg SetEmailVerificationTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setEmailVerificationToken, arg.EmailVerificationToken, arg.EmailVerificationSentAt, arg.ID)
+	return err
+}
+
+const setTwoFactorSecret = `-- name: SetTwoFactorSecret :exec
+UPDATE users
+SET two_factor_secret = $1,
+    two_factor_enabled = FALSE,
+    backup_codes = NULL
+WHERE id = $2
+`
+
+type SetTwoFactorSecretParams struct {
+	TwoFactorSecret sql.NullString `json:"twoFactorSecret"`
+	ID              uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) SetTwoFactorSecret(ctx context.Context, arg SetTwoFactorSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setTwoFactorSecret, arg.TwoFactorSecret, arg.ID)
+	return err
+}
+
+const unlockUserAccount = `-- name: UnlockUserAccount :execrows
+UPDATE users
+SET failed_login_attempts = 0,
+    failed_login_locked_until = NULL
+WHERE id = $1
+    AND deleted_at IS NULL
+`
+
+func (q *Queries) UnlockUserAccount(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unlockUserAccount, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateBackupCodes = `-- name: UpdateBackupCodes :exec
+UPDATE users
+SET backup_codes = $1
+WHERE id = $2
+`
+
+type UpdateBackupCodesParams struct {
+	BackupCodes []string  `json:"backupCodes"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateBackupCodes(ctx context.Context, arg UpdateBackupCodesParams) error {
+	_, err := q.db.ExecContext(ctx, updateBackupCodes, pq.Array(arg.BackupCodes), arg.ID)
+	return err
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :exec
+UPDATE users
+SET avatar_url = $1
+WHERE id = $2
+`
+
+type UpdateUserAvatarParams struct {
+	AvatarUrl sql.NullString `json:"avatarUrl"`
+	ID        uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserAvatar, arg.AvatarUrl, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $1,
+    password_changed_at = $2,
+    must_change_password = FALSE
+WHERE id = $3
+`
+
+type UpdateUserPasswordParams struct {
+	PasswordHash      string       `json:"passwordHash"`
+	PasswordChangedAt sql.NullTime `json:"passwordChangedAt"`
+	ID                uuid.UUID    `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.PasswordHash, arg.PasswordChangedAt, arg.ID)
+	return err
+}
+
+const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET password_hash = $1
+WHERE id = $2
+    AND password_hash = $3
+`
+
+type UpdateUserPasswordHashParams struct {
+	NewPasswordHash string    `json:"newPasswordHash"`
+	ID              uuid.UUID `json:"id"`
+	OldPasswordHash string    `json:"oldPasswordHash"`
+}
+
+// Replaces the hash of an unchanged password, e.g. with a stronger algorithm
+func (q *Queries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPasswordHash, arg.NewPasswordHash, arg.ID, arg.OldPasswordHash)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :execrows
+UPDATE users
+SET first_name = $1,
+    last_name = $2,
+    display_name = $3,
+    bio = $4,
+    phone = $5,
+    date_of_birth = $6,
+    gender = $7,
+    country = $8,
+    timezone = $9,
+    preferred_language = $10
+WHERE id = $11
+    AND deleted_at IS NULL
+`
+
+type UpdateUserProfileParams struct {
+	FirstName         string         `json:"firstName"`
+	LastName          string         `json:"lastName"`
+	DisplayName       sql.NullString `json:"displayName"`
+	Bio               sql.NullString `json:"bio"`
+	Phone             sql.NullString `json:"phone"`
+	DateOfBirth       sql.NullTime   `json:"dateOfBirth"`
+	Gender            sql.NullString `json:"gender"`
+	Country           sql.NullString `json:"country"`
+	Timezone          sql.NullString `json:"timezone"`
+	PreferredLanguage sql.NullString `json:"preferredLanguage"`
+	ID                uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserProfile,
+		arg.FirstName,
+		arg.LastName,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Phone,
+		arg.DateOfBirth,
+		arg.Gender,
+		arg.Country,
+		arg.Timezone,
+		arg.PreferredLanguage,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,175 @@
+package deletion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/export"
+	"github.com/Abdelrahiim/lms/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// pollInterval is how often the worker looks for accounts whose grace
+	// period has run out
+	pollInterval = time.Hour
+	// batchSize bounds the accounts loaded per query
+	batchSize = 100
+)
+
+// Anonymizer overwrites the personal data of deleted accounts once
+// AuthConfig.AccountDeletionGracePeriod has passed. Rows that other tables
+// point at, such as the user itself, forum posts and quiz attempts, are kept
+// so authored content and grades stay attributed to a "Deleted user".
+type Anonymizer struct {
+	db          *sql.DB
+	queries     *database.Queries
+	files       storage.Storage
+	exporter    *export.Exporter
+	audit       *audit.Logger
+	gracePeriod time.Duration
+}
+
+// New creates a new Anonymizer instance. files holds the users' uploads and
+// exporter their data exports, both of which are deleted.
+func New(cfg *config.Config, db *sql.DB, queries *database.Queries, files storage.Storage, exporter *export.Exporter) *Anonymizer {
+	return &Anonymizer{
+		db:          db,
+		queries:     queries,
+		files:       files,
+		exporter:    exporter,
+		audit:       audit.New(queries),
+		gracePeriod: cfg.Auth.AccountDeletionGracePeriod,
+	}
+}
+
+// Run anonymises accounts as their grace period runs out until ctx is
+// cancelled
+func (a *Anonymizer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.anonymizeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// anonymizeDue anonymises every account deleted longer than the grace
+// period ago
+func (a *Anonymizer) anonymizeDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		userIDs, err := a.queries.ListUsersDueForAnonymization(ctx, database.ListUsersDueForAnonymizationParams{
+			DeletedAt: sql.NullTime{Time: time.Now().Add(-a.gracePeriod), Valid: true},
+			Limit:     batchSize,
+		})
+		if err != nil {
+			log.Printf("Error listing deleted accounts: %v", err)
+			return
+		}
+
+		for _, userID := range userIDs {
+			if err := a.anonymize(ctx, userID); err != nil {
+				log.Printf("Error anonymizing account %s: %v", userID, err)
+				return
+			}
+		}
+		if len(userIDs) < batchSize {
+			return
+		}
+	}
+}
+
+// anonymize scrubs one account in a single transaction, then deletes its
+// stored files
+func (a *Anonymizer) anonymize(ctx context.Context, userID uuid.UUID) error {
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := a.queries.WithTx(tx)
+
+	// Nothing to do when the account was restored in the meantime
+	anonymized, err := qtx.AnonymizeUser(ctx, database.AnonymizeUserParams{AnonymizedAt: now, ID: userID})
+	if err != nil {
+		return fmt.Errorf("error anonymizing user: %w", err)
+	}
+	if anonymized == 0 {
+		return nil
+	}
+
+	sessions, err := qtx.AnonymizeUserSessions(ctx, database.AnonymizeUserSessionsParams{RevokedAt: now, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error anonymizing sessions: %w", err)
+	}
+
+	events, err := qtx.AnonymizeUserAnalyticsEvents(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error anonymizing analytics events: %w", err)
+	}
+
+	if err := qtx.PurgeUserCredentials(ctx, userID); err != nil {
+		return fmt.Errorf("error removing credentials: %w", err)
+	}
+
+	avatars, err := qtx.DeleteUserAvatarFiles(ctx, database.DeleteUserAvatarFilesParams{DeletedAt: now, UploadedBy: userID})
+	if err != nil {
+		return fmt.Errorf("error deleting avatars: %w", err)
+	}
+
+	archives, err := qtx.DeleteUserDataArchives(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error deleting data exports: %w", err)
+	}
+
+	err = a.audit.WithTx(tx).Record(ctx, audit.Entry{
+		Action:       "account.anonymized",
+		ResourceType: "user",
+		ResourceID:   userID,
+		NewValues: map[string]any{
+			"sessions":        sessions,
+			"analyticsEvents": events,
+			"avatars":         len(avatars),
+			"dataExports":     len(archives),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Files go last: a failed transaction must not leave a profile pointing
+	// at deleted images
+	for _, key := range avatars {
+		if err := a.files.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete avatar of anonymized account %s: %v", userID, err)
+		}
+	}
+	for _, key := range archives {
+		if !key.Valid {
+			continue
+		}
+		if err := a.exporter.Delete(ctx, key.String); err != nil {
+			log.Printf("Failed to delete data export of anonymized account %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
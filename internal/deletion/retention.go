@@ -0,0 +1,41 @@
+package deletion
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+)
+
+// LoginAttemptPruner deletes login attempts once they are older than
+// AuthConfig.LoginAttemptRetention. Each attempt records an email and a
+// client IP but is only needed within the per-IP throttling window.
+type LoginAttemptPruner struct {
+	queries   *database.Queries
+	retention time.Duration
+}
+
+// NewLoginAttemptPruner creates a new LoginAttemptPruner instance
+func NewLoginAttemptPruner(cfg *config.Config, queries *database.Queries) *LoginAttemptPruner {
+	return &LoginAttemptPruner{queries: queries, retention: cfg.Auth.LoginAttemptRetention}
+}
+
+// Run prunes expired login attempts until ctx is cancelled
+func (p *LoginAttemptPruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.queries.PruneLoginAttempts(ctx, time.Now().Add(-p.retention)); err != nil && ctx.Err() == nil {
+			log.Printf("Error pruning login attempts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/storage"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ArchiveType is the data_archives.archive_type of personal data exports
+const ArchiveType = "user_data"
+
+// mailTimeout bounds how long the worker waits on the mailer
+const mailTimeout = 30 * time.Second
+
+// dataset is one table of the user's data, written to the archive as
+// <name>.json and <name>.csv
+type dataset struct {
+	name  string
+	query func(ctx context.Context, userID uuid.UUID) ([]json.RawMessage, error)
+}
+
+// Exporter builds personal data exports in the background. Requests are
+// queued as pending rows in data_archives; the worker claims them, writes a
+// ZIP archive and mails the user a download link that expires after
+// ExportConfig.LinkTTL or ExportConfig.MaxDownloads downloads.
+type Exporter struct {
+	queries  *database.Queries
+	files    storage.Storage
+	archives *storage.LocalStorage
+	mailer   mailer.Mailer
+	cfg      config.ExportConfig
+	appURL   string
+	wake     chan struct{}
+}
+
+// New creates a new Exporter instance. files is where user uploads are read
+// from; archives are kept in ExportConfig.Path.
+func New(cfg *config.Config, queries *database.Queries, files storage.Storage, mail mailer.Mailer) (*Exporter, error) {
+	archives, err := storage.NewLocalStorage(cfg.Export.Path, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		queries:  queries,
+		files:    files,
+		archives: archives,
+		mailer:   mail,
+		cfg:      cfg.Export,
+		appURL:   cfg.Server.AppURL,
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Run builds queued exports and removes expired ones until ctx is cancelled
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.processQueue(ctx)
+		e.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// Notify wakes the worker after an export has been queued. It never blocks.
+func (e *Exporter) Notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Open reads a finished archive. The caller must close it.
+func (e *Exporter) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return e.archives.Open(ctx, key)
+}
+
+// Delete removes a finished archive
+func (e *Exporter) Delete(ctx context.Context, key string) error {
+	return e.archives.Delete(ctx, key)
+}
+
+// processQueue builds exports until none are left pending
+func (e *Exporter) processQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		archive, err := e.queries.ClaimDataArchive(ctx, ArchiveType)
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("Error claiming data export: %v", err)
+			return
+		}
+
+		if err := e.build(ctx, archive); err != nil {
+			log.Printf("Error building data export %s: %v", archive.ID, err)
+			err = e.queries.FailDataArchive(context.WithoutCancel(ctx), database.FailDataArchiveParams{
+				CompletedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+				ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+				ID:           archive.ID,
+			})
+			if err != nil {
+				log.Printf("Error marking data export %s as failed: %v", archive.ID, err)
+			}
+		}
+	}
+}
+
+// expire retires exports whose link has expired and deletes their files
+func (e *Exporter) expire(ctx context.Context) {
+	paths, err := e.queries.ExpireDataArchives(ctx, sql.NullTime{Time: time.Now(), Valid: true})
+	if err != nil {
+		log.Printf("Error expiring data exports: %v", err)
+		return
+	}
+	for _, key := range paths {
+		if !key.Valid {
+			continue
+		}
+		if err := e.archives.Delete(ctx, key.String); err != nil {
+			log.Printf("Error deleting expired data export: %v", err)
+		}
+	}
+}
+
+// build writes the archive for a claimed export, marks it completed and
+// mails the download link
+func (e *Exporter) build(ctx context.Context, archive database.DataArchive) error {
+	if !archive.UserID.Valid {
+		return errors.New("export has no user")
+	}
+	userID := archive.UserID.UUID
+
+	user, err := e.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error loading user: %w", err)
+	}
+	if user.DeletedAt.Valid {
+		return errors.New("account has been deleted")
+	}
+
+	// The archive is streamed to disk; uploads can make it far larger than
+	// the worker should hold in memory
+	key := fmt.Sprintf("%s/%s.zip", userID, archive.ID)
+	size, err := e.archives.Write(ctx, key, func(w io.Writer) error {
+		return e.buildArchive(ctx, w, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("error generating download token: %w", err)
+	}
+
+	now := time.Now()
+	err = e.queries.CompleteDataArchive(ctx, database.CompleteDataArchiveParams{
+		FilePath:          sql.NullString{String: key, Valid: true},
+		FileSize:          sql.NullInt64{Int64: size, Valid: true},
+		CompletedAt:       sql.NullTime{Time: now, Valid: true},
+		ExpiresAt:         sql.NullTime{Time: now.Add(e.cfg.LinkTTL), Valid: true},
+		DownloadTokenHash: sql.NullString{String: utils.HashToken(token), Valid: true},
+		ID:                archive.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error completing export: %w", err)
+	}
+
+	mailCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+	defer cancel()
+	if err := e.sendReadyEmail(mailCtx, user, token); err != nil {
+		log.Printf("Failed to send data export email: %v", err)
+	}
+	return nil
+}
+
+// buildArchive writes every dataset and the user's uploaded files to w as a
+// ZIP archive
+func (e *Exporter) buildArchive(ctx context.Context, w io.Writer, userID uuid.UUID) error {
+	datasets := []dataset{
+		{"profile", e.queries.ExportUserProfile},
+		{"sessions", e.queries.ExportUserSessions},
+		{"enrollments", e.queries.ExportUserEnrollments},
+		{"lesson_progress", e.queries.ExportUserLessonProgress},
+		{"quiz_attempts", e.queries.ExportUserQuizAttempts},
+		{"quiz_answers", e.queries.ExportUserQuizAnswers},
+		{"forum_threads", e.queries.ExportUserForumThreads},
+		{"forum_posts", e.queries.ExportUserForumPosts},
+		{"forum_votes", e.queries.ExportUserForumVotes},
+		{"course_ratings", e.queries.ExportUserCourseRatings},
+		{"notifications", e.queries.ExportUserNotifications},
+		{"file_uploads", e.queries.ExportUserFileUploads},
+	}
+
+	zw := zip.NewWriter(w)
+
+	var uploads []json.RawMessage
+	for _, ds := range datasets {
+		rows, err := ds.query(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("error exporting %s: %w", ds.name, err)
+		}
+		if err := writeDataset(zw, ds.name, rows); err != nil {
+			return fmt.Errorf("error writing %s: %w", ds.name, err)
+		}
+		if ds.name == "file_uploads" {
+			uploads = rows
+		}
+	}
+
+	if err := e.writeUploads(ctx, zw, uploads); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error finishing archive: %w", err)
+	}
+	return nil
+}
+
+// writeUploads copies the user's stored files below files/ in the archive.
+// Files missing from storage are skipped.
+func (e *Exporter) writeUploads(ctx context.Context, zw *zip.Writer, uploads []json.RawMessage) error {
+	for _, row := range uploads {
+		var upload struct {
+			StoragePath string `json:"storage_path"`
+		}
+		if err := json.Unmarshal(row, &upload); err != nil || upload.StoragePath == "" {
+			continue
+		}
+
+		file, err := e.files.Open(ctx, upload.StoragePath)
+		if err != nil {
+			log.Printf("Skipping upload %s in data export: %v", upload.StoragePath, err)
+			continue
+		}
+		err = copyToArchive(zw, "files/"+upload.StoragePath, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("error writing upload: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendReadyEmail mails the user the link to download their export
+func (e *Exporter) sendReadyEmail(ctx context.Context, user database.User, token string) error {
+	link := fmt.Sprintf("%s/data-export?token=%s", e.appURL, url.QueryEscape(token))
+
+	return e.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf("Hi %s,\n\nThe copy of your data you asked for is ready. The link below expires in %s and can be used %d times.\n\n%s\n\nIf you did not request this, please secure your account.\n",
+			user.FirstName, e.cfg.LinkTTL, e.cfg.MaxDownloads, link),
+	})
+}
+
+// copyToArchive adds the contents of r to the archive as name
+func copyToArchive(zw *zip.Writer, name string, r io.Reader) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
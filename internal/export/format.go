@@ -0,0 +1,92 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// writeDataset adds rows to the archive as <name>.json, an array of
+// objects, and <name>.csv, one column per key
+func writeDataset(zw *zip.Writer, name string, rows []json.RawMessage) error {
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := copyToArchive(zw, name+".json", bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	w, err := zw.Create(name + ".csv")
+	if err != nil {
+		return err
+	}
+	return writeCSV(csv.NewWriter(w), rows)
+}
+
+// writeCSV writes rows with a header of every key found in any row, sorted.
+// Nulls are written as empty cells and nested values as JSON.
+func writeCSV(w *csv.Writer, rows []json.RawMessage) error {
+	records := make([]map[string]any, 0, len(rows))
+	var header []string
+	for _, row := range rows {
+		decoder := json.NewDecoder(bytes.NewReader(row))
+		decoder.UseNumber()
+
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("error decoding row: %w", err)
+		}
+		for key := range record {
+			if !slices.Contains(header, key) {
+				header = append(header, key)
+			}
+		}
+		records = append(records, record)
+	}
+	slices.Sort(header)
+
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	line := make([]string, len(header))
+	for _, record := range records {
+		for i, key := range header {
+			cell, err := csvCell(record[key])
+			if err != nil {
+				return err
+			}
+			line[i] = cell
+		}
+		if err := w.Write(line); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// csvCell formats a decoded JSON value as a CSV cell
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/authz"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// CreateAccessTokenRequest represents the request body for creating an API key.
+// Scopes are "resource:action:scope" permissions the caller holds.
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresInDays *int     `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
+
+// AccessTokenResponse describes an API key without its secret
+type AccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAccessTokenResponse includes the API key itself, which is only ever
+// shown once
+type CreateAccessTokenResponse struct {
+	Token string `json:"token"`
+	AccessTokenResponse
+}
+
+// accessTokenPrefixLength is how much of a key is kept in clear to identify it
+const accessTokenPrefixLength = 12
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// ListAccessTokens returns the caller's active API keys
+func (h *AuthHandler) ListAccessTokens(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.queries.ListUserPersonalAccessTokens(r.Context(), principal.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting API keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, toAccessTokenResponse(token))
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// CreateAccessToken issues a new API key limited to the requested scopes.
+// Scopes must be permissions the caller currently holds.
+func (h *AuthHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[CreateAccessTokenRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, value := range req.Scopes {
+		scope, err := authz.ParseTokenScope(value)
+		if err != nil {
+			utils.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		allowed, err := middleware.GetAuthorizer().Can(r.Context(), principal.UserID, scope.Resource, scope.Action, scope.Scope)
+		if err != nil {
+			utils.SendErrorResponse(w, "Error checking permissions", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			utils.SendErrorResponse(w, fmt.Sprintf("You do not have the %s permission", scope), http.StatusForbidden)
+			return
+		}
+
+		if !slices.Contains(scopes, scope.String()) {
+			scopes = append(scopes, scope.String())
+		}
+	}
+
+	token, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating API key", http.StatusInternalServerError)
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresInDays != nil {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, *req.ExpiresInDays), Valid: true}
+	}
+
+	accessToken, err := h.queries.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:      principal.UserID,
+		Name:        req.Name,
+		TokenPrefix: token[:accessTokenPrefixLength],
+		TokenHash:   utils.HashToken(token),
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating API key", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(CreateAccessTokenResponse{
+		Token:               token,
+		AccessTokenResponse: toAccessTokenResponse(accessToken),
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteAccessToken revokes one of the caller's API keys
+func (h *AuthHandler) DeleteAccessToken(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	// Keys of other users are reported as not found
+	revoked, err := h.queries.RevokeUserPersonalAccessToken(r.Context(), database.RevokeUserPersonalAccessTokenParams{
+		ID:        tokenID,
+		UserID:    principal.UserID,
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error revoking API key", http.StatusInternalServerError)
+		return
+	}
+	if revoked == 0 {
+		utils.SendErrorResponse(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("API key revoked successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// toAccessTokenResponse converts a stored API key for API output
+func toAccessTokenResponse(token database.PersonalAccessToken) AccessTokenResponse {
+	response := AccessTokenResponse{
+		ID:        token.ID.String(),
+		Name:      token.Name,
+		Prefix:    token.TokenPrefix,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+	}
+	if response.Scopes == nil {
+		response.Scopes = []string{}
+	}
+	if token.ExpiresAt.Valid {
+		response.ExpiresAt = &token.ExpiresAt.Time
+	}
+	if token.LastUsedAt.Valid {
+		response.LastUsedAt = &token.LastUsedAt.Time
+	}
+	if token.LastUsedIp.Valid {
+		response.LastUsedIP = token.LastUsedIp.IPNet.IP.String()
+	}
+	return response
+}
@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// DeleteAccountRequest confirms the deletion of the caller's own account,
+// either with the password or, for accounts that sign in without one, with
+// the token from an emailed confirmation link
+type DeleteAccountRequest struct {
+	Password          string `json:"password" validate:"required_without=ConfirmationToken"`
+	ConfirmationToken string `json:"confirmationToken" validate:"required_without=Password"`
+}
+
+// AccountDeletionResponse reports when a deleted account will be anonymised.
+// Until then an admin can restore it.
+type AccountDeletionResponse struct {
+	utils.MutationResponse
+	AnonymizeAfter time.Time `json:"anonymizeAfter"`
+}
+
+// ownedCoursesError blocks the deletion of an instructor who still owns
+// courses
+type ownedCoursesError struct {
+	count int64
+}
+
+func (e ownedCoursesError) Error() string {
+	return fmt.Sprintf("account still owns %d courses", e.count)
+}
+
+// errAccountNotFound is returned when the account does not exist or is
+// already deleted
+var errAccountNotFound = errors.New("account not found")
+
+// accountDeletionConfirmationTTL is how long an emailed confirmation link
+// stays valid
+const accountDeletionConfirmationTTL = 30 * time.Minute
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// RequestAccountDeletionConfirmation emails the caller a single-use link
+// that confirms the deletion of their account. Accounts provisioned through
+// single sign-on have no password to confirm with.
+func (h *UserHandler) RequestAccountDeletionConfirmation(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil || user.DeletedAt.Valid {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating confirmation link", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating confirmation link", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	// Only the most recent link stays valid
+	now := time.Now()
+	err = qtx.InvalidateAccountDeletionConfirmations(r.Context(), database.InvalidateAccountDeletionConfirmationsParams{
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+		UserID: user.ID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating confirmation link", http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.CreateAccountDeletionConfirmation(r.Context(), database.CreateAccountDeletionConfirmationParams{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(accountDeletionConfirmationTTL),
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating confirmation link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error creating confirmation link", http.StatusInternalServerError)
+		return
+	}
+
+	link := fmt.Sprintf("%s/delete-account?token=%s", h.config.Server.AppURL, url.QueryEscape(token))
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm the deletion of your account",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to confirm that your account should be deleted. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for this, you can ignore this email and your account stays as it is.\n",
+			user.FirstName, accountDeletionConfirmationTTL, link),
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := h.mailer.Send(ctx, message); err != nil {
+			log.Printf("Failed to send account deletion confirmation: %v", err)
+		}
+	}()
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("A confirmation link has been sent to your email")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteAccount deletes the caller's own account after checking the
+// password or the token from a confirmation link. Every session is signed
+// out right away; personal data is anonymised once the grace period has
+// passed.
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[DeleteAccountRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil || user.DeletedAt.Valid {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if req.ConfirmationToken != "" {
+		consumed, err := h.queries.ConsumeAccountDeletionConfirmation(r.Context(), database.ConsumeAccountDeletionConfirmationParams{
+			Now:       sql.NullTime{Time: time.Now(), Valid: true},
+			TokenHash: utils.HashToken(req.ConfirmationToken),
+			UserID:    user.ID,
+		})
+		if err != nil {
+			utils.SendErrorResponse(w, "Error deleting account", http.StatusInternalServerError)
+			return
+		}
+		if consumed == 0 {
+			utils.SendErrorResponse(w, "Invalid or expired confirmation link", http.StatusBadRequest)
+			return
+		}
+	} else if err := h.hasher.Verify(user.PasswordHash, req.Password); err != nil {
+		utils.SendErrorResponse(w, "Password is incorrect", http.StatusBadRequest)
+		return
+	}
+
+	deletedAt, err := scheduleAccountDeletion(r, h.db, h.queries, h.audit, principal.UserID, user.ID)
+	if err != nil {
+		sendAccountDeletionError(w, err)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(AccountDeletionResponse{
+		MutationResponse: utils.SendMutationResponse("Account deleted successfully"),
+		AnonymizeAfter:   deletedAt.Add(h.config.Auth.AccountDeletionGracePeriod),
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteUser deletes any account on behalf of its owner, e.g. after a
+// request to support
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Admins delete their own account through the self-service endpoint,
+	// which asks them to confirm it
+	if userID == principal.UserID {
+		utils.SendErrorResponse(w, "Use the account deletion endpoint to delete your own account", http.StatusBadRequest)
+		return
+	}
+
+	deletedAt, err := scheduleAccountDeletion(r, h.db, h.queries, h.audit, principal.UserID, userID)
+	if err != nil {
+		sendAccountDeletionError(w, err)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(AccountDeletionResponse{
+		MutationResponse: utils.SendMutationResponse("User deleted successfully"),
+		AnonymizeAfter:   deletedAt.Add(h.config.Auth.AccountDeletionGracePeriod),
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// RestoreUser undoes a deletion whose grace period has not run out yet. The
+// user signs in again; revoked sessions stay revoked.
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error restoring user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	restored, err := qtx.CancelUserDeletion(r.Context(), database.CancelUserDeletionParams{
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:        userID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error restoring user", http.StatusInternalServerError)
+		return
+	}
+	if restored == 0 {
+		utils.SendErrorResponse(w, "No deleted user that can still be restored", http.StatusNotFound)
+		return
+	}
+
+	err = h.audit.WithTx(tx).Record(r.Context(), audit.Entry{
+		ActorID:      principal.UserID,
+		Action:       "account.deletion_cancelled",
+		ResourceType: "user",
+		ResourceID:   userID,
+	}.FromRequest(r))
+	if err != nil {
+		log.Printf("Failed to restore user: %v", err)
+		utils.SendErrorResponse(w, "Error restoring user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error restoring user", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("User restored successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// scheduleAccountDeletion marks the account deleted, signs out all of its
+// sessions and records who asked for it. Instructors who still own courses
+// are refused with an ownedCoursesError.
+func scheduleAccountDeletion(r *http.Request, db *sql.DB, queries *database.Queries, logger *audit.Logger, actorID, userID uuid.UUID) (time.Time, error) {
+	now := time.Now()
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		return now, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := queries.WithTx(tx)
+
+	// Courses would be left without an instructor
+	courses, err := qtx.CountOwnedCourses(r.Context(), userID)
+	if err != nil {
+		return now, err
+	}
+	if courses > 0 {
+		return now, ownedCoursesError{count: courses}
+	}
+
+	deleted, err := qtx.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+		DeletedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        userID,
+	})
+	if err != nil {
+		return now, err
+	}
+	if deleted == 0 {
+		return now, errAccountNotFound
+	}
+
+	err = qtx.RevokeUserSessions(r.Context(), database.RevokeUserSessionsParams{
+		RevokedAt:     sql.NullTime{Time: now, Valid: true},
+		RevokedReason: sql.NullString{String: "Account deleted", Valid: true},
+		UserID:        userID,
+	})
+	if err != nil {
+		return now, err
+	}
+
+	requestedBy := "admin"
+	if actorID == userID {
+		requestedBy = "user"
+	}
+	err = logger.WithTx(tx).Record(r.Context(), audit.Entry{
+		ActorID:      actorID,
+		Action:       "account.deletion_requested",
+		ResourceType: "user",
+		ResourceID:   userID,
+		NewValues: map[string]any{
+			"requestedBy": requestedBy,
+			"deletedAt":   now,
+		},
+	}.FromRequest(r))
+	if err != nil {
+		return now, err
+	}
+
+	return now, tx.Commit()
+}
+
+// sendAccountDeletionError answers a failed scheduleAccountDeletion
+func sendAccountDeletionError(w http.ResponseWriter, err error) {
+	var owned ownedCoursesError
+	switch {
+	case errors.As(err, &owned):
+		utils.SendErrorResponse(w, fmt.Sprintf("Transfer the %d courses this account still owns to another instructor first", owned.count), http.StatusConflict)
+	case errors.Is(err, errAccountNotFound):
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+	default:
+		log.Printf("Failed to delete account: %v", err)
+		utils.SendErrorResponse(w, "Error deleting account", http.StatusInternalServerError)
+	}
+}
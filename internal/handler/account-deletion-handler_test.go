@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/password"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+const testPassword = "correct horse battery staple"
+
+func TestAccountDeletionAudit(t *testing.T) {
+	adminID := uuid.New()
+
+	tests := []struct {
+		name   string
+		action string
+		serve  func(db *deletionDB, conn *sql.DB, w http.ResponseWriter, r *http.Request)
+		caller func(db *deletionDB) uuid.UUID
+	}{
+		{
+			name:   "self-service deletion",
+			action: "account.deletion_requested",
+			serve: func(db *deletionDB, conn *sql.DB, w http.ResponseWriter, r *http.Request) {
+				h := NewUserHandler(conn, database.New(conn), deletionConfig(), nil, nil, db.hasher, nil)
+				middleware.ValidateJSON[DeleteAccountRequest](h.DeleteAccount)(w, r)
+			},
+			caller: func(db *deletionDB) uuid.UUID { return db.userID },
+		},
+		{
+			name:   "admin deletion",
+			action: "account.deletion_requested",
+			serve: func(_ *deletionDB, conn *sql.DB, w http.ResponseWriter, r *http.Request) {
+				NewAdminHandler(conn, database.New(conn), deletionConfig(), utils.TokenOptions{}).DeleteUser(w, r)
+			},
+			caller: func(*deletionDB) uuid.UUID { return adminID },
+		},
+		{
+			name:   "admin restore",
+			action: "account.deletion_cancelled",
+			serve: func(_ *deletionDB, conn *sql.DB, w http.ResponseWriter, r *http.Request) {
+				NewAdminHandler(conn, database.New(conn), deletionConfig(), utils.TokenOptions{}).RestoreUser(w, r)
+			},
+			caller: func(*deletionDB) uuid.UUID { return adminID },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDeletionDB(t)
+			conn := sql.OpenDB(db)
+			t.Cleanup(func() { conn.Close() })
+
+			body := strings.NewReader(fmt.Sprintf(`{"password":%q}`, testPassword))
+			r := httptest.NewRequest(http.MethodDelete, "/", body)
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-Forwarded-For", "203.0.113.7")
+			r.SetPathValue("id", db.userID.String())
+			r = r.WithContext(middleware.WithPrincipal(r.Context(), &middleware.Principal{UserID: tt.caller(db)}))
+
+			w := httptest.NewRecorder()
+			tt.serve(db, conn, w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			if !slices.Contains(db.committedAudits(), tt.action) {
+				t.Errorf("committed audit entries = %v, want %s", db.committedAudits(), tt.action)
+			}
+		})
+	}
+}
+
+func deletionConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Auth.AccountDeletionGracePeriod = 30 * 24 * time.Hour
+	return cfg
+}
+
+// deletionDB is a database/sql driver holding one account that can be
+// deleted and restored. Like Postgres it rejects audit entries whose IP
+// address is not a valid INET value, and only keeps entries written in a
+// transaction once it commits.
+type deletionDB struct {
+	userID       uuid.UUID
+	passwordHash string
+	hasher       *password.Hasher
+
+	mu        sync.Mutex
+	pending   []string
+	committed []string
+}
+
+func newDeletionDB(t *testing.T) *deletionDB {
+	t.Helper()
+	hasher, err := password.NewHasher(config.AuthConfig{PasswordHashAlgorithm: password.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatalf("error creating hasher: %v", err)
+	}
+	hash, err := hasher.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("error hashing password: %v", err)
+	}
+	return &deletionDB{userID: uuid.New(), passwordHash: hash, hasher: hasher}
+}
+
+func (db *deletionDB) committedAudits() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return slices.Clone(db.committed)
+}
+
+func (db *deletionDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *deletionDB) Driver() driver.Driver                        { return nil }
+
+func (db *deletionDB) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (db *deletionDB) Close() error                        { return nil }
+func (db *deletionDB) Begin() (driver.Tx, error)           { return db, nil }
+
+func (db *deletionDB) Commit() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.committed = append(db.committed, db.pending...)
+	db.pending = nil
+	return nil
+}
+
+func (db *deletionDB) Rollback() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.pending = nil
+	return nil
+}
+
+func (db *deletionDB) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "CreateAuditLog":
+		if ip, ok := args[6].Value.(string); ok && net.ParseIP(strings.Split(ip, "/")[0]) == nil {
+			return nil, fmt.Errorf("pq: invalid input syntax for type inet: %q", ip)
+		}
+		db.mu.Lock()
+		db.pending = append(db.pending, args[1].Value.(string))
+		db.mu.Unlock()
+	case "ScheduleUserDeletion", "CancelUserDeletion", "RevokeUserSessions":
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (db *deletionDB) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "GetUserByID":
+		user := make([]driver.Value, 36)
+		user[0], user[1], user[5], user[6], user[7] = db.userID.String(), "student@example.test", db.passwordHash, "Test", "Student"
+		return &staticRows{rows: [][]driver.Value{user}}, nil
+	case "CountOwnedCourses":
+		return &staticRows{rows: [][]driver.Value{{int64(0)}}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+}
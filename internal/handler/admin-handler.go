@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// AdminHandler handles system administration HTTP requests
+type AdminHandler struct {
+	db      *sql.DB
+	queries *database.Queries
+	config  *config.Config
+	tokens  utils.TokenOptions
+	audit   *audit.Logger
+}
+
+// ============================================================================
+// CONSTRUCTOR
+// ============================================================================
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(db *sql.DB, queries *database.Queries, config *config.Config, tokens utils.TokenOptions) *AdminHandler {
+	return &AdminHandler{
+		db:      db,
+		queries: queries,
+		config:  config,
+		tokens:  tokens,
+		audit:   audit.New(queries),
+	}
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// UnlockUser clears a login lockout and the failed attempt counter
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	unlocked, err := h.queries.UnlockUserAccount(r.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error unlocking user", http.StatusInternalServerError)
+		return
+	}
+	if unlocked == 0 {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("User unlocked successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ListUserAccessTokens returns a user's active API keys
+func (h *AdminHandler) ListUserAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.queries.ListUserPersonalAccessTokens(r.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting API keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, toAccessTokenResponse(token))
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// RevokeAccessToken revokes any user's API key
+func (h *AdminHandler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.queries.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:        tokenID,
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error revoking API key", http.StatusInternalServerError)
+		return
+	}
+	if revoked == 0 {
+		utils.SendErrorResponse(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("API key revoked successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -1,16 +1,22 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Abdelrahiim/lms/internal/config"
 	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
 	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/password"
 	"github.com/Abdelrahiim/lms/internal/utils"
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
@@ -25,23 +31,33 @@ type AuthHandler struct {
 	db      *sql.DB
 	queries *database.Queries
 	config  *config.Config
+	mailer  mailer.Mailer
+	tokens  utils.TokenOptions
+	hasher  *password.Hasher
+	policy  *password.Policy
 }
 
 // RegisterRequest represents the user registration payload
 type RegisterRequest struct {
-	Email             string `json:"email" validate:"required,email"`
-	Password          string `json:"password" validate:"required,min=8"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	AvatarURL string `json:"avatarUrl,omitempty" validate:"omitempty,url"`
+	ProfileFields
+}
+
+// ProfileFields are the profile attributes set at registration and through
+// profile updates, validated the same way in both
+type ProfileFields struct {
 	FirstName         string `json:"firstName" validate:"required"`
 	LastName          string `json:"lastName" validate:"required"`
 	DisplayName       string `json:"displayName,omitempty"`
-	AvatarURL         string `json:"avatarUrl,omitempty" validate:"omitempty,url"`
 	Bio               string `json:"bio,omitempty" validate:"omitempty,max=500"`
 	Phone             string `json:"phone,omitempty" validate:"omitempty,e164"`
 	DateOfBirth       string `json:"dateOfBirth,omitempty" validate:"omitempty,datetime=2006-01-02"`
 	Gender            string `json:"gender,omitempty" validate:"omitempty,oneof=male female other"`
-	Country           string `json:"country,omitempty" validate:"omitempty,len=2"`
-	Timezone          string `json:"timezone,omitempty" validate:"omitempty,timezone"`
-	PreferredLanguage string `json:"preferredLanguage,omitempty" validate:"omitempty,len=2"`
+	Country           string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	Timezone          string `json:"timezone,omitempty" validate:"omitempty,max=50,timezone"`
+	PreferredLanguage string `json:"preferredLanguage,omitempty" validate:"omitempty,max=10,bcp47_language_tag"`
 }
 
 // LoginRequest represents the user login payload
@@ -50,16 +66,20 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// LoginResponse represents the successful login response
+// LoginResponse represents the successful login response. When
+// MustChangePassword is set the access token only works for changing the
+// password and signing out.
 type LoginResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	User         User   `json:"user"`
+	AccessToken        string `json:"accessToken"`
+	RefreshToken       string `json:"refreshToken"`
+	User               User   `json:"user"`
+	MustChangePassword bool   `json:"mustChangePassword,omitempty"`
 }
 
 // RefreshResponse represents the token refresh response
 type RefreshResponse struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
 }
 
 // User represents the user data in responses
@@ -75,11 +95,15 @@ type User struct {
 // ============================================================================
 
 // NewAuthHandler creates a new AuthHandler instance
-func NewAuthHandler(db *sql.DB, queries *database.Queries, config *config.Config) *AuthHandler {
+func NewAuthHandler(db *sql.DB, queries *database.Queries, config *config.Config, mailer mailer.Mailer, tokens utils.TokenOptions, hasher *password.Hasher) *AuthHandler {
 	return &AuthHandler{
 		db:      db,
 		queries: queries,
 		config:  config,
+		mailer:  mailer,
+		tokens:  tokens,
+		hasher:  hasher,
+		policy:  password.NewPolicy(config.Auth),
 	}
 }
 
@@ -103,34 +127,41 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Enforce the password policy
+	if errs := h.checkPasswordPolicy(r, "password", req.Password, req.Email, req.FirstName, req.LastName, req.DisplayName); len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
 	// Hash the password before storing
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := h.hasher.Hash(req.Password)
 	if err != nil {
 		utils.SendErrorResponse(w, "Error hashing password", http.StatusInternalServerError)
 		return
 	}
 
+	// Create the user and its default group membership atomically
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
 	// Create user with all provided data
-	err = h.queries.CreateUser(r.Context(), database.CreateUserParams{
-		ID:           uuid.New(),
-		Email:        req.Email,
-		PasswordHash: hashedPassword,
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		DisplayName:  sql.NullString{String: req.DisplayName, Valid: req.DisplayName != ""},
-		AvatarUrl:    sql.NullString{String: req.AvatarURL, Valid: req.AvatarURL != ""},
-		Bio:          sql.NullString{String: req.Bio, Valid: req.Bio != ""},
-		Phone:        sql.NullString{String: req.Phone, Valid: req.Phone != ""},
-		DateOfBirth: func() sql.NullTime {
-			if req.DateOfBirth == "" {
-				return sql.NullTime{Valid: false}
-			}
-			t, err := time.Parse("2006-01-02", req.DateOfBirth)
-			if err != nil {
-				return sql.NullTime{Valid: false}
-			}
-			return sql.NullTime{Time: t, Valid: true}
-		}(),
+	userID := uuid.New()
+	err = qtx.CreateUser(r.Context(), database.CreateUserParams{
+		ID:                userID,
+		Email:             req.Email,
+		PasswordHash:      hashedPassword,
+		FirstName:         req.FirstName,
+		LastName:          req.LastName,
+		DisplayName:       sql.NullString{String: req.DisplayName, Valid: req.DisplayName != ""},
+		AvatarUrl:         sql.NullString{String: req.AvatarURL, Valid: req.AvatarURL != ""},
+		Bio:               sql.NullString{String: req.Bio, Valid: req.Bio != ""},
+		Phone:             sql.NullString{String: req.Phone, Valid: req.Phone != ""},
+		DateOfBirth:       parseDate(req.DateOfBirth),
 		Gender:            sql.NullString{String: req.Gender, Valid: req.Gender != ""},
 		Country:           sql.NullString{String: req.Country, Valid: req.Country != ""},
 		Timezone:          sql.NullString{String: req.Timezone, Valid: req.Timezone != ""},
@@ -141,6 +172,31 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// New users start in the student group
+	err = qtx.AddUserToGroup(r.Context(), database.AddUserToGroupParams{
+		UserID:    userID,
+		GroupName: middleware.RoleStudent,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error creating user", http.StatusInternalServerError)
+		return
+	}
+
+	// Send the verification email in the background
+	newUser := database.User{ID: userID, Email: req.Email, FirstName: req.FirstName}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := h.sendEmailVerification(ctx, newUser); err != nil {
+			log.Printf("Failed to send verification email: %v", err)
+		}
+	}()
+
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -158,22 +214,226 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user exists
-	user, err := h.queries.GetUserByEmail(r.Context(), req.Email)
+	// Throttle clients that keep failing, whatever account they target
+	clientIP := utils.GetClientIP(r)
+	throttled, err := h.isIPThrottled(r.Context(), clientIP)
 	if err != nil {
+		// Fail closed: without the count the client could guess without limit
+		log.Printf("Per-IP login throttling unavailable, refusing login: %v", err)
+		utils.SendErrorResponse(w, "Error processing login", http.StatusInternalServerError)
+		return
+	}
+	if throttled {
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.config.Auth.LoginIPWindow/time.Second)))
+		utils.SendErrorResponse(w, "Too many login attempts, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	// Check if user exists and may sign in. Unknown emails and disabled
+	// accounts still pay for a hash comparison so response times do not
+	// reveal which accounts exist.
+	user, err := h.queries.GetUserByEmail(r.Context(), req.Email)
+	if err != nil || isAccountDisabled(user) {
+		h.hasher.VerifyDummy(req.Password)
+		h.recordLoginAttempt(r.Context(), req.Email, uuid.NullUUID{}, clientIP, false)
+		utils.SendErrorResponse(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	// Locked accounts get the same answer as a wrong password, after the
+	// same hash work so that timing does not reveal the lock either
+	if isAccountLocked(user) {
+		_ = h.hasher.Verify(user.PasswordHash, req.Password) // Result ignored; the account is locked
+		h.recordLoginAttempt(r.Context(), req.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, clientIP, false)
 		utils.SendErrorResponse(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
 	// Verify password
-	err = utils.CheckPasswordHash(user.PasswordHash, req.Password)
+	err = h.hasher.Verify(user.PasswordHash, req.Password)
 	if err != nil {
+		h.recordLoginAttempt(r.Context(), req.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, clientIP, false)
+		h.registerFailedLogin(r.Context(), user)
 		utils.SendErrorResponse(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	h.recordLoginAttempt(r.Context(), req.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, clientIP, true)
+
+	// Upgrade hashes made with an outdated algorithm or cost while the
+	// plaintext is at hand
+	if h.hasher.NeedsRehash(user.PasswordHash) {
+		h.rehashPassword(r.Context(), user, req.Password)
+	}
+
+	// Unverified accounts may be barred from signing in
+	if !user.EmailVerified.Bool && h.config.Auth.UnverifiedEmailPolicy == config.UnverifiedEmailBlock {
+		utils.SendErrorResponse(w, "Email address not verified", http.StatusForbidden)
+		return
+	}
+
+	// Two-factor accounts must complete a second step first
+	if user.TwoFactorEnabled.Bool {
+		h.sendMFAChallenge(w, user)
+		return
+	}
+
+	h.createSession(w, r, user)
+}
+
+// Logout handles user logout requests
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok || principal.SessionID == uuid.Nil {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Revoke the session the presented token was issued for
+	_, err := h.queries.RevokeUserSession(r.Context(), database.RevokeUserSessionParams{
+		ID:            principal.SessionID,
+		UserID:        principal.UserID,
+		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedReason: sql.NullString{String: "User logged out", Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Logged out successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// Refresh handles token refresh requests. Every call rotates the refresh
+// token; presenting a token that was already rotated revokes the session.
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	// Extract refresh token from request
+	refreshToken, err := utils.GetBearerToken(r.Header)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting bearer token", http.StatusUnauthorized)
+		return
+	}
+	refreshTokenHash := utils.HashToken(refreshToken)
+
+	// Get session by refresh token
+	session, err := h.queries.GetSessionByRefreshToken(r.Context(), refreshTokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.detectRefreshTokenReuse(r.Context(), refreshTokenHash)
+		}
+		utils.SendErrorResponse(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	// Enforce session expiry. Refreshing extends an idle session, but never
+	// past its absolute lifetime.
+	now := time.Now()
+	deadline := session.CreatedAt.Time.Add(h.config.Auth.SessionMaxAge)
+	if now.After(session.ExpiresAt) || !session.CreatedAt.Valid || now.After(deadline) {
+		h.revokeSession(r.Context(), session.ID, "Session expired")
+		utils.SendErrorResponse(w, "Refresh token expired", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user information
+	user, err := h.queries.GetUser(r.Context(), session.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting user", http.StatusInternalServerError)
+		return
+	}
+	if isAccountDisabled(user) {
+		h.revokeSession(r.Context(), session.ID, "Account disabled")
+		utils.SendErrorResponse(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	// Generate new access token
+	accessToken, err := h.generateAccessToken(r.Context(), user, session.ID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating access token", http.StatusInternalServerError)
+		return
+	}
+
+	// Generate the replacement refresh token
+	newRefreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	// Swap the tokens and remember the old one for reuse detection
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	expiresAt := now.Add(h.config.Auth.RefreshTokenExpiry)
+	if expiresAt.After(deadline) {
+		expiresAt = deadline
+	}
+	rotated, err := qtx.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+		ID:                  session.ID,
+		RefreshTokenHash:    refreshTokenHash,
+		NewRefreshTokenHash: utils.HashToken(newRefreshToken),
+		AccessTokenHash:     sql.NullString{String: utils.HashToken(accessToken), Valid: true},
+		LastAccessedAt:      sql.NullTime{Time: now, Valid: true},
+		ExpiresAt:           expiresAt,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+	if rotated == 0 {
+		// Another request rotated this token first
+		utils.SendErrorResponse(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	err = qtx.CreateRotatedRefreshToken(r.Context(), database.CreateRotatedRefreshTokenParams{
+		SessionID: session.ID,
+		TokenHash: refreshTokenHash,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare refresh response
+	refreshResponse := RefreshResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}
+
+	// Send refresh response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(refreshResponse); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
 
-	// Generate access token
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, h.config.Auth.JWTSecret)
+// createSession starts a new session for an authenticated user and sends
+// the access and refresh tokens as the login response
+func (h *AuthHandler) createSession(w http.ResponseWriter, r *http.Request, user database.User) {
+	// Generate access token bound to the new session
+	sessionID := uuid.New()
+	accessToken, err := h.generateAccessToken(r.Context(), user, sessionID)
 	if err != nil {
 		utils.SendErrorResponse(w, "Error generating tokens", http.StatusInternalServerError)
 		return
@@ -186,29 +446,46 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Location is stored as a JSON string
+	location := pqtype.NullRawMessage{}
+	if loc := utils.GetLocation(r); loc != "" {
+		if encoded, err := json.Marshal(loc); err == nil {
+			location = pqtype.NullRawMessage{RawMessage: encoded, Valid: true}
+		}
+	}
+
 	// Create user session with device and location information
 	err = h.queries.CreateSession(r.Context(), database.CreateSessionParams{
-		ID:               uuid.New(),
+		ID:               sessionID,
 		UserID:           user.ID,
-		RefreshTokenHash: refreshToken,
-		AccessTokenHash:  sql.NullString{String: accessToken, Valid: true},
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		AccessTokenHash:  sql.NullString{String: utils.HashToken(accessToken), Valid: true},
 		DeviceName:       sql.NullString{String: r.Header.Get("User-Agent"), Valid: r.Header.Get("User-Agent") != ""},
 		DeviceType:       sql.NullString{String: utils.GetDeviceType(r), Valid: true},
 		Browser:          sql.NullString{String: utils.GetBrowser(r), Valid: true},
 		BrowserVersion:   sql.NullString{String: utils.GetBrowserVersion(r), Valid: true},
 		Os:               sql.NullString{String: utils.GetOS(r), Valid: true},
 		OsVersion:        sql.NullString{String: utils.GetOSVersion(r), Valid: true},
-		IpAddress:        pqtype.Inet{IPNet: net.IPNet{IP: net.ParseIP(utils.GetClientIP(r))}, Valid: true},
-		Location:         pqtype.NullRawMessage{RawMessage: []byte(utils.GetLocation(r)), Valid: utils.GetLocation(r) != ""},
+		IpAddress:        utils.InetFromIP(net.ParseIP(utils.GetClientIP(r))),
+		Location:         location,
 		IsActive:         sql.NullBool{Bool: true, Valid: true},
 		LastAccessedAt:   sql.NullTime{Time: time.Now(), Valid: true},
-		ExpiresAt:        time.Now().AddDate(0, 0, 60), // 60 days expiration
+		ExpiresAt:        time.Now().Add(h.config.Auth.RefreshTokenExpiry),
 	})
 	if err != nil {
 		utils.SendErrorResponse(w, "Error creating session", http.StatusInternalServerError)
 		return
 	}
 
+	// Successful sign-in clears any failed attempts
+	err = h.queries.RecordSuccessfulLogin(r.Context(), database.RecordSuccessfulLoginParams{
+		ID:          user.ID,
+		LastLoginAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to record login for user %s: %v", user.ID, err)
+	}
+
 	// Prepare login response
 	loginResponse := LoginResponse{
 		AccessToken:  accessToken,
@@ -219,116 +496,187 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
 		},
+		MustChangePassword: user.MustChangePassword.Bool,
 	}
 
 	// Send login response
-	jsonData, err := json.Marshal(loginResponse)
-	if err != nil {
-		utils.SendErrorResponse(w, "Error marshalling login response", http.StatusInternalServerError)
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(jsonData); err != nil {
+	if err := json.NewEncoder(w).Encode(loginResponse); err != nil {
 		log.Printf("Failed to encode response: %v", err)
 	}
 }
 
-// Logout handles user logout requests
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// Extract and validate bearer token
-	token, err := utils.GetBearerToken(r.Header)
-	if err != nil {
-		utils.SendErrorResponse(w, "Error getting bearer token", http.StatusUnauthorized)
-		return
+// lockoutDuration doubles base once for every failure past the threshold,
+// stopping at limit. Doubling step by step keeps large failure counts from
+// overflowing into a negative lockout.
+func lockoutDuration(base, limit time.Duration, excess int) time.Duration {
+	lockout := base
+	for range excess {
+		if lockout > limit/2 {
+			return limit
+		}
+		lockout *= 2
 	}
+	return min(lockout, limit)
+}
 
-	// Validate JWT token
-	claims, err := utils.ValidateJWT(token, h.config.Auth.JWTSecret)
+// isIPThrottled reports whether the client IP has exceeded the allowed number
+// of failed logins within the configured window. Callers must refuse the
+// login when it fails.
+func (h *AuthHandler) isIPThrottled(ctx context.Context, clientIP string) (bool, error) {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false, fmt.Errorf("invalid client IP %q", clientIP)
+	}
+	failures, err := h.queries.CountRecentFailedLoginsByIP(ctx, database.CountRecentFailedLoginsByIPParams{
+		IpAddress:   utils.InetFromIP(ip),
+		AttemptedAt: time.Now().Add(-h.config.Auth.LoginIPWindow),
+	})
 	if err != nil {
-		utils.SendErrorResponse(w, "Error validating token", http.StatusUnauthorized)
-		return
+		return false, fmt.Errorf("error counting login attempts for %s: %w", clientIP, err)
 	}
+	return failures >= int64(h.config.Auth.LoginIPMaxAttempts), nil
+}
 
-	// Get user session by user ID and IP address
-	session, err := h.queries.GetSessionByUserID(r.Context(), database.GetSessionByUserIDParams{
-		UserID:    uuid.MustParse(claims.UserID),
-		IpAddress: pqtype.Inet{IPNet: net.IPNet{IP: net.ParseIP(utils.GetClientIP(r))}, Valid: true},
+// recordLoginAttempt stores a login attempt for per-IP throttling
+func (h *AuthHandler) recordLoginAttempt(ctx context.Context, email string, userID uuid.NullUUID, clientIP string, succeeded bool) {
+	err := h.queries.CreateLoginAttempt(ctx, database.CreateLoginAttemptParams{
+		Email:     email,
+		UserID:    userID,
+		IpAddress: utils.InetFromIP(net.ParseIP(clientIP)),
+		Succeeded: succeeded,
 	})
 	if err != nil {
-		utils.SendErrorResponse(w, "Error getting session", http.StatusInternalServerError)
-		return
+		log.Printf("Failed to record login attempt: %v", err)
 	}
+}
 
-	// Revoke the session
-	err = h.queries.RevokeSession(r.Context(), database.RevokeSessionParams{
-		ID:            session.ID,
-		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
-		RevokedReason: sql.NullString{String: "User logged out", Valid: true},
-	})
+// registerFailedLogin counts a failed attempt against the account and locks
+// it once the threshold is reached. Each failure past the threshold doubles
+// the lockout, up to the configured maximum.
+func (h *AuthHandler) registerFailedLogin(ctx context.Context, user database.User) {
+	attempts, err := h.queries.RecordFailedLogin(ctx, user.ID)
 	if err != nil {
-		utils.SendErrorResponse(w, "Error revoking session", http.StatusInternalServerError)
+		log.Printf("Failed to record failed login for user %s: %v", user.ID, err)
 		return
 	}
 
-	// Send success response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Logged out successfully")); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+	excess := int(attempts.Int32) - h.config.Auth.LoginMaxAttempts
+	if excess < 0 {
+		return
 	}
-}
 
-// Refresh handles token refresh requests
-func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	// Extract refresh token from request
-	refreshToken, err := utils.GetBearerToken(r.Header)
+	lockout := lockoutDuration(h.config.Auth.LoginLockoutDuration, h.config.Auth.LoginLockoutMaxDuration, excess)
+	lockedUntil := time.Now().Add(lockout)
+	err = h.queries.LockUserAccount(ctx, database.LockUserAccountParams{
+		ID:                     user.ID,
+		FailedLoginLockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+	})
 	if err != nil {
-		utils.SendErrorResponse(w, "Error getting bearer token", http.StatusUnauthorized)
+		log.Printf("Failed to lock user %s: %v", user.ID, err)
 		return
 	}
 
-	// Get session by refresh token
-	session, err := h.queries.GetSessionByRefreshToken(r.Context(), refreshToken)
+	// Let the owner know, since the login response deliberately does not
+	mailCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+	go func() {
+		defer cancel()
+		err := h.mailer.Send(mailCtx, mailer.Message{
+			To:      user.Email,
+			Subject: "Your account has been temporarily locked",
+			Body: fmt.Sprintf("Hi %s,\n\nWe locked your account until %s after %d failed sign-in attempts.\n\nIf this was not you, consider resetting your password.\n",
+				user.FirstName, lockedUntil.UTC().Format(time.RFC1123), attempts.Int32),
+		})
+		if err != nil {
+			log.Printf("Failed to send lockout email: %v", err)
+		}
+	}()
+}
+
+// isAccountDisabled reports whether the account was deactivated, suspended
+// or deleted and must not be issued tokens
+func isAccountDisabled(user database.User) bool {
+	return user.DeletedAt.Valid || user.SuspendedAt.Valid || (user.IsActive.Valid && !user.IsActive.Bool)
+}
+
+// isAccountLocked reports whether the account is inside a lockout period
+func isAccountLocked(user database.User) bool {
+	return user.FailedLoginLockedUntil.Valid && time.Now().Before(user.FailedLoginLockedUntil.Time)
+}
+
+// generateAccessToken issues an access token for the user bound to the given session
+func (h *AuthHandler) generateAccessToken(ctx context.Context, user database.User, sessionID uuid.UUID) (string, error) {
+	role, err := h.getUserRole(ctx, user.ID)
 	if err != nil {
-		utils.SendErrorResponse(w, "Invalid refresh token", http.StatusUnauthorized)
-		return
+		return "", err
 	}
 
-	// Get user information
-	user, err := h.queries.GetUser(r.Context(), session.UserID)
-	if err != nil {
-		utils.SendErrorResponse(w, "Error getting user", http.StatusInternalServerError)
-		return
+	return utils.GenerateAccessToken(utils.TokenSubject{
+		UserID:        user.ID,
+		Email:         user.Email,
+		Role:          role,
+		SessionID:     sessionID,
+		EmailVerified: user.EmailVerified.Bool,
+		// Keeps the user on the change-password endpoint until they comply
+		PasswordChangeRequired: user.MustChangePassword.Bool,
+	}, h.tokens, h.config.Auth.JWTExpiry)
+}
+
+// getUserRole returns the user's role, defaulting to student when the user
+// belongs to no role group
+func (h *AuthHandler) getUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	role, err := h.queries.GetUserRole(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return middleware.RoleStudent, nil
 	}
+	return role, err
+}
 
-	// Generate new access token
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, h.config.Auth.JWTSecret)
+// detectRefreshTokenReuse revokes the session a rotated refresh token belonged
+// to. A rotated token is only ever presented again if it was stolen.
+func (h *AuthHandler) detectRefreshTokenReuse(ctx context.Context, refreshTokenHash string) {
+	rotated, err := h.queries.GetRotatedRefreshToken(ctx, refreshTokenHash)
 	if err != nil {
-		utils.SendErrorResponse(w, "Error generating access token", http.StatusInternalServerError)
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to look up rotated refresh token: %v", err)
+		}
 		return
 	}
 
-	// Update session last accessed time
-	err = h.queries.UpdateSessionLastAccessedAt(r.Context(), database.UpdateSessionLastAccessedAtParams{
-		ID:             session.ID,
-		LastAccessedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	log.Printf("Refresh token reuse detected for session %s, revoking", rotated.SessionID)
+	h.revokeSession(ctx, rotated.SessionID, "Refresh token reuse detected")
+}
+
+// revokeSession marks a session as revoked, logging any failure
+func (h *AuthHandler) revokeSession(ctx context.Context, sessionID uuid.UUID, reason string) {
+	err := h.queries.RevokeSession(ctx, database.RevokeSessionParams{
+		ID:            sessionID,
+		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedReason: sql.NullString{String: reason, Valid: true},
 	})
 	if err != nil {
-		utils.SendErrorResponse(w, "Error updating session last accessed at", http.StatusInternalServerError)
-		return
+		log.Printf("Failed to revoke session %s: %v", sessionID, err)
 	}
+}
 
-	// Prepare refresh response
-	refreshResponse := RefreshResponse{
-		AccessToken: accessToken,
+// rehashPassword replaces the user's password hash with one made with the
+// configured algorithm and cost. Failures are logged; the login goes ahead
+// and the upgrade is retried next time.
+func (h *AuthHandler) rehashPassword(ctx context.Context, user database.User, plaintext string) {
+	hash, err := h.hasher.Hash(plaintext)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %s: %v", user.ID, err)
+		return
 	}
 
-	// Send refresh response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(refreshResponse); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+	// Skipped if the password changed since it was verified
+	err = h.queries.UpdateUserPasswordHash(ctx, database.UpdateUserPasswordHashParams{
+		NewPasswordHash: hash,
+		ID:              user.ID,
+		OldPasswordHash: user.PasswordHash,
+	})
+	if err != nil {
+		log.Printf("Failed to store rehashed password for user %s: %v", user.ID, err)
 	}
 }
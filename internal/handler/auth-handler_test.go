@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   time.Duration
+		limit  time.Duration
+		excess int
+		want   time.Duration
+	}{
+		{"at the threshold", time.Minute, time.Hour, 0, time.Minute},
+		{"one past the threshold", time.Minute, time.Hour, 1, 2 * time.Minute},
+		{"below the limit", time.Minute, time.Hour, 5, 32 * time.Minute},
+		{"reaches the limit", time.Minute, time.Hour, 6, time.Hour},
+		{"would overflow int64", time.Minute, time.Hour, 28, time.Hour},
+		{"far past the threshold", time.Minute, time.Hour, 1000, time.Hour},
+		{"limit near int64 max", time.Minute, math.MaxInt64, 60, math.MaxInt64},
+		{"base equal to limit", time.Hour, time.Hour, 3, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockoutDuration(tt.base, tt.limit, tt.excess); got != tt.want {
+				t.Errorf("lockoutDuration(%s, %s, %d) = %s, want %s", tt.base, tt.limit, tt.excess, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisabledAccountsGetNoTokens(t *testing.T) {
+	states := []struct {
+		name    string
+		disable func(user []driver.Value)
+	}{
+		{"deactivated", func(user []driver.Value) { user[17] = false }},
+		{"suspended", func(user []driver.Value) { user[18] = time.Now() }},
+		{"deleted", func(user []driver.Value) { user[32] = time.Now() }},
+	}
+
+	for _, state := range states {
+		t.Run("login/"+state.name, func(t *testing.T) {
+			db := newDisabledAccountDB(t, state.disable)
+			conn := sql.OpenDB(db)
+			t.Cleanup(func() { conn.Close() })
+
+			body := strings.NewReader(fmt.Sprintf(`{"email":"student@example.test","password":%q}`, testPassword))
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			w := httptest.NewRecorder()
+			h := NewAuthHandler(conn, database.New(conn), disabledAccountConfig(), nil, utils.TokenOptions{}, db.hasher)
+			middleware.ValidateJSON[LoginRequest](h.Login)(w, r)
+
+			if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Invalid credentials") {
+				t.Errorf("response = %d %s, want %d Invalid credentials", w.Code, w.Body, http.StatusUnauthorized)
+			}
+		})
+
+		t.Run("refresh/"+state.name, func(t *testing.T) {
+			db := newDisabledAccountDB(t, state.disable)
+			conn := sql.OpenDB(db)
+			t.Cleanup(func() { conn.Close() })
+
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Authorization", "Bearer refresh-token")
+
+			w := httptest.NewRecorder()
+			NewAuthHandler(conn, database.New(conn), disabledAccountConfig(), nil, utils.TokenOptions{}, db.hasher).RefreshToken(w, r)
+
+			if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Invalid refresh token") {
+				t.Errorf("response = %d %s, want %d Invalid refresh token", w.Code, w.Body, http.StatusUnauthorized)
+			}
+			if got := db.revokedReason(); got != "Account disabled" {
+				t.Errorf("session revoked with reason %q, want %q", got, "Account disabled")
+			}
+		})
+	}
+}
+
+func disabledAccountConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Auth.LoginIPWindow = 15 * time.Minute
+	cfg.Auth.LoginIPMaxAttempts = 20
+	cfg.Auth.SessionMaxAge = 24 * time.Hour
+	return cfg
+}
+
+// disabledAccountDB is a database/sql driver holding one account with the
+// right password that must nevertheless not be issued tokens, and one live
+// session of it. Queries that would issue tokens are rejected.
+type disabledAccountDB struct {
+	*deletionDB
+	disable func(user []driver.Value)
+
+	mu      sync.Mutex
+	revoked string
+}
+
+func newDisabledAccountDB(t *testing.T, disable func(user []driver.Value)) *disabledAccountDB {
+	return &disabledAccountDB{deletionDB: newDeletionDB(t), disable: disable}
+}
+
+func (db *disabledAccountDB) revokedReason() string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.revoked
+}
+
+func (db *disabledAccountDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *disabledAccountDB) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+
+func (db *disabledAccountDB) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "RevokeSession":
+		db.mu.Lock()
+		db.revoked = args[1].Value.(string)
+		db.mu.Unlock()
+	case "CreateLoginAttempt":
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (db *disabledAccountDB) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "CountRecentFailedLoginsByIP":
+		return &staticRows{rows: [][]driver.Value{{int64(0)}}}, nil
+
+	case "GetUserByEmail", "GetUser":
+		user := make([]driver.Value, 36)
+		user[0], user[1], user[5], user[6], user[7] = db.userID.String(), "student@example.test", db.passwordHash, "Test", "Student"
+		user[17] = true
+		db.disable(user)
+		return &staticRows{rows: [][]driver.Value{user}}, nil
+
+	case "GetSessionByRefreshToken":
+		session := make([]driver.Value, 18)
+		session[0], session[1], session[2] = uuid.NewString(), db.userID.String(), "hash"
+		session[12], session[14], session[17] = true, time.Now().Add(time.Hour), time.Now()
+		return &staticRows{rows: [][]driver.Value{session}}, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+}
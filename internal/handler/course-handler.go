@@ -0,0 +1,769 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/pagination"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// CourseHandler handles course catalog and authoring HTTP requests
+type CourseHandler struct {
+	db        *sql.DB
+	queries   *database.Queries
+	config    *config.Config
+	myCourses *pagination.Paginator[database.Course]
+	catalog   pagination.Options
+}
+
+// CourseFields are the course attributes set on creation and replaced by
+// updates. Omitted optional fields are cleared or reset to their default.
+type CourseFields struct {
+	Code                  string   `json:"code" validate:"required,max=50"`
+	Title                 string   `json:"title" validate:"required,max=255"`
+	Description           string   `json:"description,omitempty"`
+	Syllabus              string   `json:"syllabus,omitempty"`
+	Category              string   `json:"category,omitempty" validate:"omitempty,max=100"`
+	SubCategory           string   `json:"subCategory,omitempty" validate:"omitempty,max=100"`
+	Level                 string   `json:"level,omitempty" validate:"omitempty,oneof=beginner intermediate advanced"`
+	Language              string   `json:"language,omitempty" validate:"omitempty,max=10,bcp47_language_tag"`
+	ThumbnailURL          string   `json:"thumbnailUrl,omitempty" validate:"omitempty,url,max=500"`
+	IntroVideoURL         string   `json:"introVideoUrl,omitempty" validate:"omitempty,url,max=500"`
+	DurationHours         int32    `json:"durationHours,omitempty" validate:"gte=0"`
+	Price                 float64  `json:"price,omitempty" validate:"gte=0,lt=100000000"`
+	Currency              string   `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	IsFree                *bool    `json:"isFree,omitempty"`
+	EnrollmentType        string   `json:"enrollmentType,omitempty" validate:"omitempty,oneof=open approval invite"`
+	MaxStudents           int32    `json:"maxStudents,omitempty" validate:"gte=0"`
+	Prerequisites         []string `json:"prerequisites,omitempty" validate:"max=50,dive,required,max=255"`
+	Tags                  []string `json:"tags,omitempty" validate:"max=30,dive,required,max=50"`
+	LearningOutcomes      []string `json:"learningOutcomes,omitempty" validate:"max=50,dive,required,max=500"`
+	Requirements          []string `json:"requirements,omitempty" validate:"max=50,dive,required,max=500"`
+	TargetAudience        string   `json:"targetAudience,omitempty"`
+	CompletionCertificate *bool    `json:"completionCertificate,omitempty"`
+	AllowDiscussion       *bool    `json:"allowDiscussion,omitempty"`
+	AllowDownload         *bool    `json:"allowDownload,omitempty"`
+}
+
+// CreateCourseRequest represents a new course. The slug is derived from the
+// title and the caller becomes the instructor.
+type CreateCourseRequest struct {
+	CourseFields
+}
+
+// UpdateCourseRequest replaces a course's attributes. The slug is kept so
+// existing links keep working.
+type UpdateCourseRequest struct {
+	CourseFields
+}
+
+// CourseResponse represents a course. Status is draft, published or
+// archived.
+type CourseResponse struct {
+	ID                    string     `json:"id"`
+	Code                  string     `json:"code"`
+	Title                 string     `json:"title"`
+	Slug                  string     `json:"slug"`
+	Status                string     `json:"status"`
+	Description           string     `json:"description,omitempty"`
+	Syllabus              string     `json:"syllabus,omitempty"`
+	InstructorID          string     `json:"instructorId"`
+	Category              string     `json:"category,omitempty"`
+	SubCategory           string     `json:"subCategory,omitempty"`
+	Level                 string     `json:"level,omitempty"`
+	Language              string     `json:"language,omitempty"`
+	ThumbnailURL          string     `json:"thumbnailUrl,omitempty"`
+	IntroVideoURL         string     `json:"introVideoUrl,omitempty"`
+	DurationHours         int32      `json:"durationHours,omitempty"`
+	Price                 string     `json:"price"`
+	Currency              string     `json:"currency"`
+	IsFree                bool       `json:"isFree"`
+	IsFeatured            bool       `json:"isFeatured"`
+	EnrollmentType        string     `json:"enrollmentType"`
+	MaxStudents           int32      `json:"maxStudents,omitempty"`
+	Prerequisites         []string   `json:"prerequisites"`
+	Tags                  []string   `json:"tags"`
+	LearningOutcomes      []string   `json:"learningOutcomes"`
+	Requirements          []string   `json:"requirements"`
+	TargetAudience        string     `json:"targetAudience,omitempty"`
+	CompletionCertificate bool       `json:"completionCertificate"`
+	AllowDiscussion       bool       `json:"allowDiscussion"`
+	AllowDownload         bool       `json:"allowDownload"`
+	RatingAverage         string     `json:"ratingAverage"`
+	RatingCount           int32      `json:"ratingCount"`
+	EnrolledCount         int32      `json:"enrolledCount"`
+	PublishedAt           *time.Time `json:"publishedAt,omitempty"`
+	ArchivedAt            *time.Time `json:"archivedAt,omitempty"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
+
+// Course statuses reported in CourseResponse
+const (
+	CourseStatusDraft     = "draft"
+	CourseStatusPublished = "published"
+	CourseStatusArchived  = "archived"
+)
+
+// instructorCourses declares how an instructor's course list can be sorted
+// and filtered
+var instructorCourses = pagination.Spec[database.Course]{
+	Fields: map[string]pagination.Field[database.Course]{
+		"id": {
+			Column: "id", Type: pagination.UUID, Sortable: true,
+			Value: func(c database.Course) any { return c.ID },
+		},
+		"title": {
+			Column: "title", Type: pagination.String, Sortable: true,
+			Value: func(c database.Course) any { return c.Title },
+		},
+		"createdAt": {
+			Column: "created_at", Type: pagination.Time, Nullable: true, Sortable: true, Operators: pagination.Comparable,
+			Value: func(c database.Course) any { return c.CreatedAt.Time },
+		},
+		"updatedAt": {
+			Column: "updated_at", Type: pagination.Time, Nullable: true, Sortable: true, Operators: pagination.Comparable,
+			Value: func(c database.Course) any { return c.UpdatedAt.Time },
+		},
+		"enrolledCount": {
+			Column: "enrolled_count", Type: pagination.Int, Nullable: true, Sortable: true, Operators: pagination.Comparable,
+			Value: func(c database.Course) any { return c.EnrolledCount.Int32 },
+		},
+		"publishedAt": {Column: "published_at", Type: pagination.Time, Operators: pagination.Comparable},
+		"isPublished": {Column: "is_published", Type: pagination.Bool, Operators: []pagination.Operator{pagination.OpEq}},
+		"category":    {Column: "category", Type: pagination.String, Operators: pagination.Equality},
+		"level":       {Column: "level", Type: pagination.String, Operators: pagination.Equality},
+		"language":    {Column: "language", Type: pagination.String, Operators: pagination.Equality},
+	},
+	DefaultSort: "-updatedAt",
+	Tiebreaker:  "id",
+}
+
+// uniqueViolation is the Postgres error code for a duplicate key
+const uniqueViolation = "23505"
+
+// ============================================================================
+// CONSTRUCTOR
+// ============================================================================
+
+// NewCourseHandler creates a new CourseHandler instance
+func NewCourseHandler(db *sql.DB, queries *database.Queries, config *config.Config) *CourseHandler {
+	opts := pagination.NewOptions(config.Pagination)
+	return &CourseHandler{
+		db:        db,
+		queries:   queries,
+		config:    config,
+		myCourses: pagination.New(opts, instructorCourses),
+		catalog:   opts,
+	}
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// ListMyCourses returns a page of the courses the caller owns or teaches,
+// including drafts and archived courses. See instructorCourses for the
+// sort and filter fields.
+func (h *CourseHandler) ListMyCourses(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, err := h.myCourses.Parse(r.URL.Query())
+	if err != nil {
+		utils.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	courses, err := h.queries.ListInstructorCoursesPage(r.Context(), req, principal.UserID)
+	if err != nil {
+		log.Printf("Error listing instructor courses: %v", err)
+		utils.SendErrorResponse(w, "Error listing courses", http.StatusInternalServerError)
+		return
+	}
+	page := h.myCourses.Page(req, courses)
+
+	if req.IncludeTotal {
+		total, err := h.queries.CountInstructorCourses(r.Context(), req, principal.UserID)
+		if err != nil {
+			utils.SendErrorResponse(w, "Error listing courses", http.StatusInternalServerError)
+			return
+		}
+		page.Total = &total
+	}
+
+	response := pagination.MapItems(page, toCourseResponse)
+	response.SetLinkHeader(w, r)
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// GetCourse returns a course by ID or slug. Drafts are only visible to the
+// course's instructors and admins, archived courses also to its enrolled
+// students; anyone else gets a 404.
+func (h *CourseHandler) GetCourse(w http.ResponseWriter, r *http.Request) {
+	var (
+		course database.Course
+		err    error
+	)
+	if courseID, parseErr := uuid.Parse(r.PathValue("id")); parseErr == nil {
+		course, err = h.queries.GetCourseByID(r.Context(), courseID)
+	} else {
+		course, err = h.queries.GetCourseBySlug(r.Context(), r.PathValue("id"))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting course", http.StatusInternalServerError)
+		return
+	}
+
+	canView, err := h.canView(r.Context(), course)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting course", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toCourseResponse(course)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// CreateCourse creates a draft course owned by the caller
+func (h *CourseHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[CreateCourseRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if errs := req.check(); len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	code := normalizeCourseCode(req.Code)
+	if !h.checkCourseCode(w, r, code, uuid.Nil) {
+		return
+	}
+
+	slug, err := h.uniqueSlug(r.Context(), req.Title)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating course", http.StatusInternalServerError)
+		return
+	}
+
+	fields := req.params(code)
+	course, err := h.queries.CreateCourse(r.Context(), database.CreateCourseParams{
+		Code:                  fields.Code,
+		Title:                 fields.Title,
+		Slug:                  slug,
+		Description:           fields.Description,
+		Syllabus:              fields.Syllabus,
+		InstructorID:          principal.UserID,
+		Category:              fields.Category,
+		SubCategory:           fields.SubCategory,
+		Level:                 fields.Level,
+		Language:              fields.Language,
+		ThumbnailUrl:          fields.ThumbnailUrl,
+		IntroVideoUrl:         fields.IntroVideoUrl,
+		DurationHours:         fields.DurationHours,
+		Price:                 fields.Price,
+		Currency:              fields.Currency,
+		IsFree:                fields.IsFree,
+		EnrollmentType:        fields.EnrollmentType,
+		MaxStudents:           fields.MaxStudents,
+		Prerequisites:         fields.Prerequisites,
+		Tags:                  fields.Tags,
+		LearningOutcomes:      fields.LearningOutcomes,
+		Requirements:          fields.Requirements,
+		TargetAudience:        fields.TargetAudience,
+		CompletionCertificate: fields.CompletionCertificate,
+		AllowDiscussion:       fields.AllowDiscussion,
+		AllowDownload:         fields.AllowDownload,
+	})
+	if err != nil {
+		sendCourseWriteError(w, err, "Error creating course")
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toCourseResponse(course)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UpdateCourse replaces a course's attributes
+func (h *CourseHandler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[UpdateCourseRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	courseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid course ID", http.StatusBadRequest)
+		return
+	}
+
+	if errs := req.check(); len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	code := normalizeCourseCode(req.Code)
+	if !h.checkCourseCode(w, r, code, courseID) {
+		return
+	}
+
+	params := req.params(code)
+	params.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	params.ID = courseID
+	course, err := h.queries.UpdateCourse(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendCourseWriteError(w, err, "Error updating course")
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toCourseResponse(course)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// PublishCourse makes a draft course visible in the catalog. Archived
+// courses have to be unarchived first.
+func (h *CourseHandler) PublishCourse(w http.ResponseWriter, r *http.Request) {
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+	if course.ArchivedAt.Valid {
+		utils.SendErrorResponse(w, "Unarchive the course before publishing it", http.StatusConflict)
+		return
+	}
+
+	h.sendCourseUpdate(w, r, func(ctx context.Context, now sql.NullTime) (database.Course, error) {
+		return h.queries.PublishCourse(ctx, database.PublishCourseParams{Now: now, ID: course.ID})
+	})
+}
+
+// UnpublishCourse turns a course back into a draft
+func (h *CourseHandler) UnpublishCourse(w http.ResponseWriter, r *http.Request) {
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendCourseUpdate(w, r, func(ctx context.Context, now sql.NullTime) (database.Course, error) {
+		return h.queries.UnpublishCourse(ctx, database.UnpublishCourseParams{UpdatedAt: now, ID: course.ID})
+	})
+}
+
+// ArchiveCourse removes a course from the catalog while keeping it readable
+// for enrolled students
+func (h *CourseHandler) ArchiveCourse(w http.ResponseWriter, r *http.Request) {
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendCourseUpdate(w, r, func(ctx context.Context, now sql.NullTime) (database.Course, error) {
+		return h.queries.ArchiveCourse(ctx, database.ArchiveCourseParams{Now: now, ID: course.ID})
+	})
+}
+
+// UnarchiveCourse restores an archived course to its previous state
+func (h *CourseHandler) UnarchiveCourse(w http.ResponseWriter, r *http.Request) {
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendCourseUpdate(w, r, func(ctx context.Context, now sql.NullTime) (database.Course, error) {
+		return h.queries.UnarchiveCourse(ctx, database.UnarchiveCourseParams{UpdatedAt: now, ID: course.ID})
+	})
+}
+
+// DeleteCourse soft deletes a course. Only its owner and admins may delete
+// it; staff members may not.
+func (h *CourseHandler) DeleteCourse(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+	if course.InstructorID != principal.UserID && !isAdmin(principal) {
+		utils.SendErrorResponse(w, "Only the course owner can delete it", http.StatusForbidden)
+		return
+	}
+
+	deleted, err := h.queries.SoftDeleteCourse(r.Context(), database.SoftDeleteCourseParams{
+		DeletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:        course.ID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error deleting course", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Course deleted successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// check validates the rules that span several fields
+func (f CourseFields) check() []middleware.ValidationError {
+	var errs []middleware.ValidationError
+	if f.IsFree != nil && *f.IsFree && f.Price > 0 {
+		errs = append(errs, middleware.ValidationError{
+			Field:   "price",
+			Tag:     "free_course_price",
+			Value:   strconv.FormatFloat(f.Price, 'f', 2, 64),
+			Message: "price must be 0 for a free course",
+		})
+	}
+	if normalizeCourseCode(f.Code) == "" {
+		errs = append(errs, middleware.ValidationError{
+			Field:   "code",
+			Tag:     "required",
+			Message: "code is required",
+		})
+	}
+	return errs
+}
+
+// params converts the fields to query parameters, applying the column
+// defaults to omitted values. A course without an explicit isFree flag is
+// free when it has no price.
+func (f CourseFields) params(code string) database.UpdateCourseParams {
+	language := f.Language
+	if language == "" {
+		language = "en"
+	}
+	currency := strings.ToUpper(f.Currency)
+	if currency == "" {
+		currency = "USD"
+	}
+	enrollmentType := f.EnrollmentType
+	if enrollmentType == "" {
+		enrollmentType = "open"
+	}
+
+	return database.UpdateCourseParams{
+		Code:                  code,
+		Title:                 strings.TrimSpace(f.Title),
+		Description:           sql.NullString{String: f.Description, Valid: f.Description != ""},
+		Syllabus:              sql.NullString{String: f.Syllabus, Valid: f.Syllabus != ""},
+		Category:              sql.NullString{String: f.Category, Valid: f.Category != ""},
+		SubCategory:           sql.NullString{String: f.SubCategory, Valid: f.SubCategory != ""},
+		Level:                 sql.NullString{String: f.Level, Valid: f.Level != ""},
+		Language:              sql.NullString{String: language, Valid: true},
+		ThumbnailUrl:          sql.NullString{String: f.ThumbnailURL, Valid: f.ThumbnailURL != ""},
+		IntroVideoUrl:         sql.NullString{String: f.IntroVideoURL, Valid: f.IntroVideoURL != ""},
+		DurationHours:         sql.NullInt32{Int32: f.DurationHours, Valid: f.DurationHours > 0},
+		Price:                 sql.NullString{String: strconv.FormatFloat(f.Price, 'f', 2, 64), Valid: true},
+		Currency:              sql.NullString{String: currency, Valid: true},
+		IsFree:                boolOrDefault(f.IsFree, f.Price == 0),
+		EnrollmentType:        sql.NullString{String: enrollmentType, Valid: true},
+		MaxStudents:           sql.NullInt32{Int32: f.MaxStudents, Valid: f.MaxStudents > 0},
+		Prerequisites:         f.Prerequisites,
+		Tags:                  f.Tags,
+		LearningOutcomes:      f.LearningOutcomes,
+		Requirements:          f.Requirements,
+		TargetAudience:        sql.NullString{String: f.TargetAudience, Valid: f.TargetAudience != ""},
+		CompletionCertificate: boolOrDefault(f.CompletionCertificate, true),
+		AllowDiscussion:       boolOrDefault(f.AllowDiscussion, true),
+		AllowDownload:         boolOrDefault(f.AllowDownload, false),
+	}
+}
+
+// checkCourseCode reports whether code is free for the course, answering
+// the request when it is not
+func (h *CourseHandler) checkCourseCode(w http.ResponseWriter, r *http.Request, code string, courseID uuid.UUID) bool {
+	exists, err := h.queries.CourseCodeExists(r.Context(), database.CourseCodeExistsParams{
+		Code:      code,
+		ExcludeID: courseID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error checking course code", http.StatusInternalServerError)
+		return false
+	}
+	if exists {
+		utils.SendErrorResponse(w, "Course code already in use", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// uniqueSlug derives a slug from the title, numbering it when the plain
+// slug is taken: intro-to-go, intro-to-go-2, intro-to-go-3...
+func (h *CourseHandler) uniqueSlug(ctx context.Context, title string) (string, error) {
+	base := utils.Slugify(title)
+	if base == "" {
+		base = "course"
+	}
+
+	taken, err := h.queries.ListCourseSlugs(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+// courseFromPath loads the course addressed by the {id} path value,
+// answering the request when it cannot
+func (h *CourseHandler) courseFromPath(w http.ResponseWriter, r *http.Request) (database.Course, bool) {
+	courseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid course ID", http.StatusBadRequest)
+		return database.Course{}, false
+	}
+
+	course, err := h.queries.GetCourseByID(r.Context(), courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return database.Course{}, false
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting course", http.StatusInternalServerError)
+		return database.Course{}, false
+	}
+	return course, true
+}
+
+// sendCourseUpdate runs a state change and answers with the updated course
+func (h *CourseHandler) sendCourseUpdate(w http.ResponseWriter, r *http.Request, update func(context.Context, sql.NullTime) (database.Course, error)) {
+	course, err := update(r.Context(), sql.NullTime{Time: time.Now(), Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating course", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toCourseResponse(course)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// canManage reports whether the caller, if signed in, is an admin or one of
+// the course's instructors
+func (h *CourseHandler) canManage(ctx context.Context, course database.Course) (bool, error) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		return false, nil
+	}
+	if isAdmin(principal) || course.InstructorID == principal.UserID {
+		return true, nil
+	}
+	return h.queries.IsCourseInstructor(ctx, database.IsCourseInstructorParams{
+		CourseID: course.ID,
+		UserID:   principal.UserID,
+	})
+}
+
+// canView reports whether the caller may see the course. Published courses
+// are public until archived; after that only the students who took them keep
+// access, next to those who can manage the course.
+func (h *CourseHandler) canView(ctx context.Context, course database.Course) (bool, error) {
+	if course.IsPublished.Bool && !course.ArchivedAt.Valid {
+		return true, nil
+	}
+	canManage, err := h.canManage(ctx, course)
+	if err != nil || canManage || !course.IsPublished.Bool {
+		return canManage, err
+	}
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		return false, nil
+	}
+	return h.queries.IsEnrolled(ctx, database.IsEnrolledParams{
+		CourseID: course.ID,
+		UserID:   principal.UserID,
+	})
+}
+
+// isAdmin reports whether the principal acts with admin rights. API keys do
+// not inherit them.
+func isAdmin(principal *middleware.Principal) bool {
+	return principal.Role == middleware.RoleAdmin && !principal.UsesAccessToken()
+}
+
+// sendCourseWriteError answers a failed insert or update. A duplicate key
+// means another request took the code or slug in the meantime.
+func sendCourseWriteError(w http.ResponseWriter, err error, message string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		utils.SendErrorResponse(w, "Course code or slug already in use", http.StatusConflict)
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	utils.SendErrorResponse(w, message, http.StatusInternalServerError)
+}
+
+// normalizeCourseCode stores codes such as "comp 101" as "COMP 101"
+func normalizeCourseCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// boolOrDefault returns the value of an optional flag or the default
+func boolOrDefault(value *bool, fallback bool) sql.NullBool {
+	if value == nil {
+		return sql.NullBool{Bool: fallback, Valid: true}
+	}
+	return sql.NullBool{Bool: *value, Valid: true}
+}
+
+// optionalBool returns the value of an optional flag, NULL when omitted
+func optionalBool(value *bool) sql.NullBool {
+	if value == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *value, Valid: true}
+}
+
+// toCourseResponse converts a course to its API form
+func toCourseResponse(course database.Course) CourseResponse {
+	status := CourseStatusDraft
+	switch {
+	case course.ArchivedAt.Valid:
+		status = CourseStatusArchived
+	case course.IsPublished.Bool:
+		status = CourseStatusPublished
+	}
+
+	resp := CourseResponse{
+		ID:                    course.ID.String(),
+		Code:                  course.Code,
+		Title:                 course.Title,
+		Slug:                  course.Slug,
+		Status:                status,
+		Description:           course.Description.String,
+		Syllabus:              course.Syllabus.String,
+		InstructorID:          course.InstructorID.String(),
+		Category:              course.Category.String,
+		SubCategory:           course.SubCategory.String,
+		Level:                 course.Level.String,
+		Language:              course.Language.String,
+		ThumbnailURL:          course.ThumbnailUrl.String,
+		IntroVideoURL:         course.IntroVideoUrl.String,
+		DurationHours:         course.DurationHours.Int32,
+		Price:                 course.Price.String,
+		Currency:              course.Currency.String,
+		IsFree:                course.IsFree.Bool,
+		IsFeatured:            course.IsFeatured.Bool,
+		EnrollmentType:        course.EnrollmentType.String,
+		MaxStudents:           course.MaxStudents.Int32,
+		Prerequisites:         nonNil(course.Prerequisites),
+		Tags:                  nonNil(course.Tags),
+		LearningOutcomes:      nonNil(course.LearningOutcomes),
+		Requirements:          nonNil(course.Requirements),
+		TargetAudience:        course.TargetAudience.String,
+		CompletionCertificate: course.CompletionCertificate.Bool,
+		AllowDiscussion:       course.AllowDiscussion.Bool,
+		AllowDownload:         course.AllowDownload.Bool,
+		RatingAverage:         course.RatingAverage.String,
+		RatingCount:           course.RatingCount.Int32,
+		EnrolledCount:         course.EnrolledCount.Int32,
+		CreatedAt:             course.CreatedAt.Time,
+		UpdatedAt:             course.UpdatedAt.Time,
+	}
+	if course.PublishedAt.Valid {
+		resp.PublishedAt = &course.PublishedAt.Time
+	}
+	if course.ArchivedAt.Valid {
+		resp.ArchivedAt = &course.ArchivedAt.Time
+	}
+	return resp
+}
+
+// nonNil encodes missing lists as [] rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/google/uuid"
+)
+
+func TestGetCourseVisibility(t *testing.T) {
+	instructorID, studentID, strangerID := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		published bool
+		archived  bool
+		principal *middleware.Principal
+		want      int
+	}{
+		{name: "published course to anonymous caller", published: true, want: http.StatusOK},
+		{name: "draft to anonymous caller", want: http.StatusNotFound},
+		{name: "draft to instructor", principal: &middleware.Principal{UserID: instructorID}, want: http.StatusOK},
+		{name: "draft to enrolled student", principal: &middleware.Principal{UserID: studentID}, want: http.StatusNotFound},
+		{name: "archived course to anonymous caller", published: true, archived: true, want: http.StatusNotFound},
+		{name: "archived course to other user", published: true, archived: true, principal: &middleware.Principal{UserID: strangerID}, want: http.StatusNotFound},
+		{name: "archived course to enrolled student", published: true, archived: true, principal: &middleware.Principal{UserID: studentID}, want: http.StatusOK},
+		{name: "archived course to instructor", published: true, archived: true, principal: &middleware.Principal{UserID: instructorID}, want: http.StatusOK},
+		{name: "archived course to admin", published: true, archived: true, principal: &middleware.Principal{UserID: strangerID, Role: middleware.RoleAdmin}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &courseDB{
+				courseID:     uuid.New(),
+				instructorID: instructorID,
+				studentID:    studentID,
+				published:    tt.published,
+				archived:     tt.archived,
+			}
+			conn := sql.OpenDB(db)
+			t.Cleanup(func() { conn.Close() })
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.SetPathValue("id", db.courseID.String())
+			if tt.principal != nil {
+				r = r.WithContext(middleware.WithPrincipal(r.Context(), tt.principal))
+			}
+
+			w := httptest.NewRecorder()
+			NewCourseHandler(conn, database.New(conn), &config.Config{}).GetCourse(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+// courseDB is a database/sql driver holding one course, its instructor and
+// one student enrolled in it
+type courseDB struct {
+	courseID     uuid.UUID
+	instructorID uuid.UUID
+	studentID    uuid.UUID
+	published    bool
+	archived     bool
+}
+
+func (db *courseDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *courseDB) Driver() driver.Driver                        { return nil }
+
+func (db *courseDB) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (db *courseDB) Close() error                        { return nil }
+func (db *courseDB) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (db *courseDB) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "GetCourseByID":
+		course := make([]driver.Value, 40)
+		course[0], course[1], course[2], course[3] = db.courseID.String(), "CS101", "Intro", "intro"
+		course[6], course[17] = db.instructorID.String(), db.published
+		if db.archived {
+			course[38] = time.Now()
+		}
+		return &staticRows{rows: [][]driver.Value{course}}, nil
+
+	case "IsCourseInstructor":
+		return &staticRows{rows: [][]driver.Value{{args[1].Value == db.instructorID.String()}}}, nil
+
+	case "IsEnrolled":
+		return &staticRows{rows: [][]driver.Value{{args[1].Value == db.studentID.String()}}}, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// CourseSearchResponse is a page of catalog search results with facet
+// counts for the whole result set
+type CourseSearchResponse struct {
+	Courses []CourseSearchResult `json:"courses"`
+	Facets  CourseFacets         `json:"facets"`
+	Total   int64                `json:"total"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+}
+
+// CourseSearchResult is a course matching the search. Rank and highlights
+// are only set when searching for text.
+type CourseSearchResult struct {
+	CourseResponse
+	Rank       float32           `json:"rank,omitempty"`
+	Highlights *CourseHighlights `json:"highlights,omitempty"`
+}
+
+// CourseHighlights are the title and description excerpts with matching
+// terms wrapped in <mark> tags. Everything else is HTML-escaped.
+type CourseHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// CourseFacets counts the results per filter value. Each facet ignores its
+// own filter, so the other values stay selectable.
+type CourseFacets struct {
+	Categories    []FacetCount      `json:"categories"`
+	SubCategories []FacetCount      `json:"subCategories"`
+	Levels        []FacetCount      `json:"levels"`
+	Languages     []FacetCount      `json:"languages"`
+	IsFree        []FacetCount      `json:"isFree"`
+	PriceRanges   []PriceRangeCount `json:"priceRanges"`
+}
+
+// FacetCount is the number of results with a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeCount is the number of results priced from Min up to, but not
+// including, Max. The last range has no Max.
+type PriceRangeCount struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// Sort orders accepted by ListCourses
+const (
+	CourseSortRelevance = "relevance"
+	CourseSortRating    = "rating"
+	CourseSortPopular   = "popular"
+	CourseSortNewest    = "newest"
+)
+
+// maxSearchLength bounds the search text
+const maxSearchLength = 200
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// ListCourses searches the public catalog: published courses that are not
+// archived or deleted.
+//
+// Query parameters:
+//   - q: search text in web search syntax ("quoted phrases", -excluded, or)
+//   - category, subCategory, level, language, isFree: exact filters
+//   - minPrice, maxPrice: price range, maxPrice exclusive
+//   - sort: relevance (default with q), rating, popular or newest (default)
+//   - limit, offset: paging
+//
+// Unlike the other list endpoints the catalog is paged by offset rather
+// than through the pagination package. Results are ordered by a relevance
+// rank computed per request, which a keyset cursor cannot resume from
+// reliably, and the facets already count the whole result set, so the
+// response carries the total and the offset lets clients jump to any page.
+// The limit honours the same configured bounds as every other list.
+func (h *CourseHandler) ListCourses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := h.catalog.ParseLimit(query)
+	if err != nil {
+		utils.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, err := queryInt(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		utils.SendErrorResponse(w, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	search := strings.TrimSpace(query.Get("q"))
+	if len(search) > maxSearchLength {
+		utils.SendErrorResponse(w, fmt.Sprintf("q must be at most %d characters", maxSearchLength), http.StatusBadRequest)
+		return
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = CourseSortNewest
+		if search != "" {
+			sort = CourseSortRelevance
+		}
+	}
+	if !slices.Contains([]string{CourseSortRelevance, CourseSortRating, CourseSortPopular, CourseSortNewest}, sort) {
+		utils.SendErrorResponse(w, "sort must be one of relevance, rating, popular or newest", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := parseCourseFilters(query)
+	if err != nil {
+		utils.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filters.Query = sql.NullString{String: search, Valid: search != ""}
+
+	rows, err := h.queries.SearchCourses(r.Context(), database.SearchCoursesParams{
+		Sort:        sort,
+		Query:       filters.Query,
+		Category:    filters.Category,
+		SubCategory: filters.SubCategory,
+		Level:       filters.Level,
+		Language:    filters.Language,
+		IsFree:      filters.IsFree,
+		MinPrice:    filters.MinPrice,
+		MaxPrice:    filters.MaxPrice,
+		PageLimit:   int32(limit),
+		PageOffset:  int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error searching courses: %v", err)
+		utils.SendErrorResponse(w, "Error searching courses", http.StatusInternalServerError)
+		return
+	}
+
+	facets, err := h.queries.SearchCourseFacets(r.Context(), filters)
+	if err != nil {
+		log.Printf("Error counting course facets: %v", err)
+		utils.SendErrorResponse(w, "Error searching courses", http.StatusInternalServerError)
+		return
+	}
+
+	response := toCourseSearchResponse(rows, facets)
+	response.Limit = limit
+	response.Offset = offset
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// parseCourseFilters reads the catalog filters from the query string
+func parseCourseFilters(query url.Values) (database.SearchCourseFacetsParams, error) {
+	filters := database.SearchCourseFacetsParams{}
+	for _, f := range []struct {
+		name  string
+		value *sql.NullString
+	}{
+		{"category", &filters.Category},
+		{"subCategory", &filters.SubCategory},
+		{"level", &filters.Level},
+		{"language", &filters.Language},
+	} {
+		if v := query.Get(f.name); v != "" {
+			*f.value = sql.NullString{String: v, Valid: true}
+		}
+	}
+
+	if v := query.Get("isFree"); v != "" {
+		isFree, err := strconv.ParseBool(v)
+		if err != nil {
+			return filters, errors.New("isFree must be true or false")
+		}
+		filters.IsFree = sql.NullBool{Bool: isFree, Valid: true}
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *sql.NullString
+	}{
+		{"minPrice", &filters.MinPrice},
+		{"maxPrice", &filters.MaxPrice},
+	} {
+		v := query.Get(f.name)
+		if v == "" {
+			continue
+		}
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price < 0 {
+			return filters, fmt.Errorf("%s must be a non-negative number", f.name)
+		}
+		*f.value = sql.NullString{String: strconv.FormatFloat(price, 'f', 2, 64), Valid: true}
+	}
+
+	return filters, nil
+}
+
+// toCourseSearchResponse converts search rows and facet counts to their API
+// form
+func toCourseSearchResponse(rows []database.SearchCoursesRow, facets []database.SearchCourseFacetsRow) CourseSearchResponse {
+	response := CourseSearchResponse{
+		Courses: make([]CourseSearchResult, 0, len(rows)),
+		Facets: CourseFacets{
+			Categories:    []FacetCount{},
+			SubCategories: []FacetCount{},
+			Levels:        []FacetCount{},
+			Languages:     []FacetCount{},
+			IsFree:        []FacetCount{},
+			PriceRanges:   []PriceRangeCount{},
+		},
+	}
+
+	for _, row := range rows {
+		result := CourseSearchResult{
+			CourseResponse: toCourseResponse(row.Course),
+			Rank:           row.Rank,
+		}
+		if row.TitleHighlight.Valid {
+			result.Highlights = &CourseHighlights{
+				Title:       highlight(row.TitleHighlight.String),
+				Description: highlight(row.DescriptionHighlight.String),
+			}
+		}
+		response.Courses = append(response.Courses, result)
+	}
+
+	for _, facet := range facets {
+		count := FacetCount{Value: facet.Value, Count: facet.Count}
+		switch facet.Facet {
+		case "total":
+			response.Total = facet.Count
+		case "category":
+			response.Facets.Categories = append(response.Facets.Categories, count)
+		case "sub_category":
+			response.Facets.SubCategories = append(response.Facets.SubCategories, count)
+		case "level":
+			response.Facets.Levels = append(response.Facets.Levels, count)
+		case "language":
+			response.Facets.Languages = append(response.Facets.Languages, count)
+		case "is_free":
+			response.Facets.IsFree = append(response.Facets.IsFree, count)
+		case "price_range":
+			response.Facets.PriceRanges = append(response.Facets.PriceRanges, toPriceRangeCount(facet))
+		}
+	}
+	slices.SortFunc(response.Facets.PriceRanges, func(a, b PriceRangeCount) int {
+		return cmp.Compare(a.Min, b.Min)
+	})
+
+	return response
+}
+
+// toPriceRangeCount converts a price range facet such as "25-50" or "200-"
+func toPriceRangeCount(facet database.SearchCourseFacetsRow) PriceRangeCount {
+	lower, upper, _ := strings.Cut(facet.Value, "-")
+	count := PriceRangeCount{Count: facet.Count}
+	count.Min, _ = strconv.ParseFloat(lower, 64)
+	if upper != "" {
+		if bound, err := strconv.ParseFloat(upper, 64); err == nil {
+			count.Max = &bound
+		}
+	}
+	return count
+}
+
+// highlight escapes a ts_headline excerpt for HTML while keeping the <mark>
+// tags Postgres put around the matching terms
+func highlight(excerpt string) string {
+	escaped := html.EscapeString(excerpt)
+	escaped = strings.ReplaceAll(escaped, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(escaped, "&lt;/mark&gt;", "</mark>")
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/export"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// DataExportResponse reports the progress of a personal data export
+type DataExportResponse struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status"`
+	RequestedAt        time.Time  `json:"requestedAt"`
+	CompletedAt        *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+	FileSize           int64      `json:"fileSize,omitempty"`
+	DownloadsRemaining *int       `json:"downloadsRemaining,omitempty"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// RequestDataExport queues an export of everything stored about the caller.
+// The archive is built in the background and a download link is emailed
+// when it is ready. While an export is still queued or being built, that
+// export is returned instead of starting another.
+func (h *UserHandler) RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := uuid.NullUUID{UUID: principal.UserID, Valid: true}
+
+	archive, err := h.queries.GetOpenDataArchive(r.Context(), database.GetOpenDataArchiveParams{
+		UserID:      userID,
+		ArchiveType: export.ArchiveType,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		archive, err = h.queries.CreateDataArchive(r.Context(), database.CreateDataArchiveParams{
+			UserID:      userID,
+			ArchiveType: export.ArchiveType,
+		})
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Failed to request data export", http.StatusInternalServerError)
+		return
+	}
+	h.exporter.Notify()
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(h.toDataExportResponse(archive)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// GetDataExport reports the progress of one of the caller's exports
+func (h *UserHandler) GetDataExport(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	archiveID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid export ID", http.StatusBadRequest)
+		return
+	}
+
+	// Exports of other users are reported as not found
+	archive, err := h.queries.GetUserDataArchive(r.Context(), database.GetUserDataArchiveParams{
+		ID:     archiveID,
+		UserID: uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	})
+	if err != nil || archive.ArchiveType != export.ArchiveType {
+		utils.SendErrorResponse(w, "Export not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.toDataExportResponse(archive)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DownloadDataExport streams a finished export to whoever holds the emailed
+// token. Each download counts against ExportConfig.MaxDownloads.
+func (h *UserHandler) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.SendErrorResponse(w, "Download token is required", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := h.queries.GetDataArchiveByToken(r.Context(), sql.NullString{String: utils.HashToken(token), Valid: true})
+	if err != nil || !archive.FilePath.Valid {
+		utils.SendErrorResponse(w, "Invalid or expired download link", http.StatusNotFound)
+		return
+	}
+
+	counted, err := h.queries.RecordDataArchiveDownload(r.Context(), database.RecordDataArchiveDownloadParams{
+		ID:           archive.ID,
+		Now:          sql.NullTime{Time: time.Now(), Valid: true},
+		MaxDownloads: int32(h.config.Export.MaxDownloads),
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Failed to download data export", http.StatusInternalServerError)
+		return
+	}
+	if counted == 0 {
+		utils.SendErrorResponse(w, "Download link has expired", http.StatusGone)
+		return
+	}
+
+	file, err := h.exporter.Open(r.Context(), archive.FilePath.String)
+	if err != nil {
+		log.Printf("Failed to open data export %s: %v", archive.ID, err)
+		utils.SendErrorResponse(w, "Failed to download data export", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-export-%s.zip"`, archive.RequestedAt.Time.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	if archive.FileSize.Valid {
+		w.Header().Set("Content-Length", strconv.FormatInt(archive.FileSize.Int64, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send data export %s: %v", archive.ID, err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// toDataExportResponse converts a data_archives row to its API form
+func (h *UserHandler) toDataExportResponse(archive database.DataArchive) DataExportResponse {
+	resp := DataExportResponse{
+		ID:          archive.ID.String(),
+		Status:      archive.Status.String,
+		RequestedAt: archive.RequestedAt.Time,
+		FileSize:    archive.FileSize.Int64,
+	}
+	if archive.CompletedAt.Valid {
+		resp.CompletedAt = &archive.CompletedAt.Time
+	}
+	if archive.ExpiresAt.Valid {
+		resp.ExpiresAt = &archive.ExpiresAt.Time
+	}
+	if archive.Status.String == "completed" {
+		remaining := max(h.config.Export.MaxDownloads-int(archive.DownloadCount.Int32), 0)
+		resp.DownloadsRemaining = &remaining
+	}
+	return resp
+}
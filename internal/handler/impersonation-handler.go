@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// StartImpersonationRequest represents the request body for impersonating a user
+type StartImpersonationRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationResponse carries the token to act as the user. There is no
+// refresh token; a new impersonation must be started once it expires.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"accessToken"`
+	SessionID   string    `json:"sessionId"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	User        User      `json:"user"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// StartImpersonation issues a short-lived token that lets an admin act as
+// another user. The token names the admin in its act claim, and every
+// request made with it is audited.
+func (h *AdminHandler) StartImpersonation(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[StartImpersonationRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if targetID == principal.UserID {
+		utils.SendErrorResponse(w, "You cannot impersonate yourself", http.StatusBadRequest)
+		return
+	}
+
+	target, err := h.queries.GetUserByID(r.Context(), targetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+			return
+		}
+		utils.SendErrorResponse(w, "Error getting user", http.StatusInternalServerError)
+		return
+	}
+	if target.DeletedAt.Valid || target.SuspendedAt.Valid || (target.IsActive.Valid && !target.IsActive.Bool) {
+		utils.SendErrorResponse(w, "Disabled accounts cannot be impersonated", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.queries.GetUserRole(r.Context(), target.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		role, err = middleware.RoleStudent, nil
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting user", http.StatusInternalServerError)
+		return
+	}
+	// Acting as another admin would hand out admin rights without the audit
+	// trail pointing at the right person
+	if role == middleware.RoleAdmin {
+		utils.SendErrorResponse(w, "Administrators cannot be impersonated", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(h.config.Auth.ImpersonationTTL)
+	sessionID := uuid.New()
+
+	accessToken, err := utils.GenerateAccessToken(utils.TokenSubject{
+		UserID:        target.ID,
+		Email:         target.Email,
+		Role:          role,
+		SessionID:     sessionID,
+		EmailVerified: target.EmailVerified.Bool,
+		Actor:         &utils.Actor{UserID: principal.UserID.String(), Email: principal.Email},
+	}, h.tokens, h.config.Auth.ImpersonationTTL)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating tokens", http.StatusInternalServerError)
+		return
+	}
+
+	// The session is never refreshed, so its refresh token is thrown away
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating tokens", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting impersonation", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	// A regular session row makes the impersonation visible to the user and
+	// revocable like any other session
+	err = qtx.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:               sessionID,
+		UserID:           target.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		AccessTokenHash:  sql.NullString{String: utils.HashToken(accessToken), Valid: true},
+		DeviceName:       sql.NullString{String: "Support session (" + principal.Email + ")", Valid: true},
+		DeviceType:       sql.NullString{String: utils.GetDeviceType(r), Valid: true},
+		Browser:          sql.NullString{String: utils.GetBrowser(r), Valid: true},
+		BrowserVersion:   sql.NullString{String: utils.GetBrowserVersion(r), Valid: true},
+		Os:               sql.NullString{String: utils.GetOS(r), Valid: true},
+		OsVersion:        sql.NullString{String: utils.GetOSVersion(r), Valid: true},
+		IpAddress:        utils.InetFromIP(net.ParseIP(utils.GetClientIP(r))),
+		IsActive:         sql.NullBool{Bool: true, Valid: true},
+		LastAccessedAt:   sql.NullTime{Time: now, Valid: true},
+		ExpiresAt:        expiresAt,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting impersonation", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.audit.WithTx(tx).Record(r.Context(), audit.Entry{
+		ActorID:      principal.UserID,
+		Action:       "impersonation.start",
+		ResourceType: "user",
+		ResourceID:   target.ID,
+		NewValues: map[string]any{
+			"reason":    req.Reason,
+			"sessionId": sessionID,
+			"expiresAt": expiresAt,
+		},
+	}.FromRequest(r))
+	if err != nil {
+		log.Printf("Failed to start impersonation: %v", err)
+		utils.SendErrorResponse(w, "Error starting impersonation", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error starting impersonation", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(ImpersonationResponse{
+		AccessToken: accessToken,
+		SessionID:   sessionID.String(),
+		ExpiresAt:   expiresAt,
+		User: User{
+			ID:        target.ID.String(),
+			Email:     target.Email,
+			FirstName: target.FirstName,
+			LastName:  target.LastName,
+		},
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// StopImpersonation ends the impersonation session the request is made with
+func (h *AdminHandler) StopImpersonation(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !principal.IsImpersonated() {
+		utils.SendErrorResponse(w, "Not impersonating a user", http.StatusBadRequest)
+		return
+	}
+
+	err := h.queries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		ID:            principal.SessionID,
+		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedReason: sql.NullString{String: "Impersonation ended", Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error ending impersonation", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.audit.Record(r.Context(), audit.Entry{
+		ActorID:      principal.ActorID,
+		Action:       "impersonation.stop",
+		ResourceType: "user",
+		ResourceID:   principal.UserID,
+		NewValues:    map[string]any{"sessionId": principal.SessionID},
+	}.FromRequest(r))
+	if err != nil {
+		log.Printf("Failed to audit end of impersonation: %v", err)
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Impersonation ended")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,425 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/content"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// LessonRequest creates a lesson or replaces its attributes. New lessons are
+// appended after the module's last lesson. Omitted flags take their defaults
+// on creation and keep their current values on update.
+type LessonRequest struct {
+	Title           string             `json:"title" validate:"required,max=255"`
+	Description     string             `json:"description,omitempty"`
+	ContentType     string             `json:"contentType" validate:"required,oneof=video text pdf audio interactive"`
+	Content         json.RawMessage    `json:"content" validate:"required"`
+	DurationMinutes int32              `json:"durationMinutes,omitempty" validate:"gte=0"`
+	IsPreview       *bool              `json:"isPreview,omitempty"`
+	IsPublished     *bool              `json:"isPublished,omitempty"`
+	AllowComments   *bool              `json:"allowComments,omitempty"`
+	Attachments     []LessonAttachment `json:"attachments,omitempty" validate:"max=20,dive"`
+	Transcript      string             `json:"transcript,omitempty"`
+}
+
+// LessonAttachment is a downloadable file attached to a lesson
+type LessonAttachment struct {
+	Name string `json:"name" validate:"required,max=255"`
+	URL  string `json:"url" validate:"required,url"`
+	Size int64  `json:"size,omitempty" validate:"gte=0"`
+	Type string `json:"type,omitempty" validate:"max=100"`
+}
+
+// LessonResponse represents a lesson
+type LessonResponse struct {
+	ID              string             `json:"id"`
+	ModuleID        string             `json:"moduleId"`
+	Title           string             `json:"title"`
+	Description     string             `json:"description,omitempty"`
+	ContentType     string             `json:"contentType"`
+	Content         json.RawMessage    `json:"content"`
+	OrderIndex      int32              `json:"orderIndex"`
+	DurationMinutes int32              `json:"durationMinutes,omitempty"`
+	IsPreview       bool               `json:"isPreview"`
+	IsPublished     bool               `json:"isPublished"`
+	AllowComments   bool               `json:"allowComments"`
+	Attachments     []LessonAttachment `json:"attachments"`
+	Transcript      string             `json:"transcript,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// GetModuleLessons lists a module's lessons in order. Students only see
+// published lessons of published modules; course staff and admins see all
+// of them.
+func (h *CourseHandler) GetModuleLessons(w http.ResponseWriter, r *http.Request) {
+	module, ok := h.moduleFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	course, err := h.queries.GetCourseByID(r.Context(), module.CourseID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error listing lessons", http.StatusInternalServerError)
+		return
+	}
+	canManage, err := h.canManage(r.Context(), course)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error listing lessons", http.StatusInternalServerError)
+		return
+	}
+
+	var lessons []database.Lesson
+	switch {
+	case canManage:
+		lessons, err = h.queries.ListModuleLessons(r.Context(), module.ID)
+	case module.IsPublished.Bool:
+		lessons, err = h.queries.ListPublishedModuleLessons(r.Context(), module.ID)
+	default:
+		utils.SendErrorResponse(w, "Module not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error listing lessons", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]LessonResponse, 0, len(lessons))
+	for _, lesson := range lessons {
+		response = append(response, toLessonResponse(lesson))
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// CreateLesson appends a lesson to a module
+func (h *CourseHandler) CreateLesson(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[LessonRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	module, ok := h.moduleFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	lessonContent, errs, err := h.parseContent(r, req, module.CourseID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating lesson", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating lesson", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	// Serialises the new position with concurrent creates and reorders
+	if _, err := qtx.LockCourse(r.Context(), module.CourseID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+			return
+		}
+		utils.SendErrorResponse(w, "Error creating lesson", http.StatusInternalServerError)
+		return
+	}
+
+	fields := req.params(lessonContent)
+	lesson, err := qtx.CreateLesson(r.Context(), database.CreateLessonParams{
+		ModuleID:        module.ID,
+		Title:           fields.Title,
+		Description:     fields.Description,
+		ContentType:     fields.ContentType,
+		Content:         fields.Content,
+		DurationMinutes: fields.DurationMinutes,
+		IsPreview:       boolOrDefault(req.IsPreview, false),
+		IsPublished:     boolOrDefault(req.IsPublished, true),
+		AllowComments:   boolOrDefault(req.AllowComments, true),
+		Attachments:     fields.Attachments,
+		Transcript:      fields.Transcript,
+	})
+	if err != nil {
+		log.Printf("Error creating lesson: %v", err)
+		utils.SendErrorResponse(w, "Error creating lesson", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error creating lesson", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toLessonResponse(lesson)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UpdateLesson replaces a lesson's attributes. Its position is unchanged.
+func (h *CourseHandler) UpdateLesson(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[LessonRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	lessonID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid lesson ID", http.StatusBadRequest)
+		return
+	}
+
+	courseID, err := h.queries.GetLessonCourseID(r.Context(), lessonID)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Lesson not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating lesson", http.StatusInternalServerError)
+		return
+	}
+
+	lessonContent, errs, err := h.parseContent(r, req, courseID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating lesson", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	params := req.params(lessonContent)
+	params.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	params.ID = lessonID
+	lesson, err := h.queries.UpdateLesson(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Lesson not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating lesson: %v", err)
+		utils.SendErrorResponse(w, "Error updating lesson", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toLessonResponse(lesson)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteLesson deletes a lesson
+func (h *CourseHandler) DeleteLesson(w http.ResponseWriter, r *http.Request) {
+	lessonID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid lesson ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.queries.DeleteLesson(r.Context(), lessonID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error deleting lesson", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		utils.SendErrorResponse(w, "Lesson not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Lesson deleted successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// parseContent validates the content document against the request's
+// content type and returns it in its stored form. Referenced files must
+// belong to the course (see ListCourseFileUploads) and be of a MIME type
+// that matches the content type.
+func (h *CourseHandler) parseContent(r *http.Request, req LessonRequest, courseID uuid.UUID) (json.RawMessage, []middleware.ValidationError, error) {
+	parsed, err := content.Parse(content.Type(req.ContentType), req.Content)
+	if err != nil {
+		var contentErr *content.Error
+		if !errors.As(err, &contentErr) {
+			return nil, nil, err
+		}
+		field := "content"
+		if contentErr.Field != "" {
+			field += "." + contentErr.Field
+		}
+		return nil, []middleware.ValidationError{{
+			Field:   field,
+			Tag:     contentErr.Tag,
+			Message: contentErr.Message,
+		}}, nil
+	}
+
+	if errs := middleware.ValidateField(r, "content", parsed); len(errs) > 0 {
+		return nil, errs, nil
+	}
+
+	if uploads := parsed.FileUploads(); len(uploads) > 0 {
+		if errs, err := h.checkFileUploads(r, courseID, uploads); err != nil || len(errs) > 0 {
+			return nil, errs, err
+		}
+	}
+
+	encoded, err := content.Encode(parsed)
+	return encoded, nil, err
+}
+
+// checkFileUploads reports the uploads content may not refer to. Files of
+// other courses are reported as missing so their existence is not revealed.
+func (h *CourseHandler) checkFileUploads(r *http.Request, courseID uuid.UUID, uploads []content.FileUpload) ([]middleware.ValidationError, error) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		return nil, errors.New("missing principal")
+	}
+
+	ids := make([]uuid.UUID, 0, len(uploads))
+	for _, upload := range uploads {
+		ids = append(ids, upload.ID)
+	}
+	files, err := h.queries.ListCourseFileUploads(r.Context(), database.ListCourseFileUploadsParams{
+		Ids:      ids,
+		UserID:   principal.UserID,
+		CourseID: courseID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	mimeTypes := make(map[uuid.UUID]string, len(files))
+	for _, file := range files {
+		mimeTypes[file.ID] = file.MimeType.String
+	}
+
+	var errs []middleware.ValidationError
+	for _, upload := range uploads {
+		mimeType, found := mimeTypes[upload.ID]
+		switch {
+		case !found:
+			errs = append(errs, middleware.ValidationError{
+				Field:   "content." + upload.Field,
+				Tag:     "file_upload",
+				Value:   upload.ID.String(),
+				Message: fmt.Sprintf("file upload %s does not exist", upload.ID),
+			})
+		case !upload.Accepts(mimeType):
+			expected := upload.MimeType
+			if strings.HasSuffix(expected, "/") {
+				expected += "*"
+			}
+			errs = append(errs, middleware.ValidationError{
+				Field:   "content." + upload.Field,
+				Tag:     "mime_type",
+				Value:   upload.ID.String(),
+				Message: fmt.Sprintf("file upload %s must be of type %s, not %q", upload.ID, expected, mimeType),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// params converts the request to query parameters, applying the column
+// defaults to omitted values. Omitted flags are left NULL so updates keep
+// the stored values.
+func (req LessonRequest) params(lessonContent json.RawMessage) database.UpdateLessonParams {
+	params := database.UpdateLessonParams{
+		Title:           req.Title,
+		Description:     sql.NullString{String: req.Description, Valid: req.Description != ""},
+		ContentType:     req.ContentType,
+		Content:         lessonContent,
+		DurationMinutes: sql.NullInt32{Int32: req.DurationMinutes, Valid: req.DurationMinutes > 0},
+		IsPreview:       optionalBool(req.IsPreview),
+		IsPublished:     optionalBool(req.IsPublished),
+		AllowComments:   optionalBool(req.AllowComments),
+		Transcript:      sql.NullString{String: req.Transcript, Valid: req.Transcript != ""},
+	}
+	if len(req.Attachments) > 0 {
+		// Marshalling validated attachments cannot fail
+		attachments, _ := json.Marshal(req.Attachments)
+		params.Attachments = pqtype.NullRawMessage{RawMessage: attachments, Valid: true}
+	}
+	return params
+}
+
+// toLessonResponse converts a lesson to its API form
+func toLessonResponse(lesson database.Lesson) LessonResponse {
+	// Stored content is served in the current shape of its type; documents
+	// that no longer match it are served as stored
+	lessonContent, err := content.Normalize(content.Type(lesson.ContentType), lesson.Content)
+	if err != nil {
+		log.Printf("Invalid content on lesson %s: %v", lesson.ID, err)
+	}
+
+	resp := LessonResponse{
+		ID:              lesson.ID.String(),
+		ModuleID:        lesson.ModuleID.String(),
+		Title:           lesson.Title,
+		Description:     lesson.Description.String,
+		ContentType:     lesson.ContentType,
+		Content:         lessonContent,
+		OrderIndex:      lesson.OrderIndex,
+		DurationMinutes: lesson.DurationMinutes.Int32,
+		IsPreview:       lesson.IsPreview.Bool,
+		IsPublished:     lesson.IsPublished.Bool,
+		AllowComments:   lesson.AllowComments.Bool,
+		Attachments:     []LessonAttachment{},
+		Transcript:      lesson.Transcript.String,
+		CreatedAt:       lesson.CreatedAt.Time,
+		UpdatedAt:       lesson.UpdatedAt.Time,
+	}
+	if lesson.Attachments.Valid {
+		if err := json.Unmarshal(lesson.Attachments.RawMessage, &resp.Attachments); err != nil {
+			log.Printf("Invalid attachments on lesson %s: %v", lesson.ID, err)
+		}
+	}
+	return resp
+}
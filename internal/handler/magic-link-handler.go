@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// MagicLinkRequest represents the request body for a passwordless login link
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// MagicLinkResponse is returned whether or not a link was sent. The device
+// token must be presented again when the link is used.
+type MagicLinkResponse struct {
+	utils.MutationResponse
+	DeviceToken string `json:"deviceToken"`
+}
+
+// ConsumeMagicLinkRequest represents the request body for signing in with a
+// login link. DeviceToken falls back to the device cookie when omitted.
+type ConsumeMagicLinkRequest struct {
+	Token       string `json:"token" validate:"required"`
+	DeviceToken string `json:"deviceToken"`
+}
+
+const (
+	magicLinkDeviceCookie = "lms_magic_device"
+	magicLinkCookiePath   = "/api/v1/auth/magic-link"
+)
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// RequestMagicLink emails a single-use login link. Like ForgotPassword, the
+// response does not reveal whether the email is registered; the link is
+// issued in the background.
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[MagicLinkRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// Bind the link to this device through a secret only it receives
+	deviceToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating login link", http.StatusInternalServerError)
+		return
+	}
+
+	device := magicLinkDevice{
+		hash:      utils.HashToken(deviceToken),
+		ip:        utils.GetClientIP(r),
+		userAgent: r.UserAgent(),
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := h.issueMagicLink(ctx, req.Email, device); err != nil {
+			log.Printf("Failed to issue magic link: %v", err)
+		}
+	}()
+
+	h.setMagicLinkDeviceCookie(w, deviceToken, int(h.config.Auth.MagicLinkTTL/time.Second))
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(MagicLinkResponse{
+		MutationResponse: utils.SendMutationResponse("If an account exists for this email, a login link has been sent"),
+		DeviceToken:      deviceToken,
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ConsumeMagicLink signs the user in with a login link and returns tokens
+// the same way Login does
+func (h *AuthHandler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ConsumeMagicLinkRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.queries.GetValidMagicLink(r.Context(), utils.HashToken(req.Token))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired login link", http.StatusBadRequest)
+		return
+	}
+
+	if h.config.Auth.MagicLinkRequireDevice {
+		deviceToken := req.DeviceToken
+		if cookie, err := r.Cookie(magicLinkDeviceCookie); deviceToken == "" && err == nil {
+			deviceToken = cookie.Value
+		}
+		if subtle.ConstantTimeCompare([]byte(utils.HashToken(deviceToken)), []byte(link.DeviceHash)) != 1 {
+			utils.SendErrorResponse(w, "This login link must be opened on the device that requested it", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Consume the link and any older ones; following it proves the user
+	// controls the mailbox
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	consumed, err := qtx.MarkMagicLinkUsed(r.Context(), database.MarkMagicLinkUsedParams{
+		ID:     link.ID,
+		UsedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+		return
+	}
+	if consumed == 0 {
+		utils.SendErrorResponse(w, "Invalid or expired login link", http.StatusBadRequest)
+		return
+	}
+
+	err = qtx.InvalidateUserMagicLinks(r.Context(), database.InvalidateUserMagicLinksParams{
+		UserID: link.UserID,
+		UsedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := qtx.GetUserByID(r.Context(), link.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired login link", http.StatusBadRequest)
+		return
+	}
+	if isAccountDisabled(user) {
+		utils.SendErrorResponse(w, "Account is disabled", http.StatusForbidden)
+		return
+	}
+
+	if !user.EmailVerified.Bool {
+		err = qtx.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+			ID:              user.ID,
+			EmailVerifiedAt: now,
+		})
+		if err != nil {
+			utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+			return
+		}
+		user.EmailVerified = sql.NullBool{Bool: true, Valid: true}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+		return
+	}
+
+	h.setMagicLinkDeviceCookie(w, "", -1)
+
+	// Two-factor accounts must complete a second step first
+	if user.TwoFactorEnabled.Bool {
+		h.sendMFAChallenge(w, user)
+		return
+	}
+
+	h.createSession(w, r, user)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// magicLinkDevice identifies the device a login link was requested from
+type magicLinkDevice struct {
+	hash      string
+	ip        string
+	userAgent string
+}
+
+// issueMagicLink creates a login link for the account with the given email
+// and mails it. Unknown or inactive accounts are ignored, as are accounts
+// that already received MagicLinkMaxPerWindow links recently.
+func (h *AuthHandler) issueMagicLink(ctx context.Context, email string, device magicLinkDevice) error {
+	user, err := h.queries.GetUserByEmail(ctx, email)
+	if err != nil || isAccountDisabled(user) {
+		return nil
+	}
+
+	now := time.Now()
+	sent, err := h.queries.CountRecentMagicLinks(ctx, database.CountRecentMagicLinksParams{
+		UserID:    user.ID,
+		CreatedAt: now.Add(-h.config.Auth.MagicLinkWindow),
+	})
+	if err != nil {
+		return fmt.Errorf("error counting magic links: %w", err)
+	}
+	if sent >= int64(h.config.Auth.MagicLinkMaxPerWindow) {
+		log.Printf("Magic link rate limit reached for user %s", user.ID)
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Only the most recent link stays valid
+	err = h.queries.InvalidateUserMagicLinks(ctx, database.InvalidateUserMagicLinksParams{
+		UserID: user.ID,
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error invalidating previous magic links: %w", err)
+	}
+
+	err = h.queries.CreateMagicLink(ctx, database.CreateMagicLinkParams{
+		UserID:     user.ID,
+		TokenHash:  utils.HashToken(token),
+		DeviceHash: device.hash,
+		IpAddress:  utils.InetFromIP(net.ParseIP(device.ip)),
+		UserAgent:  sql.NullString{String: device.userAgent, Valid: device.userAgent != ""},
+		ExpiresAt:  now.Add(h.config.Auth.MagicLinkTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating magic link: %w", err)
+	}
+
+	restriction := ""
+	if h.config.Auth.MagicLinkRequireDevice {
+		restriction = " Open it on the device you requested it from."
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s", h.config.Server.AppURL, url.QueryEscape(token))
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to sign in. It expires in %s and can only be used once.%s\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+			user.FirstName, h.config.Auth.MagicLinkTTL, restriction, link),
+	})
+}
+
+// setMagicLinkDeviceCookie writes or, with a negative maxAge, clears the
+// device binding cookie
+func (h *AuthHandler) setMagicLinkDeviceCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkDeviceCookie,
+		Value:    value,
+		Path:     magicLinkCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.config.Server.Environment == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,535 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// ModuleRequest creates a module or replaces its attributes. New modules are
+// appended after the course's last module; use the outline endpoint to move
+// them. New modules are published unless isPublished is false; updates that
+// omit it keep the module's current state.
+type ModuleRequest struct {
+	Title                    string     `json:"title" validate:"required,max=255"`
+	Description              string     `json:"description,omitempty"`
+	IsPublished              *bool      `json:"isPublished,omitempty"`
+	UnlockType               string     `json:"unlockType,omitempty" validate:"omitempty,oneof=immediate scheduled sequential"`
+	UnlockDate               *time.Time `json:"unlockDate,omitempty" validate:"required_if=UnlockType scheduled"`
+	Prerequisites            []string   `json:"prerequisites,omitempty" validate:"max=50,dive,uuid"`
+	EstimatedDurationMinutes int32      `json:"estimatedDurationMinutes,omitempty" validate:"gte=0"`
+}
+
+// ModuleResponse represents a module
+type ModuleResponse struct {
+	ID                       string     `json:"id"`
+	CourseID                 string     `json:"courseId"`
+	Title                    string     `json:"title"`
+	Description              string     `json:"description,omitempty"`
+	OrderIndex               int32      `json:"orderIndex"`
+	IsPublished              bool       `json:"isPublished"`
+	UnlockType               string     `json:"unlockType"`
+	UnlockDate               *time.Time `json:"unlockDate,omitempty"`
+	Prerequisites            []string   `json:"prerequisites"`
+	EstimatedDurationMinutes int32      `json:"estimatedDurationMinutes,omitempty"`
+	CreatedAt                time.Time  `json:"createdAt"`
+	UpdatedAt                time.Time  `json:"updatedAt"`
+}
+
+// CourseOutlineRequest is the complete new order of a course's modules and
+// lessons. Every module and lesson of the course must be listed exactly
+// once; listing a lesson under another module moves it there.
+type CourseOutlineRequest struct {
+	Modules []OutlineModule `json:"modules" validate:"required,dive"`
+}
+
+// OutlineModule is a module and its lessons in their new order
+type OutlineModule struct {
+	ID      string   `json:"id" validate:"required,uuid"`
+	Lessons []string `json:"lessons" validate:"dive,uuid"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// GetCourseModules lists a course's modules in order. Students only see
+// published modules; course staff and admins see all of them.
+func (h *CourseHandler) GetCourseModules(w http.ResponseWriter, r *http.Request) {
+	course, ok := h.courseFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	canManage, err := h.canManage(r.Context(), course)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error listing modules", http.StatusInternalServerError)
+		return
+	}
+
+	var modules []database.Module
+	if canManage {
+		modules, err = h.queries.ListCourseModules(r.Context(), course.ID)
+	} else {
+		modules, err = h.queries.ListPublishedCourseModules(r.Context(), course.ID)
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error listing modules", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ModuleResponse, 0, len(modules))
+	for _, module := range modules {
+		response = append(response, toModuleResponse(module))
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// CreateModule appends a module to a course
+func (h *CourseHandler) CreateModule(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ModuleRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	courseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid course ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating module", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	if _, err := qtx.LockCourse(r.Context(), courseID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+			return
+		}
+		utils.SendErrorResponse(w, "Error creating module", http.StatusInternalServerError)
+		return
+	}
+
+	prerequisites, errs, err := checkPrerequisites(r, qtx, courseID, uuid.Nil, req.Prerequisites)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error creating module", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	fields := req.params(prerequisites)
+	module, err := qtx.CreateModule(r.Context(), database.CreateModuleParams{
+		CourseID:                 courseID,
+		Title:                    fields.Title,
+		Description:              fields.Description,
+		IsPublished:              boolOrDefault(req.IsPublished, true),
+		UnlockType:               fields.UnlockType,
+		UnlockDate:               fields.UnlockDate,
+		Prerequisites:            fields.Prerequisites,
+		EstimatedDurationMinutes: fields.EstimatedDurationMinutes,
+	})
+	if err != nil {
+		log.Printf("Error creating module: %v", err)
+		utils.SendErrorResponse(w, "Error creating module", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error creating module", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toModuleResponse(module)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UpdateModule replaces a module's attributes. Its position is unchanged.
+func (h *CourseHandler) UpdateModule(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ModuleRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	module, ok := h.moduleFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	prerequisites, errs, err := checkPrerequisites(r, h.queries, module.CourseID, module.ID, req.Prerequisites)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating module", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	params := req.params(prerequisites)
+	params.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	params.ID = module.ID
+	module, err = h.queries.UpdateModule(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Module not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating module: %v", err)
+		utils.SendErrorResponse(w, "Error updating module", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toModuleResponse(module)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteModule deletes a module together with its lessons
+func (h *CourseHandler) DeleteModule(w http.ResponseWriter, r *http.Request) {
+	module, ok := h.moduleFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error deleting module", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	deleted, err := qtx.DeleteModule(r.Context(), module.ID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error deleting module", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		utils.SendErrorResponse(w, "Module not found", http.StatusNotFound)
+		return
+	}
+
+	err = qtx.RemoveModulePrerequisite(r.Context(), database.RemoveModulePrerequisiteParams{
+		ModuleID: module.ID,
+		CourseID: module.CourseID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error deleting module", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error deleting module", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Module deleted successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UpdateCourseOutline reorders a course's modules and lessons in one
+// transaction, moving lessons between modules where the outline says so
+func (h *CourseHandler) UpdateCourseOutline(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[CourseOutlineRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	courseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid course ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	if _, err := qtx.LockCourse(r.Context(), courseID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.SendErrorResponse(w, "Course not found", http.StatusNotFound)
+			return
+		}
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+
+	modules, err := qtx.ListCourseModules(r.Context(), courseID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+	placements, err := qtx.ListCourseLessonPlacements(r.Context(), courseID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+
+	order, errs := req.order(modules, placements)
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	// Every position is rewritten: park the current ones first so the new
+	// ones never collide with them
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	if err := qtx.ParkCourseModules(r.Context(), courseID); err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+	_, err = qtx.SetModuleOrder(r.Context(), database.SetModuleOrderParams{
+		UpdatedAt: now,
+		ModuleIds: order.moduleIDs,
+		CourseID:  courseID,
+	})
+	if err != nil {
+		log.Printf("Error reordering modules: %v", err)
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+
+	if err := qtx.ParkCourseLessons(r.Context(), courseID); err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+	_, err = qtx.SetLessonOrder(r.Context(), database.SetLessonOrderParams{
+		UpdatedAt: now,
+		LessonIds: order.lessonIDs,
+		ModuleIds: order.lessonModuleIDs,
+		Positions: order.lessonPositions,
+		CourseID:  courseID,
+	})
+	if err != nil {
+		log.Printf("Error reordering lessons: %v", err)
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error updating outline", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Course outline updated successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+// outlineOrder is a validated outline in the shape of the reorder queries
+type outlineOrder struct {
+	moduleIDs       []uuid.UUID
+	lessonIDs       []uuid.UUID
+	lessonModuleIDs []uuid.UUID
+	lessonPositions []int32
+}
+
+// order checks that the outline lists every module and lesson of the course
+// exactly once
+func (req CourseOutlineRequest) order(modules []database.Module, placements []database.ListCourseLessonPlacementsRow) (outlineOrder, []middleware.ValidationError) {
+	var order outlineOrder
+
+	unlisted := make(map[uuid.UUID]bool, len(modules))
+	for _, module := range modules {
+		unlisted[module.ID] = true
+	}
+	for _, item := range req.Modules {
+		moduleID := uuid.MustParse(item.ID) // Validated by the uuid tag
+		if !unlisted[moduleID] {
+			return order, []middleware.ValidationError{outlineError("modules", item.ID, "module %s is not part of the course or is listed twice", item.ID)}
+		}
+		delete(unlisted, moduleID)
+		order.moduleIDs = append(order.moduleIDs, moduleID)
+	}
+	if len(unlisted) > 0 {
+		return order, []middleware.ValidationError{outlineError("modules", "", "every module of the course must be listed; %d missing", len(unlisted))}
+	}
+
+	unplaced := make(map[uuid.UUID]bool, len(placements))
+	for _, placement := range placements {
+		unplaced[placement.ID] = true
+	}
+	for i, item := range req.Modules {
+		for position, id := range item.Lessons {
+			lessonID := uuid.MustParse(id)
+			if !unplaced[lessonID] {
+				return order, []middleware.ValidationError{outlineError("lessons", id, "lesson %s is not part of the course or is listed twice", id)}
+			}
+			delete(unplaced, lessonID)
+			order.lessonIDs = append(order.lessonIDs, lessonID)
+			order.lessonModuleIDs = append(order.lessonModuleIDs, order.moduleIDs[i])
+			order.lessonPositions = append(order.lessonPositions, int32(position))
+		}
+	}
+	if len(unplaced) > 0 {
+		return order, []middleware.ValidationError{outlineError("lessons", "", "every lesson of the course must be listed; %d missing", len(unplaced))}
+	}
+
+	return order, nil
+}
+
+// outlineError reports an outline that does not match the course
+func outlineError(field, value, format string, args ...any) middleware.ValidationError {
+	return middleware.ValidationError{
+		Field:   field,
+		Tag:     "outline",
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// params converts the request to query parameters, applying the column
+// defaults to omitted values. An omitted isPublished is left NULL so updates
+// keep the stored value.
+func (req ModuleRequest) params(prerequisites []uuid.UUID) database.UpdateModuleParams {
+	unlockType := req.UnlockType
+	if unlockType == "" {
+		unlockType = "immediate"
+	}
+	params := database.UpdateModuleParams{
+		Title:                    req.Title,
+		Description:              sql.NullString{String: req.Description, Valid: req.Description != ""},
+		IsPublished:              optionalBool(req.IsPublished),
+		UnlockType:               sql.NullString{String: unlockType, Valid: true},
+		Prerequisites:            prerequisites,
+		EstimatedDurationMinutes: sql.NullInt32{Int32: req.EstimatedDurationMinutes, Valid: req.EstimatedDurationMinutes > 0},
+	}
+	if req.UnlockDate != nil {
+		params.UnlockDate = sql.NullTime{Time: *req.UnlockDate, Valid: true}
+	}
+	return params
+}
+
+// checkPrerequisites resolves prerequisite module IDs, which must be other
+// modules of the same course
+func checkPrerequisites(r *http.Request, queries *database.Queries, courseID, moduleID uuid.UUID, ids []string) ([]uuid.UUID, []middleware.ValidationError, error) {
+	prerequisites := []uuid.UUID{}
+	if len(ids) == 0 {
+		return prerequisites, nil, nil
+	}
+
+	modules, err := queries.ListCourseModules(r.Context(), courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := make(map[uuid.UUID]bool, len(modules))
+	for _, module := range modules {
+		known[module.ID] = module.ID != moduleID
+	}
+
+	var errs []middleware.ValidationError
+	for _, id := range ids {
+		prerequisite := uuid.MustParse(id) // Validated by the uuid tag
+		if !known[prerequisite] {
+			errs = append(errs, middleware.ValidationError{
+				Field:   "prerequisites",
+				Tag:     "course_module",
+				Value:   id,
+				Message: "prerequisites must be other modules of the same course",
+			})
+			continue
+		}
+		prerequisites = append(prerequisites, prerequisite)
+	}
+	return prerequisites, errs, nil
+}
+
+// moduleFromPath loads the module addressed by the {id} path value,
+// answering the request when it cannot
+func (h *CourseHandler) moduleFromPath(w http.ResponseWriter, r *http.Request) (database.Module, bool) {
+	moduleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid module ID", http.StatusBadRequest)
+		return database.Module{}, false
+	}
+
+	module, err := h.queries.GetModule(r.Context(), moduleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Module not found", http.StatusNotFound)
+		return database.Module{}, false
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting module", http.StatusInternalServerError)
+		return database.Module{}, false
+	}
+	return module, true
+}
+
+// toModuleResponse converts a module to its API form
+func toModuleResponse(module database.Module) ModuleResponse {
+	resp := ModuleResponse{
+		ID:                       module.ID.String(),
+		CourseID:                 module.CourseID.String(),
+		Title:                    module.Title,
+		Description:              module.Description.String,
+		OrderIndex:               module.OrderIndex,
+		IsPublished:              module.IsPublished.Bool,
+		UnlockType:               module.UnlockType.String,
+		Prerequisites:            make([]string, 0, len(module.Prerequisites)),
+		EstimatedDurationMinutes: module.EstimatedDurationMinutes.Int32,
+		CreatedAt:                module.CreatedAt.Time,
+		UpdatedAt:                module.UpdatedAt.Time,
+	}
+	for _, id := range module.Prerequisites {
+		resp.Prerequisites = append(resp.Prerequisites, id.String())
+	}
+	if module.UnlockDate.Valid {
+		resp.UnlockDate = &module.UnlockDate.Time
+	}
+	return resp
+}
@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/oidc"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// OIDCHandler signs users in through external OpenID Connect providers
+type OIDCHandler struct {
+	auth      *AuthHandler
+	providers oidc.Providers
+}
+
+// oidcStateClaims carry the per-login secrets between the login redirect and
+// the callback. They travel in a signed, HttpOnly cookie.
+type oidcStateClaims struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	jwt.RegisteredClaims
+}
+
+// OIDCExchangeRequest represents the request body for redeeming the login
+// code the frontend received from the callback
+type OIDCExchangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+const (
+	oidcStateCookie   = "lms_oidc_state"
+	oidcStateTTL      = 10 * time.Minute
+	oidcStateAudience = "lms-oidc-state"
+	oidcCookiePath    = "/api/v1/auth/oidc/"
+	oidcLoginCodeTTL  = time.Minute
+)
+
+// Error codes the frontend receives from Callback
+const (
+	oidcErrorInvalidState     = "invalid_state"
+	oidcErrorAccessDenied     = "access_denied"
+	oidcErrorInvalidRequest   = "invalid_request"
+	oidcErrorProvider         = "provider_error"
+	oidcErrorInvalidToken     = "invalid_token"
+	oidcErrorEmailNotVerified = "email_not_verified"
+	oidcErrorAccountDisabled  = "account_disabled"
+	oidcErrorServer           = "server_error"
+)
+
+// errProviderEmailNotVerified is returned when an unknown identity cannot be
+// linked or provisioned because the provider did not verify its email
+var errProviderEmailNotVerified = errors.New("email not verified by identity provider")
+
+// ============================================================================
+// CONSTRUCTOR
+// ============================================================================
+
+// NewOIDCHandler creates a new OIDCHandler instance. Sessions are created
+// through the given AuthHandler.
+func NewOIDCHandler(auth *AuthHandler, providers oidc.Providers) *OIDCHandler {
+	return &OIDCHandler{
+		auth:      auth,
+		providers: providers,
+	}
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// Login starts an authorization code flow with PKCE and redirects the
+// browser to the identity provider
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[r.PathValue("provider")]
+	if !ok {
+		utils.SendErrorResponse(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	state, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting sign-in", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting sign-in", http.StatusInternalServerError)
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		log.Printf("OIDC provider %s unavailable: %v", provider.Name(), err)
+		utils.SendErrorResponse(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	now := time.Now()
+	cookieValue, err := h.auth.tokens.Keys.Sign(oidcStateClaims{
+		Provider:     provider.Name(),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    h.auth.tokens.Issuer,
+			Audience:  jwt.ClaimStrings{oidcStateAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(oidcStateTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error starting sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	h.setStateCookie(w, cookieValue, int(oidcStateTTL/time.Second))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes the authorization code flow, verifies the ID token and
+// resolves the matching local user, linking or provisioning it if needed.
+// The browser is sent back to the frontend either way: with a single-use
+// login code to redeem through ExchangeCode, or with an error code.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[r.PathValue("provider")]
+	if !ok {
+		utils.SendErrorResponse(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	// The state cookie is single use
+	stateClaims, err := h.readStateCookie(r)
+	h.setStateCookie(w, "", -1)
+	query := r.URL.Query()
+	if err != nil || stateClaims.Provider != provider.Name() ||
+		subtle.ConstantTimeCompare([]byte(stateClaims.State), []byte(query.Get("state"))) != 1 {
+		h.redirectToApp(w, r, "error", oidcErrorInvalidState)
+		return
+	}
+
+	if query.Get("error") != "" {
+		h.redirectToApp(w, r, "error", oidcErrorAccessDenied)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		h.redirectToApp(w, r, "error", oidcErrorInvalidRequest)
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(r.Context(), code, stateClaims.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", provider.Name(), err)
+		h.redirectToApp(w, r, "error", oidcErrorProvider)
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(r.Context(), rawIDToken, stateClaims.Nonce)
+	if err != nil {
+		log.Printf("OIDC id token from %s rejected: %v", provider.Name(), err)
+		h.redirectToApp(w, r, "error", oidcErrorInvalidToken)
+		return
+	}
+
+	user, err := h.resolveUser(r.Context(), provider.Name(), claims)
+	if errors.Is(err, errProviderEmailNotVerified) {
+		h.redirectToApp(w, r, "error", oidcErrorEmailNotVerified)
+		return
+	}
+	if err != nil {
+		log.Printf("OIDC sign-in with %s failed: %v", provider.Name(), err)
+		h.redirectToApp(w, r, "error", oidcErrorServer)
+		return
+	}
+
+	if isAccountDisabled(user) {
+		h.redirectToApp(w, r, "error", oidcErrorAccountDisabled)
+		return
+	}
+
+	loginCode, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		h.redirectToApp(w, r, "error", oidcErrorServer)
+		return
+	}
+	err = h.auth.queries.CreateOIDCLoginCode(r.Context(), database.CreateOIDCLoginCodeParams{
+		UserID:    user.ID,
+		Provider:  provider.Name(),
+		CodeHash:  utils.HashToken(loginCode),
+		ExpiresAt: time.Now().Add(oidcLoginCodeTTL),
+	})
+	if err != nil {
+		log.Printf("Failed to create OIDC login code for user %s: %v", user.ID, err)
+		h.redirectToApp(w, r, "error", oidcErrorServer)
+		return
+	}
+
+	h.redirectToApp(w, r, "code", loginCode)
+}
+
+// ExchangeCode redeems a login code from Callback and signs the user in,
+// returning tokens the same way Login does
+func (h *OIDCHandler) ExchangeCode(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[OIDCExchangeRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.auth.queries.ConsumeOIDCLoginCode(r.Context(), database.ConsumeOIDCLoginCodeParams{
+		UsedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		CodeHash: utils.HashToken(req.Code),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.SendErrorResponse(w, "Invalid or expired login code", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, "Error signing in", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.auth.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired login code", http.StatusBadRequest)
+		return
+	}
+	if isAccountDisabled(user) {
+		utils.SendErrorResponse(w, "Account is disabled", http.StatusForbidden)
+		return
+	}
+
+	// Two-factor accounts must complete a second step first
+	if user.TwoFactorEnabled.Bool {
+		h.auth.sendMFAChallenge(w, user)
+		return
+	}
+
+	h.auth.createSession(w, r, user)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// resolveUser finds the local user for a verified ID token. Known identities
+// map straight to their user; otherwise the identity is linked to the user
+// with the same, provider-verified email, or a new user is provisioned.
+func (h *OIDCHandler) resolveUser(ctx context.Context, provider string, claims *oidc.Claims) (database.User, error) {
+	queries := h.auth.queries
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	email := sql.NullString{String: claims.Email, Valid: claims.Email != ""}
+
+	identity, err := queries.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  claims.Subject,
+	})
+	if err == nil {
+		err = queries.UpdateUserIdentityLogin(ctx, database.UpdateUserIdentityLoginParams{
+			ID:          identity.ID,
+			Email:       email,
+			LastLoginAt: now,
+		})
+		if err != nil {
+			log.Printf("Failed to update identity %s: %v", identity.ID, err)
+		}
+		return queries.GetUserByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("error loading identity: %w", err)
+	}
+
+	// Linking by email is only safe when the provider vouches for it
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return database.User{}, errProviderEmailNotVerified
+	}
+
+	tx, err := h.auth.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := queries.WithTx(tx)
+
+	user, err := qtx.GetUserByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		err = h.linkExistingUser(ctx, qtx, user, provider)
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = h.provisionUser(ctx, qtx, claims)
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+
+	err = qtx.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: now,
+	})
+	if err != nil {
+		return database.User{}, fmt.Errorf("error linking identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.User{}, err
+	}
+
+	return queries.GetUserByID(ctx, user.ID)
+}
+
+// linkExistingUser prepares a local account for linking. If the local email
+// was never verified, whoever registered it may not own the address, so the
+// password is replaced and existing sessions are signed out.
+func (h *OIDCHandler) linkExistingUser(ctx context.Context, qtx *database.Queries, user database.User, provider string) error {
+	if user.EmailVerified.Bool {
+		return nil
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	if err := qtx.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{ID: user.ID, EmailVerifiedAt: now}); err != nil {
+		return fmt.Errorf("error verifying email: %w", err)
+	}
+
+	passwordHash, err := h.unusablePasswordHash()
+	if err != nil {
+		return err
+	}
+	err = qtx.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:                user.ID,
+		PasswordHash:      passwordHash,
+		PasswordChangedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("error resetting password: %w", err)
+	}
+
+	err = qtx.RevokeUserSessions(ctx, database.RevokeUserSessionsParams{
+		UserID:        user.ID,
+		RevokedAt:     now,
+		RevokedReason: sql.NullString{String: "Linked to " + provider, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error revoking sessions: %w", err)
+	}
+
+	return nil
+}
+
+// provisionUser creates a verified student account from ID token claims.
+// The account has no usable password until the user sets one via reset.
+func (h *OIDCHandler) provisionUser(ctx context.Context, qtx *database.Queries, claims *oidc.Claims) (database.User, error) {
+	passwordHash, err := h.unusablePasswordHash()
+	if err != nil {
+		return database.User{}, err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	now := time.Now()
+	userID := uuid.New()
+	err = qtx.CreateUser(ctx, database.CreateUserParams{
+		ID:           userID,
+		Email:        claims.Email,
+		PasswordHash: passwordHash,
+		FirstName:    firstName,
+		LastName:     lastName,
+		DisplayName:  sql.NullString{String: claims.Name, Valid: claims.Name != ""},
+		AvatarUrl:    sql.NullString{String: claims.Picture, Valid: claims.Picture != "" && len(claims.Picture) <= 500},
+		CreatedAt:    sql.NullTime{Time: now, Valid: true},
+		UpdatedAt:    sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return database.User{}, fmt.Errorf("error creating user: %w", err)
+	}
+
+	// New users start in the student group
+	err = qtx.AddUserToGroup(ctx, database.AddUserToGroupParams{
+		UserID:    userID,
+		GroupName: middleware.RoleStudent,
+	})
+	if err != nil {
+		return database.User{}, fmt.Errorf("error assigning group: %w", err)
+	}
+
+	err = qtx.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{
+		ID:              userID,
+		EmailVerifiedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return database.User{}, fmt.Errorf("error verifying email: %w", err)
+	}
+
+	return database.User{ID: userID}, nil
+}
+
+// readStateCookie validates the signed state cookie set by Login
+func (h *OIDCHandler) readStateCookie(r *http.Request) (*oidcStateClaims, error) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(cookie.Value, &oidcStateClaims{}, h.auth.tokens.Keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*oidcStateClaims)
+	if !ok || !token.Valid || !claims.VerifyIssuer(h.auth.tokens.Issuer, true) || !claims.VerifyAudience(oidcStateAudience, true) {
+		return nil, errors.New("invalid state cookie")
+	}
+	return claims, nil
+}
+
+// setStateCookie writes or, with a negative maxAge, clears the state cookie
+func (h *OIDCHandler) setStateCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.auth.config.Server.Environment == "production",
+		// Lax so the cookie survives the top-level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// redirectToApp sends the browser to the frontend's sign-in page with the
+// result of the callback. It travels in the URL fragment, which browsers
+// neither send to servers nor include in the Referer header.
+func (h *OIDCHandler) redirectToApp(w http.ResponseWriter, r *http.Request, key, value string) {
+	fragment := url.Values{key: {value}}
+	http.Redirect(w, r, h.auth.config.Server.AppURL+"/oidc/callback#"+fragment.Encode(), http.StatusFound)
+}
+
+// unusablePasswordHash hashes a random secret nobody knows, for accounts
+// that sign in through an identity provider
+func (h *OIDCHandler) unusablePasswordHash() (string, error) {
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	return h.auth.hasher.Hash(secret)
+}
@@ -0,0 +1,477 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/jwtkeys"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/oidc"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	testAppURL   = "https://app.example.test"
+	testClientID = "lms"
+)
+
+func TestOIDCSignIn(t *testing.T) {
+	idp := newMockIdP(t)
+	h, db := newTestOIDCHandler(t, idp)
+
+	code := completeSignIn(t, h, idp, nil)
+	if code.Get("error") != "" {
+		t.Fatalf("sign-in failed with %q", code.Get("error"))
+	}
+
+	w := exchangeCode(h, code.Get("code"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("exchange status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var resp LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.AccessToken == "" || resp.RefreshToken == "" {
+		t.Fatalf("exchange returned no tokens: %s", w.Body)
+	}
+	if resp.User.ID != db.userID.String() {
+		t.Errorf("signed in user = %s, want %s", resp.User.ID, db.userID)
+	}
+
+	// Login codes are single use
+	if w := exchangeCode(h, code.Get("code")); w.Code != http.StatusBadRequest {
+		t.Errorf("second exchange status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request)
+		want   string
+	}{
+		{
+			name: "state from another login",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				query := callback.URL.Query()
+				query.Set("state", "forged")
+				callback.URL.RawQuery = query.Encode()
+			},
+			want: oidcErrorInvalidState,
+		},
+		{
+			name: "missing state cookie",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				callback.Header.Del("Cookie")
+			},
+			want: oidcErrorInvalidState,
+		},
+		{
+			name: "state cookie for another provider",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				callback.SetPathValue("provider", "other")
+				h.providers["other"] = newTestProvider("other", idp)
+			},
+			want: oidcErrorInvalidState,
+		},
+		{
+			name: "code issued to another login",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				// The code's PKCE challenge belongs to a different verifier
+				other := authorize(t, h, idp)
+				query := callback.URL.Query()
+				query.Set("code", other.URL.Query().Get("code"))
+				callback.URL.RawQuery = query.Encode()
+			},
+			want: oidcErrorProvider,
+		},
+		{
+			name: "replayed nonce",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				idp.nonce = "nonce-of-another-login"
+			},
+			want: oidcErrorInvalidToken,
+		},
+		{
+			name: "token for another client",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				idp.audience = "another-client"
+			},
+			want: oidcErrorInvalidToken,
+		},
+		{
+			name: "denied at the provider",
+			tamper: func(t *testing.T, h *OIDCHandler, idp *mockIdP, callback *http.Request) {
+				query := callback.URL.Query()
+				query.Del("code")
+				query.Set("error", "access_denied")
+				callback.URL.RawQuery = query.Encode()
+			},
+			want: oidcErrorAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			h, _ := newTestOIDCHandler(t, idp)
+
+			result := completeSignIn(t, h, idp, func(callback *http.Request) {
+				tt.tamper(t, h, idp, callback)
+			})
+			if result.Get("error") != tt.want {
+				t.Errorf("callback result = %v, want error %q", result, tt.want)
+			}
+			if result.Get("code") != "" {
+				t.Error("callback issued a login code")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// completeSignIn runs Login, the provider's authorization step and Callback
+// like a browser would, letting tamper change the callback request. It
+// returns the result the frontend receives in the URL fragment.
+func completeSignIn(t *testing.T, h *OIDCHandler, idp *mockIdP, tamper func(*http.Request)) url.Values {
+	t.Helper()
+
+	callback := authorize(t, h, idp)
+	if tamper != nil {
+		tamper(callback)
+	}
+
+	w := httptest.NewRecorder()
+	h.Callback(w, callback)
+	if w.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, want %d: %s", w.Code, http.StatusFound, w.Body)
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), testAppURL+"/oidc/callback#") {
+		t.Fatalf("callback redirected to %q, want the frontend", w.Header().Get("Location"))
+	}
+	result, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		t.Fatalf("invalid callback result %q: %v", location.Fragment, err)
+	}
+	return result
+}
+
+// authorize starts a sign-in and follows the redirect to the provider,
+// returning the callback request the provider sends the browser back with
+func authorize(t *testing.T, h *OIDCHandler, idp *mockIdP) *http.Request {
+	t.Helper()
+
+	login := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/mock/login", nil)
+	login.SetPathValue("provider", "mock")
+	w := httptest.NewRecorder()
+	h.Login(w, login)
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want %d: %s", w.Code, http.StatusFound, w.Body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("error authorizing: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	callback := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	callback.SetPathValue("provider", "mock")
+	for _, cookie := range w.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	return callback
+}
+
+func exchangeCode(h *OIDCHandler, code string) *httptest.ResponseRecorder {
+	body := strings.NewReader(fmt.Sprintf(`{"code":%q}`, code))
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/oidc/exchange", body)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	middleware.ValidateJSON[OIDCExchangeRequest](h.ExchangeCode)(w, r)
+	return w
+}
+
+func newTestOIDCHandler(t *testing.T, idp *mockIdP) (*OIDCHandler, *identityDB) {
+	t.Helper()
+
+	keys, err := jwtkeys.Load(jwtkeys.Options{Dir: t.TempDir(), Algorithm: jwtkeys.AlgorithmEdDSA})
+	if err != nil {
+		t.Fatalf("error creating signing keys: %v", err)
+	}
+	tokens := utils.TokenOptions{Keys: keys, Issuer: "lms-test", Audience: []string{"lms-test"}}
+
+	cfg := &config.Config{}
+	cfg.Server.AppURL = testAppURL
+	cfg.Auth.JWTExpiry = 15 * time.Minute
+	cfg.Auth.RefreshTokenExpiry = time.Hour
+
+	db := &identityDB{userID: uuid.New(), codes: map[string]bool{}}
+	conn := sql.OpenDB(db)
+	t.Cleanup(func() { conn.Close() })
+
+	auth := NewAuthHandler(conn, database.New(conn), cfg, nil, tokens, nil)
+	return NewOIDCHandler(auth, oidc.Providers{"mock": newTestProvider("mock", idp)}), db
+}
+
+// newTestProvider configures the mock provider under the given name
+func newTestProvider(name string, idp *mockIdP) *oidc.Provider {
+	return oidc.NewProvider(config.OIDCProviderConfig{
+		Name:         name,
+		Issuer:       idp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "https://api.example.test/api/v1/auth/oidc/" + name + "/callback",
+		Scopes:       []string{"openid", "email"},
+	}, idp.server.Client())
+}
+
+// mockIdP is an OpenID provider that authorizes every request at once. The
+// nonce and audience of the ID tokens it issues can be overridden.
+type mockIdP struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	nonce    string
+	audience string
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+}
+
+// mockGrant is what the provider remembers about an authorization code
+type mockGrant struct {
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating provider key: %v", err)
+	}
+
+	idp := &mockIdP{key: key, audience: testClientID, grants: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /authorize", idp.authorize)
+	mux.HandleFunc("POST /token", idp.token)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != testClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" ||
+		query.Get("state") == "" || query.Get("nonce") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := uuid.NewString()
+	idp.mu.Lock()
+	idp.grants[code] = mockGrant{
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	callback := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, r, query.Get("redirect_uri")+"?"+callback.Encode(), http.StatusFound)
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, _ := r.BasicAuth()
+	if clientID != testClientID || secret != "secret" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	grant, ok := idp.grants[r.PostFormValue("code")]
+	delete(idp.grants, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	// The verifier must hash to the challenge sent with the authorization request
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	nonce := grant.nonce
+	if idp.nonce != "" {
+		nonce = idp.nonce
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            idp.audience,
+		"sub":            "mock-subject",
+		"email":          "student@example.test",
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = "mock"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	coordinate := func(value []byte) string {
+		padded := make([]byte, 32)
+		copy(padded[32-len(value):], value)
+		return base64.RawURLEncoding.EncodeToString(padded)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": "mock",
+		"use": "sig",
+		"alg": "ES256",
+		"crv": "P-256",
+		"x":   coordinate(idp.key.X.Bytes()),
+		"y":   coordinate(idp.key.Y.Bytes()),
+	}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+// identityDB is a database/sql driver holding one user already linked to
+// the mock provider, and the login codes issued for it
+type identityDB struct {
+	userID uuid.UUID
+
+	mu    sync.Mutex
+	codes map[string]bool // code hash -> used
+}
+
+func (db *identityDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *identityDB) Driver() driver.Driver                        { return nil }
+
+func (db *identityDB) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (db *identityDB) Close() error                        { return nil }
+func (db *identityDB) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+func (db *identityDB) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "CreateOIDCLoginCode":
+		db.mu.Lock()
+		db.codes[args[2].Value.(string)] = false
+		db.mu.Unlock()
+	case "UpdateUserIdentityLogin", "CreateSession", "RecordSuccessfulLogin":
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (db *identityDB) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch name := queryName.FindStringSubmatch(query)[1]; name {
+	case "GetUserIdentity":
+		return &staticRows{rows: [][]driver.Value{{
+			uuid.NewString(), db.userID.String(), "mock", "mock-subject", "student@example.test", nil, time.Now(),
+		}}}, nil
+
+	case "GetUserByID":
+		user := make([]driver.Value, 36)
+		user[0], user[1], user[5], user[6], user[7] = db.userID.String(), "student@example.test", "", "Test", "Student"
+		return &staticRows{rows: [][]driver.Value{user}}, nil
+
+	case "ConsumeOIDCLoginCode":
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		hash := args[1].Value.(string)
+		if used, ok := db.codes[hash]; !ok || used {
+			return &staticRows{}, nil
+		}
+		db.codes[hash] = true
+		return &staticRows{rows: [][]driver.Value{{db.userID.String()}}}, nil
+
+	case "GetUserRole":
+		return &staticRows{}, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected query %s", name)
+	}
+}
+
+// staticRows serves fixed rows; the column count follows the first row
+type staticRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *staticRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"value"}
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *staticRows) Close() error { return nil }
+func (r *staticRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
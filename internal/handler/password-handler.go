@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/password"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// ForgotPasswordRequest represents the forgot password payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents the reset password payload
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangePasswordRequest represents the change password payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+// ChangePasswordResponse carries a fresh access token for the current
+// session, since the old one may still be limited to changing the password
+type ChangePasswordResponse struct {
+	utils.MutationResponse
+	AccessToken string `json:"accessToken"`
+}
+
+// mailTimeout bounds background email delivery
+const mailTimeout = 30 * time.Second
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// ForgotPassword handles password reset requests. The response is identical
+// whether or not the email is registered so it cannot be used to probe for
+// accounts; the reset itself is issued in the background.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ForgotPasswordRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := h.issuePasswordReset(ctx, req.Email); err != nil {
+			log.Printf("Failed to issue password reset: %v", err)
+		}
+	}()
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("If an account exists for this email, a password reset link has been sent")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ResetPasswordRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// Look up an unused, unexpired reset by token hash
+	reset, err := h.queries.GetValidPasswordReset(r.Context(), utils.HashToken(req.Token))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), reset.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	// Enforce the password policy and history
+	errs, err := h.checkNewPassword(r, "password", req.Password, user)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	// Hash the new password before storing
+	hashedPassword, err := h.hasher.Hash(req.Password)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	// Consume the token, update the password and sign out every device atomically
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	consumed, err := qtx.MarkPasswordResetUsed(r.Context(), database.MarkPasswordResetUsedParams{
+		ID:     reset.ID,
+		UsedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+	if consumed == 0 {
+		utils.SendErrorResponse(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:                reset.UserID,
+		PasswordHash:      hashedPassword,
+		PasswordChangedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.recordPasswordHistory(r.Context(), qtx, user); err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.InvalidateUserPasswordResets(r.Context(), database.InvalidateUserPasswordResetsParams{
+		UserID: reset.UserID,
+		UsedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.RevokeUserSessions(r.Context(), database.RevokeUserSessionsParams{
+		UserID:        reset.UserID,
+		RevokedAt:     now,
+		RevokedReason: sql.NullString{String: "Password reset", Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Password has been reset")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ChangePassword replaces the caller's password after confirming the current
+// one. Other sessions are signed out, and the current session gets a new
+// access token that is no longer limited by must_change_password.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ChangePasswordRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.hasher.Verify(user.PasswordHash, req.CurrentPassword); err != nil {
+		utils.SendErrorResponse(w, "Current password is incorrect", http.StatusBadRequest)
+		return
+	}
+
+	// Enforce the password policy and history
+	errs, err := h.checkNewPassword(r, "newPassword", req.NewPassword, user)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		middleware.SendValidationErrors(w, errs)
+		return
+	}
+
+	hashedPassword, err := h.hasher.Hash(req.NewPassword)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	// Update the password and sign out every other device atomically
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:                user.ID,
+		PasswordHash:      hashedPassword,
+		PasswordChangedAt: now,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.recordPasswordHistory(r.Context(), qtx, user); err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = qtx.RevokeOtherUserSessions(r.Context(), database.RevokeOtherUserSessionsParams{
+		RevokedAt:     now,
+		RevokedReason: sql.NullString{String: "Password changed", Valid: true},
+		UserID:        user.ID,
+		ID:            principal.SessionID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.SendErrorResponse(w, "Error changing password", http.StatusInternalServerError)
+		return
+	}
+
+	// UpdateUserPassword clears must_change_password
+	user.MustChangePassword = sql.NullBool{Bool: false, Valid: true}
+	accessToken, err := h.generateAccessToken(r.Context(), user, principal.SessionID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating tokens", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(ChangePasswordResponse{
+		MutationResponse: utils.SendMutationResponse("Password has been changed"),
+		AccessToken:      accessToken,
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// issuePasswordReset creates a single-use reset token for the account with
+// the given email and mails it. Unknown or inactive accounts are ignored.
+func (h *AuthHandler) issuePasswordReset(ctx context.Context, email string) error {
+	user, err := h.queries.GetUserByEmail(ctx, email)
+	if err != nil || isAccountDisabled(user) {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Only the most recent link stays valid
+	now := time.Now()
+	err = h.queries.InvalidateUserPasswordResets(ctx, database.InvalidateUserPasswordResetsParams{
+		UserID: user.ID,
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error invalidating previous resets: %w", err)
+	}
+
+	err = h.queries.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(h.config.Auth.PasswordResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating password reset: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.config.Server.AppURL, url.QueryEscape(token))
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+			user.FirstName, h.config.Auth.PasswordResetTTL, link),
+	})
+}
+
+// checkPasswordPolicy reports the policy rules the password breaks as
+// validation errors on field, in the request's language. personal holds the
+// email and names the password must not contain.
+func (h *AuthHandler) checkPasswordPolicy(r *http.Request, field, newPassword string, personal ...string) []middleware.ValidationError {
+	var errs []middleware.ValidationError
+	translate := middleware.Translator(r)
+	for _, violation := range h.policy.Check(r.Context(), newPassword, personal...) {
+		// The password itself is never echoed back
+		errs = append(errs, middleware.ValidationError{
+			Field:   field,
+			Tag:     violation.Rule,
+			Message: passwordMessage(translate, violation),
+		})
+	}
+	return errs
+}
+
+// checkNewPassword applies the password policy for an existing user and
+// rejects the current password and the last PasswordHistorySize ones
+func (h *AuthHandler) checkNewPassword(r *http.Request, field, newPassword string, user database.User) ([]middleware.ValidationError, error) {
+	errs := h.checkPasswordPolicy(r, field, newPassword,
+		user.Email, user.FirstName, user.LastName, user.DisplayName.String)
+	if len(errs) > 0 || h.config.Auth.PasswordHistorySize == 0 {
+		return errs, nil
+	}
+
+	previous, err := h.queries.ListRecentPasswordHashes(r.Context(), database.ListRecentPasswordHashesParams{
+		UserID: user.ID,
+		Limit:  int32(h.config.Auth.PasswordHistorySize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading password history: %w", err)
+	}
+
+	for _, hash := range append([]string{user.PasswordHash}, previous...) {
+		if h.hasher.Verify(hash, newPassword) == nil {
+			errs = append(errs, middleware.ValidationError{
+				Field: field,
+				Tag:   password.RuleReused,
+				Message: passwordMessage(middleware.Translator(r), password.Violation{
+					Rule:    password.RuleReused,
+					Message: "password must not match any of your recent passwords",
+				}),
+			})
+			break
+		}
+	}
+	return errs, nil
+}
+
+// passwordMessage translates a policy violation, keeping the policy's own
+// English message for rules without a translation
+func passwordMessage(translate func(string, ...string) (string, bool), violation password.Violation) string {
+	var params []string
+	if violation.Limit > 0 {
+		params = append(params, strconv.Itoa(violation.Limit))
+	}
+	if message, ok := translate("password_"+violation.Rule, params...); ok {
+		return message
+	}
+	return violation.Message
+}
+
+// recordPasswordHistory remembers the password the user is replacing and
+// forgets anything older than PasswordHistorySize
+func (h *AuthHandler) recordPasswordHistory(ctx context.Context, qtx *database.Queries, user database.User) error {
+	if h.config.Auth.PasswordHistorySize == 0 {
+		return nil
+	}
+
+	err := qtx.CreatePasswordHistory(ctx, database.CreatePasswordHistoryParams{
+		UserID:       user.ID,
+		PasswordHash: user.PasswordHash,
+	})
+	if err != nil {
+		return fmt.Errorf("error recording password history: %w", err)
+	}
+
+	err = qtx.PrunePasswordHistory(ctx, database.PrunePasswordHistoryParams{
+		UserID: user.ID,
+		Limit:  int32(h.config.Auth.PasswordHistorySize),
+	})
+	if err != nil {
+		return fmt.Errorf("error pruning password history: %w", err)
+	}
+	return nil
+}
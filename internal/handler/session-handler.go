@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// SessionResponse describes one signed-in device
+type SessionResponse struct {
+	ID             string     `json:"id"`
+	DeviceName     string     `json:"deviceName,omitempty"`
+	DeviceType     string     `json:"deviceType,omitempty"`
+	Browser        string     `json:"browser,omitempty"`
+	BrowserVersion string     `json:"browserVersion,omitempty"`
+	OS             string     `json:"os,omitempty"`
+	OSVersion      string     `json:"osVersion,omitempty"`
+	IPAddress      string     `json:"ipAddress,omitempty"`
+	Location       string     `json:"location,omitempty"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	Current        bool       `json:"current"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// ListSessions returns the caller's active sessions, most recently used
+// first, flagging the one the request was made with
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.queries.GetActiveSessions(r.Context(), database.GetActiveSessionsParams{
+		UserID:   principal.UserID,
+		IsActive: sql.NullBool{Bool: true, Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting sessions", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		// Expired sessions stay active until cleaned up but can no longer refresh
+		if session.ExpiresAt.Before(now) {
+			continue
+		}
+		response = append(response, toSessionResponse(session, principal.SessionID))
+	}
+
+	slices.SortFunc(response, func(a, b SessionResponse) int {
+		return lastUsed(b).Compare(lastUsed(a))
+	})
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DeleteSession signs out one of the caller's sessions
+func (h *AuthHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	// Sessions of other users are reported as not found
+	revoked, err := h.queries.RevokeUserSession(r.Context(), database.RevokeUserSessionParams{
+		ID:            sessionID,
+		UserID:        principal.UserID,
+		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedReason: sql.NullString{String: "Revoked by user", Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+	if revoked == 0 {
+		utils.SendErrorResponse(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Session revoked successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// RevokeOtherSessions signs out every session except the current one
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok || principal.SessionID == uuid.Nil {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, err := h.queries.RevokeOtherUserSessions(r.Context(), database.RevokeOtherUserSessionsParams{
+		ID:            principal.SessionID,
+		UserID:        principal.UserID,
+		RevokedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedReason: sql.NullString{String: "Revoked by user", Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Other sessions revoked successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// toSessionResponse converts a session row for the sessions API
+func toSessionResponse(session database.UserSession, currentSessionID uuid.UUID) SessionResponse {
+	response := SessionResponse{
+		ID:             session.ID.String(),
+		DeviceName:     session.DeviceName.String,
+		DeviceType:     session.DeviceType.String,
+		Browser:        session.Browser.String,
+		BrowserVersion: session.BrowserVersion.String,
+		OS:             session.Os.String,
+		OSVersion:      session.OsVersion.String,
+		ExpiresAt:      session.ExpiresAt,
+		Current:        session.ID == currentSessionID,
+	}
+
+	if session.IpAddress.Valid && session.IpAddress.IPNet.IP != nil {
+		response.IPAddress = session.IpAddress.IPNet.IP.String()
+	}
+	if session.Location.Valid {
+		// Location is stored as a JSON string
+		if err := json.Unmarshal(session.Location.RawMessage, &response.Location); err != nil {
+			response.Location = ""
+		}
+	}
+	if session.LastAccessedAt.Valid {
+		response.LastAccessedAt = &session.LastAccessedAt.Time
+	}
+	if session.CreatedAt.Valid {
+		response.CreatedAt = &session.CreatedAt.Time
+	}
+
+	return response
+}
+
+// lastUsed returns the time a session was last seen
+func lastUsed(session SessionResponse) time.Time {
+	if session.LastAccessedAt != nil {
+		return *session.LastAccessedAt
+	}
+	if session.CreatedAt != nil {
+		return *session.CreatedAt
+	}
+	return time.Time{}
+}
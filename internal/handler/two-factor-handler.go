@@ -0,0 +1,448 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// EnableTwoFactorRequest confirms enrollment with a first code from the authenticator
+type EnableTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// DisableTwoFactorRequest represents the disable 2FA payload
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// RegenerateBackupCodesRequest represents the backup code regeneration payload
+type RegenerateBackupCodesRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// VerifyTwoFactorRequest completes a two-step login with a TOTP or backup code
+type VerifyTwoFactorRequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TwoFactorSetupResponse carries the secret for authenticator enrollment
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+// BackupCodesResponse carries freshly generated backup codes. They are only
+// ever shown once; the server keeps hashes.
+type BackupCodesResponse struct {
+	BackupCodes []string `json:"backupCodes"`
+}
+
+// MFAChallengeResponse is returned by Login instead of tokens when the
+// account has two-factor authentication enabled
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+// backupCodeCount is the number of backup codes issued at a time
+const backupCodeCount = 10
+
+var backupCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// SetupTwoFactor generates a new TOTP secret for the authenticated user. 2FA
+// stays off until the secret is confirmed through EnableTwoFactor.
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.TwoFactorEnabled.Bool {
+		utils.SendErrorResponse(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	key, err := h.config.Auth.TwoFactorKey()
+	if err != nil {
+		log.Printf("Two-factor setup unavailable: %v", err)
+		utils.SendErrorResponse(w, "Two-factor authentication is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating secret", http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only stored encrypted
+	encrypted, err := utils.EncryptSecret(secret, key)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating secret", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.queries.SetTwoFactorSecret(r.Context(), database.SetTwoFactorSecretParams{
+		ID:              user.ID,
+		TwoFactorSecret: sql.NullString{String: encrypted, Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error storing secret", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPProvisioningURI(secret, h.config.Auth.TwoFactorIssuer, user.Email),
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// EnableTwoFactor turns on 2FA once the user proves their authenticator
+// produces valid codes, and returns a first set of backup codes
+func (h *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[EnableTwoFactorRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.TwoFactorEnabled.Bool {
+		utils.SendErrorResponse(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if !user.TwoFactorSecret.Valid {
+		utils.SendErrorResponse(w, "Two-factor setup has not been started", http.StatusBadRequest)
+		return
+	}
+
+	valid, err := h.validateTOTP(r.Context(), user, req.Code)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error verifying code", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		utils.SendErrorResponse(w, "Invalid verification code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating backup codes", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.queries.EnableTwoFactor(r.Context(), database.EnableTwoFactorParams{
+		ID:          user.ID,
+		BackupCodes: hashes,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(BackupCodesResponse{BackupCodes: codes}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// DisableTwoFactor turns off 2FA after re-checking the password and a
+// current TOTP or backup code
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[DisableTwoFactorRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !user.TwoFactorEnabled.Bool {
+		utils.SendErrorResponse(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.hasher.Verify(user.PasswordHash, req.Password); err != nil {
+		utils.SendErrorResponse(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	valid, err := h.verifySecondFactor(r.Context(), user, req.Code)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error verifying code", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		utils.SendErrorResponse(w, "Invalid verification code", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.DisableTwoFactor(r.Context(), user.ID); err != nil {
+		utils.SendErrorResponse(w, "Error disabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Two-factor authentication disabled")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// RegenerateBackupCodes replaces all remaining backup codes with a new set
+func (h *AuthHandler) RegenerateBackupCodes(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[RegenerateBackupCodesRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !user.TwoFactorEnabled.Bool {
+		utils.SendErrorResponse(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	valid, err := h.validateTOTP(r.Context(), user, req.Code)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error verifying code", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		utils.SendErrorResponse(w, "Invalid verification code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating backup codes", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.queries.UpdateBackupCodes(r.Context(), database.UpdateBackupCodesParams{
+		ID:          user.ID,
+		BackupCodes: hashes,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error storing backup codes", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(BackupCodesResponse{BackupCodes: codes}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// VerifyTwoFactor completes a two-step login. It exchanges the mfa_pending
+// token from Login plus a TOTP or backup code for a regular session.
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[VerifyTwoFactorRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := utils.ValidateJWT(req.MFAToken, h.tokens)
+	if err != nil || claims.Type != "mfa_pending" {
+		utils.SendErrorResponse(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := utils.GetUserIDFromClaims(claims)
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil || !user.TwoFactorEnabled.Bool || isAccountLocked(user) || isAccountDisabled(user) {
+		utils.SendErrorResponse(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	valid, err := h.verifySecondFactor(r.Context(), user, req.Code)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error verifying code", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		// Wrong codes count towards the same lockout as wrong passwords
+		h.registerFailedLogin(r.Context(), user)
+		utils.SendErrorResponse(w, "Invalid verification code", http.StatusUnauthorized)
+		return
+	}
+
+	h.createSession(w, r, user)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// sendMFAChallenge answers a successful password check with an mfa_pending
+// token instead of a session
+func (h *AuthHandler) sendMFAChallenge(w http.ResponseWriter, user database.User) {
+	ttl := h.config.Auth.MFATokenTTL
+	mfaToken, err := utils.GenerateMFAToken(user.ID, h.tokens, ttl)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error generating tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int(ttl / time.Second),
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// currentUser loads the authenticated user, writing an error response if
+// that is not possible
+func (h *AuthHandler) currentUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return database.User{}, false
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return database.User{}, false
+	}
+
+	return user, true
+}
+
+// validateTOTP checks a code against the user's encrypted TOTP secret. A
+// valid code is accepted only once: its time step is recorded in the same
+// statement that accepts it.
+func (h *AuthHandler) validateTOTP(ctx context.Context, user database.User, code string) (bool, error) {
+	if !user.TwoFactorSecret.Valid {
+		return false, nil
+	}
+
+	key, err := h.config.Auth.TwoFactorKey()
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := utils.DecryptSecret(user.TwoFactorSecret.String, key)
+	if err != nil {
+		return false, err
+	}
+
+	counter, valid := utils.ValidateTOTP(secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	accepted, err := h.queries.AcceptTOTPCounter(ctx, database.AcceptTOTPCounterParams{
+		Counter: counter,
+		ID:      user.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error recording TOTP code: %w", err)
+	}
+	return accepted > 0, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused backup
+// code. A matching backup code is consumed.
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, user database.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	valid, err := h.validateTOTP(ctx, user, code)
+	if err != nil || valid {
+		return valid, err
+	}
+
+	consumed, err := h.queries.ConsumeBackupCode(ctx, database.ConsumeBackupCodeParams{
+		ID:       user.ID,
+		CodeHash: utils.HashToken(normalizeBackupCode(code)),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error consuming backup code: %w", err)
+	}
+
+	return consumed > 0, nil
+}
+
+// generateBackupCodes returns a new set of backup codes in display form
+// (xxxx-xxxx) together with the hashes to store
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+
+	for range backupCodeCount {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("error generating backup code: %v", err)
+		}
+		code := strings.ToLower(backupCodeEncoding.EncodeToString(raw))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, utils.HashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeBackupCode strips the formatting users may type along with a backup code
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
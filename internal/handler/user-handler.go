@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/export"
+	"github.com/Abdelrahiim/lms/internal/imaging"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/password"
+	"github.com/Abdelrahiim/lms/internal/storage"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// UserHandler handles user profile, personal data and account deletion HTTP
+// requests
+type UserHandler struct {
+	db       *sql.DB
+	queries  *database.Queries
+	config   *config.Config
+	storage  storage.Storage
+	exporter *export.Exporter
+	hasher   *password.Hasher
+	mailer   mailer.Mailer
+	audit    *audit.Logger
+}
+
+// UpdateProfileRequest replaces the caller's profile. Omitted optional
+// fields are cleared.
+type UpdateProfileRequest struct {
+	ProfileFields
+}
+
+// ProfileResponse represents the caller's profile
+type ProfileResponse struct {
+	ID                string    `json:"id"`
+	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"emailVerified"`
+	FirstName         string    `json:"firstName"`
+	LastName          string    `json:"lastName"`
+	DisplayName       string    `json:"displayName,omitempty"`
+	AvatarURL         string    `json:"avatarUrl,omitempty"`
+	Bio               string    `json:"bio,omitempty"`
+	Phone             string    `json:"phone,omitempty"`
+	DateOfBirth       string    `json:"dateOfBirth,omitempty"`
+	Gender            string    `json:"gender,omitempty"`
+	Country           string    `json:"country,omitempty"`
+	Timezone          string    `json:"timezone,omitempty"`
+	PreferredLanguage string    `json:"preferredLanguage,omitempty"`
+	TwoFactorEnabled  bool      `json:"twoFactorEnabled"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// UploadAvatarResponse lists the URL of every avatar size, keyed by width
+type UploadAvatarResponse struct {
+	AvatarURL string            `json:"avatarUrl"`
+	Sizes     map[string]string `json:"sizes"`
+}
+
+const (
+	// avatarField is the multipart form field the image is sent in
+	avatarField = "avatar"
+	// avatarFileType is recorded in file_uploads.file_type
+	avatarFileType = "avatar"
+	// avatarSize is the rendition users.avatar_url points at
+	avatarSize = 256
+	// avatarThumbnailSize is the rendition recorded as the thumbnail
+	avatarThumbnailSize = 64
+	// multipartOverhead allows for form boundaries and headers on top of
+	// StorageConfig.MaxSize
+	multipartOverhead = 64 * 1024
+)
+
+// avatarSizes are the square renditions made of every avatar, in pixels
+var avatarSizes = []int{64, 128, 256, 512}
+
+// ============================================================================
+// CONSTRUCTOR
+// ============================================================================
+
+// NewUserHandler creates a new UserHandler instance
+func NewUserHandler(db *sql.DB, queries *database.Queries, config *config.Config, storage storage.Storage, exporter *export.Exporter, hasher *password.Hasher, mailer mailer.Mailer) *UserHandler {
+	return &UserHandler{
+		db:       db,
+		queries:  queries,
+		config:   config,
+		storage:  storage,
+		exporter: exporter,
+		hasher:   hasher,
+		mailer:   mailer,
+		audit:    audit.New(queries),
+	}
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// GetProfile returns the caller's profile
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil || user.DeletedAt.Valid {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toProfileResponse(user)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UpdateProfile replaces the caller's profile fields. Email, password and
+// avatar have their own endpoints.
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[UpdateProfileRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Timezone and language fall back to the column defaults
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	language := req.PreferredLanguage
+	if language == "" {
+		language = "en"
+	}
+
+	updated, err := h.queries.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		FirstName:         req.FirstName,
+		LastName:          req.LastName,
+		DisplayName:       sql.NullString{String: req.DisplayName, Valid: req.DisplayName != ""},
+		Bio:               sql.NullString{String: req.Bio, Valid: req.Bio != ""},
+		Phone:             sql.NullString{String: req.Phone, Valid: req.Phone != ""},
+		DateOfBirth:       parseDate(req.DateOfBirth),
+		Gender:            sql.NullString{String: req.Gender, Valid: req.Gender != ""},
+		Country:           sql.NullString{String: req.Country, Valid: req.Country != ""},
+		Timezone:          sql.NullString{String: timezone, Valid: true},
+		PreferredLanguage: sql.NullString{String: language, Valid: true},
+		ID:                principal.UserID,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error updating profile", http.StatusInternalServerError)
+		return
+	}
+	if updated == 0 {
+		utils.SendErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, "Error getting profile", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(toProfileResponse(user)); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// UploadAvatar accepts an image as multipart/form-data in the "avatar" field.
+// The type is sniffed from the content, and the image is cropped and resized
+// to square renditions that replace the previous avatar.
+func (h *UserHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		utils.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, fileName, ok := h.readUpload(w, r, avatarField)
+	if !ok {
+		return
+	}
+
+	img, mimeType, err := imaging.Decode(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedType):
+			utils.SendErrorResponse(w, "Avatar must be a JPEG, PNG, GIF or WebP image", http.StatusUnsupportedMediaType)
+		case errors.Is(err, imaging.ErrTooManyPixels):
+			utils.SendErrorResponse(w, "Avatar dimensions are too large", http.StatusBadRequest)
+		default:
+			utils.SendErrorResponse(w, "Avatar image could not be read", http.StatusBadRequest)
+		}
+		return
+	}
+
+	// Each upload gets its own directory so replaced renditions are never
+	// served from a cached URL
+	uploadID := uuid.New()
+	dir := path.Join("avatars", principal.UserID.String(), uploadID.String())
+
+	sizes := make(map[string]string, len(avatarSizes))
+	var outputType string
+	for _, size := range avatarSizes {
+		rendition, err := imaging.Encode(imaging.Square(img, size))
+		if err != nil {
+			h.deleteStored(r, dir)
+			utils.SendErrorResponse(w, "Error processing avatar", http.StatusInternalServerError)
+			return
+		}
+
+		key := fmt.Sprintf("%s/%d.%s", dir, size, rendition.Extension)
+		if err := h.storage.Put(r.Context(), key, rendition.Data); err != nil {
+			log.Printf("Failed to store avatar: %v", err)
+			h.deleteStored(r, dir)
+			utils.SendErrorResponse(w, "Error storing avatar", http.StatusInternalServerError)
+			return
+		}
+
+		sizes[strconv.Itoa(size)] = h.storage.URL(key)
+		outputType = rendition.MIMEType
+	}
+
+	bounds := img.Bounds()
+	metadata, err := json.Marshal(map[string]any{
+		"sizes":            sizes,
+		"originalMimeType": mimeType,
+		"originalWidth":    bounds.Dx(),
+		"originalHeight":   bounds.Dy(),
+	})
+	if err != nil {
+		h.deleteStored(r, dir)
+		utils.SendErrorResponse(w, "Error processing avatar", http.StatusInternalServerError)
+		return
+	}
+
+	avatarURL := sizes[strconv.Itoa(avatarSize)]
+	replaced, err := h.saveAvatar(r, database.CreateFileUploadParams{
+		ID:              uploadID,
+		UploadedBy:      principal.UserID,
+		FileName:        fileName,
+		FileSize:        int64(len(data)),
+		FileType:        avatarFileType,
+		MimeType:        sql.NullString{String: outputType, Valid: true},
+		StoragePath:     dir,
+		StorageProvider: sql.NullString{String: h.storage.Provider(), Valid: true},
+		Url:             sql.NullString{String: avatarURL, Valid: true},
+		ThumbnailUrl:    sql.NullString{String: sizes[strconv.Itoa(avatarThumbnailSize)], Valid: true},
+		Metadata:        pqtype.NullRawMessage{RawMessage: metadata, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to save avatar: %v", err)
+		h.deleteStored(r, dir)
+		utils.SendErrorResponse(w, "Error saving avatar", http.StatusInternalServerError)
+		return
+	}
+
+	// The previous avatar's rows are already soft deleted; drop its files
+	for _, previous := range replaced {
+		h.deleteStored(r, previous)
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(UploadAvatarResponse{
+		AvatarURL: avatarURL,
+		Sizes:     sizes,
+	}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// readUpload reads the file in the given multipart form field, enforcing
+// StorageConfig.MaxSize. It returns the content and the client's file name,
+// or writes an error response and returns false.
+func (h *UserHandler) readUpload(w http.ResponseWriter, r *http.Request, field string) ([]byte, string, bool) {
+	maxSize := h.config.Storage.MaxSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			utils.SendErrorResponse(w, fmt.Sprintf("File must be at most %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, http.ErrMissingFile):
+			utils.SendErrorResponse(w, fmt.Sprintf("%s file is required", field), http.StatusBadRequest)
+		default:
+			utils.SendErrorResponse(w, "Request must be multipart/form-data", http.StatusBadRequest)
+		}
+		return nil, "", false
+	}
+	defer file.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		utils.SendErrorResponse(w, "Error reading upload", http.StatusBadRequest)
+		return nil, "", false
+	}
+	if int64(len(data)) > maxSize {
+		utils.SendErrorResponse(w, fmt.Sprintf("File must be at most %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+		return nil, "", false
+	}
+
+	// Keep only the base name, within the column's 255 characters
+	fileName := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	if runes := []rune(fileName); len(runes) > 255 {
+		fileName = string(runes[:255])
+	}
+
+	return data, fileName, true
+}
+
+// saveAvatar records the upload, points users.avatar_url at it and soft
+// deletes earlier avatars, returning their storage paths
+func (h *UserHandler) saveAvatar(r *http.Request, upload database.CreateFileUploadParams) ([]string, error) {
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := h.queries.WithTx(tx)
+
+	if err := qtx.CreateFileUpload(r.Context(), upload); err != nil {
+		return nil, fmt.Errorf("error recording upload: %w", err)
+	}
+
+	err = qtx.UpdateUserAvatar(r.Context(), database.UpdateUserAvatarParams{
+		AvatarUrl: upload.Url,
+		ID:        upload.UploadedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating avatar URL: %w", err)
+	}
+
+	replaced, err := qtx.DeleteOtherUserFiles(r.Context(), database.DeleteOtherUserFilesParams{
+		DeletedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		UploadedBy: upload.UploadedBy,
+		FileType:   upload.FileType,
+		ID:         upload.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error removing previous avatars: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+// deleteStored removes stored files, logging failures since the request
+// outcome does not depend on them
+func (h *UserHandler) deleteStored(r *http.Request, key string) {
+	if err := h.storage.Delete(r.Context(), key); err != nil {
+		log.Printf("Failed to delete stored files %s: %v", key, err)
+	}
+}
+
+// toProfileResponse converts a user for API output
+func toProfileResponse(user database.User) ProfileResponse {
+	response := ProfileResponse{
+		ID:                user.ID.String(),
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerified.Bool,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		DisplayName:       user.DisplayName.String,
+		AvatarURL:         user.AvatarUrl.String,
+		Bio:               user.Bio.String,
+		Phone:             user.Phone.String,
+		Gender:            user.Gender.String,
+		Country:           user.Country.String,
+		Timezone:          user.Timezone.String,
+		PreferredLanguage: user.PreferredLanguage.String,
+		TwoFactorEnabled:  user.TwoFactorEnabled.Bool,
+		CreatedAt:         user.CreatedAt.Time,
+	}
+	if user.DateOfBirth.Valid {
+		response.DateOfBirth = user.DateOfBirth.Time.Format(time.DateOnly)
+	}
+	return response
+}
+
+// parseDate parses a YYYY-MM-DD date, returning NULL when empty or invalid
+func parseDate(value string) sql.NullTime {
+	if value == "" {
+		return sql.NullTime{Valid: false}
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
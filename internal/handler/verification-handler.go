@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// VerifyEmailRequest represents the email verification payload
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest represents the resend verification email payload
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// VerifyEmail handles confirming an email address with a verification token
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[VerifyEmailRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// Look up the account by token hash
+	user, err := h.queries.GetUserByEmailVerificationToken(r.Context(), sql.NullString{
+		String: utils.HashToken(req.Token),
+		Valid:  true,
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	// Enforce token expiry
+	if !user.EmailVerificationSentAt.Valid ||
+		time.Since(user.EmailVerificationSentAt.Time) > h.config.Auth.EmailVerificationTTL {
+		utils.SendErrorResponse(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	err = h.queries.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+		ID:              user.ID,
+		EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		utils.SendErrorResponse(w, "Error verifying email", http.StatusInternalServerError)
+		return
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("Email verified successfully")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ResendVerification handles requests for a new verification email. The
+// response is identical for unknown, verified and throttled accounts.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	// Get validated payload from context
+	req, ok := middleware.GetValidatedPayload[ResendVerificationRequest](r)
+	if !ok {
+		utils.SendErrorResponse(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailTimeout)
+	go func() {
+		defer cancel()
+
+		user, err := h.queries.GetUserByEmail(ctx, req.Email)
+		if err != nil || user.DeletedAt.Valid || user.EmailVerified.Bool {
+			return
+		}
+
+		// Throttle resends per account
+		if user.EmailVerificationSentAt.Valid &&
+			time.Since(user.EmailVerificationSentAt.Time) < h.config.Auth.EmailVerificationResendInterval {
+			return
+		}
+
+		if err := h.sendEmailVerification(ctx, user); err != nil {
+			log.Printf("Failed to resend verification email: %v", err)
+		}
+	}()
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(utils.SendMutationResponse("If this email needs verification, a new link has been sent")); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// sendEmailVerification replaces the user's verification token and mails it
+func (h *AuthHandler) sendEmailVerification(ctx context.Context, user database.User) error {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = h.queries.SetEmailVerificationToken(ctx, database.SetEmailVerificationTokenParams{
+		ID:                      user.ID,
+		EmailVerificationToken:  sql.NullString{String: utils.HashToken(token), Valid: true},
+		EmailVerificationSentAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error storing verification token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", h.config.Server.AppURL, url.QueryEscape(token))
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.FirstName, h.config.Auth.EmailVerificationTTL, link),
+	})
+}
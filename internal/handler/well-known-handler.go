@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Abdelrahiim/lms/internal/jwtkeys"
+)
+
+// ============================================================================
+// TYPES AND STRUCTS
+// ============================================================================
+
+// WellKnownHandler serves discovery documents under /.well-known
+type WellKnownHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+// ============================================================================
+// CONSTRUCTOR
+// ============================================================================
+
+// NewWellKnownHandler creates a new WellKnownHandler instance
+func NewWellKnownHandler(keys *jwtkeys.KeySet) *WellKnownHandler {
+	return &WellKnownHandler{keys: keys}
+}
+
+// ============================================================================
+// HTTP HANDLERS
+// ============================================================================
+
+// JWKS publishes the public keys other services use to verify our tokens
+func (h *WellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	// Short cache so new keys are picked up well within the propagation delay
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.keys.JWKS()); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
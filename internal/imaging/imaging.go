@@ -0,0 +1,101 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/gabriel-vasile/mimetype"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the dimensions of images that are decoded, so a small
+// file cannot expand into gigabytes of memory
+const MaxPixels = 40_000_000
+
+// jpegQuality is used for every JPEG rendition
+const jpegQuality = 85
+
+var (
+	// ErrUnsupportedType is returned for content that is not a supported image
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrTooManyPixels is returned for images larger than MaxPixels
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// supportedTypes are the accepted image formats, by sniffed MIME type. Each
+// has a decoder registered with the image package.
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Rendition is an encoded image ready to be stored
+type Rendition struct {
+	Data      []byte
+	MIMEType  string
+	Extension string
+}
+
+// Decode identifies the image type from its content, ignoring any name or
+// declared type, and decodes it. Only the first frame of an animation is
+// kept. It returns the image and its sniffed MIME type.
+func Decode(data []byte) (image.Image, string, error) {
+	mimeType := mimetype.Detect(data).String()
+	if !supportedTypes[mimeType] {
+		return nil, mimeType, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, mimeType, fmt.Errorf("error reading image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, mimeType, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, mimeType, fmt.Errorf("error decoding image: %w", err)
+	}
+	return img, mimeType, nil
+}
+
+// Square crops the largest centred square from img and scales it to
+// size x size pixels
+func Square(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// Encode encodes img as JPEG, or as PNG when it has transparency
+func Encode(img image.Image) (Rendition, error) {
+	var buf bytes.Buffer
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return Rendition{}, fmt.Errorf("error encoding image: %w", err)
+		}
+		return Rendition{Data: buf.Bytes(), MIMEType: "image/png", Extension: "png"}, nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Rendition{}, fmt.Errorf("error encoding image: %w", err)
+	}
+	return Rendition{Data: buf.Bytes(), MIMEType: "image/jpeg", Extension: "jpg"}, nil
+}
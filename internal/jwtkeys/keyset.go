@@ -0,0 +1,389 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const (
+	// reloadInterval is how often the key directory is re-read so that keys
+	// created by other instances sharing it are picked up
+	reloadInterval = time.Minute
+	// propagationDelay is how long a new key is published for verification
+	// before it is used for signing, leaving every instance time to load it
+	propagationDelay = 5 * time.Minute
+
+	rsaKeyBits = 2048
+
+	// kidTimeLayout prefixes generated key IDs with their creation time
+	kidTimeLayout = "20060102T150405Z"
+	// createdAtHeader records the creation time in the PEM block of a key
+	createdAtHeader = "Created-At"
+)
+
+// Key is a private signing key loaded from the key directory
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+
+	private crypto.Signer
+}
+
+// Public returns the public half of the key
+func (k *Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// Options configure a KeySet
+type Options struct {
+	// Dir holds one PKCS#8 PEM file per key, named <kid>.pem
+	Dir string
+	// Algorithm is used for newly generated keys
+	Algorithm string
+	// RotationInterval is the age at which a new key is generated; zero
+	// disables rotation
+	RotationInterval time.Duration
+	// TokenLifetime is the longest lifetime of a token signed with these
+	// keys. Retired keys are kept at least this long after their successor
+	// takes over.
+	TokenLifetime time.Duration
+}
+
+// KeySet signs tokens with the current key and verifies tokens signed by any
+// key in the directory. Keys are identified by the kid header.
+type KeySet struct {
+	opts Options
+
+	mu   sync.RWMutex
+	keys []*Key // newest first
+}
+
+// Load reads the key directory, generating a first key if it is empty
+func Load(opts Options) (*KeySet, error) {
+	if opts.Algorithm != AlgorithmRS256 && opts.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", opts.Algorithm)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating key directory: %w", err)
+	}
+
+	ks := &KeySet{opts: opts}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	if len(ks.keys) == 0 {
+		key, err := ks.Rotate()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Generated initial %s signing key %s", key.Algorithm, key.ID)
+	}
+
+	return ks, nil
+}
+
+// Reload re-reads every key from the key directory
+func (ks *KeySet) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ks.opts.Dir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("error listing keys: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b *Key) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// Rotate generates a new key in the key directory. It starts signing once
+// the propagation delay has passed.
+func (ks *KeySet) Rotate() (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch ks.opts.Algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding key: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("error generating key ID: %w", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	kid := now.Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	// Write to a temporary file first so other instances never read a partial key
+	path := filepath.Join(ks.opts.Dir, kid+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdAtHeader: now.Format(time.RFC3339)},
+		Bytes:   der,
+	}), 0o600); err != nil {
+		return nil, fmt.Errorf("error writing key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("error writing key: %w", err)
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	return &Key{ID: kid, Algorithm: ks.opts.Algorithm, CreatedAt: now, private: private}, nil
+}
+
+// Run keeps the key set current until ctx is cancelled: it reloads the
+// directory, rotates the signing key when it is due and deletes keys that
+// can no longer have valid tokens
+func (ks *KeySet) Run(ctx context.Context) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Reload(); err != nil {
+				log.Printf("Error reloading signing keys: %v", err)
+				continue
+			}
+			if ks.rotationDue() {
+				if key, err := ks.Rotate(); err != nil {
+					log.Printf("Error rotating signing key: %v", err)
+				} else {
+					log.Printf("Generated %s signing key %s", key.Algorithm, key.ID)
+				}
+			}
+			if err := ks.prune(); err != nil {
+				log.Printf("Error removing retired signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// Sign signs claims with the current signing key, setting the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.signingKey()
+	if key == nil {
+		return "", errors.New("no signing key available")
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key for a token from its kid header. It
+// is meant to be passed to jwt.Parse.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid header")
+	}
+
+	key := ks.find(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public(), nil
+}
+
+// Keys returns every loaded key, newest first
+func (ks *KeySet) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return slices.Clone(ks.keys)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// signingKey returns the newest key that has been published for at least
+// the propagation delay, or the newest key if none has
+func (ks *KeySet) signingKey() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if len(ks.keys) == 0 {
+		return nil
+	}
+	for _, key := range ks.keys {
+		if time.Since(key.CreatedAt) >= propagationDelay {
+			return key
+		}
+	}
+	return ks.keys[0]
+}
+
+func (ks *KeySet) find(kid string) *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, key := range ks.keys {
+		if key.ID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// rotationDue reports whether the newest key has reached the rotation interval
+func (ks *KeySet) rotationDue() bool {
+	if ks.opts.RotationInterval <= 0 {
+		return false
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return len(ks.keys) == 0 || time.Since(ks.keys[0].CreatedAt) >= ks.opts.RotationInterval
+}
+
+// prune deletes keys whose successor has been signing for longer than the
+// token lifetime. The current signing key is never removed.
+func (ks *KeySet) prune() error {
+	current := ks.signingKey()
+	if current == nil {
+		return nil
+	}
+
+	removed := false
+	for _, key := range ks.Keys() {
+		if !key.CreatedAt.Before(current.CreatedAt) {
+			continue
+		}
+		// The successor of key started signing no later than current did
+		retiredAt := current.CreatedAt.Add(propagationDelay)
+		if time.Since(retiredAt) < ks.opts.TokenLifetime {
+			continue
+		}
+
+		err := os.Remove(filepath.Join(ks.opts.Dir, key.ID+".pem"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		log.Printf("Removed retired signing key %s", key.ID)
+		removed = true
+	}
+
+	if removed {
+		return ks.Reload()
+	}
+	return nil
+}
+
+// readKey parses a PKCS#8 PEM private key. The file name is the key ID.
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("error reading key %s: no PEM data", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key %s: %w", path, err)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	key.CreatedAt, err = createdAt(key.ID, block)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", path, err)
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.private = AlgorithmRS256, private
+	case ed25519.PrivateKey:
+		key.Algorithm, key.private = AlgorithmEdDSA, private
+	default:
+		return nil, fmt.Errorf("error parsing key %s: unsupported key type %T", path, parsed)
+	}
+
+	return key, nil
+}
+
+// createdAt returns when a key was generated, from the Created-At header of
+// its PEM block or else the timestamp its ID starts with. File times are not
+// used: copying or restoring the key directory would reset them and with
+// them the rotation and retirement schedule.
+func createdAt(kid string, block *pem.Block) (time.Time, error) {
+	if value, ok := block.Headers[createdAtHeader]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s header: %w", createdAtHeader, err)
+		}
+		return t, nil
+	}
+
+	prefix, _, _ := strings.Cut(kid, "-")
+	t, err := time.Parse(kidTimeLayout, prefix)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("no creation time: add a %s header or name the file <%s>-<suffix>.pem", createdAtHeader, kidTimeLayout)
+	}
+	return t, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
@@ -0,0 +1,451 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/authz"
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/revocation"
+	"github.com/Abdelrahiim/lms/internal/utils"
+	"github.com/google/uuid"
+)
+
+// Roles understood by RequireRole
+const (
+	RoleStudent    = "student"
+	RoleInstructor = "instructor"
+	RoleAdmin      = "admin"
+)
+
+// accessTokenTouchInterval bounds how often an API key's last use is
+// recorded, so that busy integrations do not turn every read into a write
+const accessTokenTouchInterval = time.Minute
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	UserID    uuid.UUID
+	Email     string
+	Role      string
+	SessionID uuid.UUID
+	// AccessTokenID and Scopes are set when the request was made with an
+	// API key instead of a session's access token
+	AccessTokenID uuid.UUID
+	Scopes        []string
+	// ActorID is the admin acting as UserID during impersonation
+	ActorID uuid.UUID
+}
+
+// IsImpersonated reports whether an admin is acting as the principal
+func (p *Principal) IsImpersonated() bool {
+	return p.ActorID != uuid.Nil
+}
+
+// UsesAccessToken reports whether the principal authenticated with an API key
+func (p *Principal) UsesAccessToken() bool {
+	return p.AccessTokenID != uuid.Nil
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// authDeps holds the dependencies shared by the auth middleware
+type authDeps struct {
+	config      *config.Config
+	queries     *database.Queries
+	tokens      utils.TokenOptions
+	authorizer  *authz.Authorizer
+	revocations *revocation.Cache
+	audit       *audit.Logger
+}
+
+var auth authDeps
+
+// InitAuth wires the auth middleware to the application config and database.
+// It must be called before any route using RequireAuth is served.
+func InitAuth(cfg *config.Config, queries *database.Queries, tokens utils.TokenOptions, revocations *revocation.Cache) {
+	auth = authDeps{
+		config:      cfg,
+		queries:     queries,
+		tokens:      tokens,
+		authorizer:  authz.New(queries),
+		revocations: revocations,
+		audit:       audit.New(queries),
+	}
+}
+
+// WithPrincipal returns a copy of ctx carrying the given principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// GetPrincipal retrieves the authenticated principal from context
+func GetPrincipal(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// RequireAuth middleware validates the bearer access token or API key and
+// stores the authenticated principal in the request context. Users who must
+// change their password are refused until they do.
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return authenticate(next, false)
+}
+
+// RequireAuthAllowingPasswordChange is RequireAuth for the endpoints a user
+// who must change their password may still reach, such as the password
+// change itself and logout. Unverified users may reach them under the
+// read-only policy too.
+func RequireAuthAllowingPasswordChange(next http.HandlerFunc) http.HandlerFunc {
+	return authenticate(next, true)
+}
+
+// OptionalAuth middleware lets anonymous requests through and authenticates
+// the others like RequireAuth, for public endpoints that show more to
+// signed-in users. Invalid credentials are still rejected.
+func OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	authenticated := authenticate(next, false)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+		authenticated(w, r)
+	}
+}
+
+// authenticate implements RequireAuth and RequireAuthAllowingPasswordChange
+func authenticate(next http.HandlerFunc, allowPasswordChange bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := utils.GetBearerToken(r.Header)
+		if err != nil {
+			utils.SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		if strings.HasPrefix(token, utils.PersonalAccessTokenPrefix) {
+			authenticateAccessToken(w, r, token, next, allowPasswordChange)
+			return
+		}
+
+		claims, err := utils.ValidateJWT(token, auth.tokens)
+		if err != nil || claims.Type != "access_token" {
+			utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(claims)
+		if err != nil {
+			utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		principal := &Principal{
+			UserID: userID,
+			Email:  claims.Email,
+			Role:   claims.Role,
+		}
+		if principal.Role == "" {
+			principal.Role = RoleStudent
+		}
+
+		// Every access token is bound to a session that may have been revoked
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+		principal.SessionID = sessionID
+
+		// Impersonation tokens name the admin behind them
+		if claims.Actor != nil {
+			actorID, err := uuid.Parse(claims.Actor.UserID)
+			if err != nil {
+				utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			principal.ActorID = actorID
+		}
+
+		revoked, err := auth.revocations.IsRevoked(r.Context(), sessionID, userID)
+		if err != nil {
+			log.Printf("Error checking session %s: %v", sessionID, err)
+			utils.SendErrorResponse(w, "Error validating session", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			utils.SendErrorResponse(w, "Session has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		if claims.PasswordChangeRequired && !allowPasswordChange {
+			utils.SendErrorResponse(w, "Password change required", http.StatusForbidden)
+			return
+		}
+
+		if !allowedUnverified(claims.EmailVerified, r, allowPasswordChange) {
+			utils.SendErrorResponse(w, "Email address not verified", http.StatusForbidden)
+			return
+		}
+
+		ctx := authz.NewRequestContext(r.Context(), authz.Attributes{ClientIP: utils.GetClientIP(r)})
+		handler := next
+		if principal.IsImpersonated() {
+			handler = auditImpersonation(next, principal)
+		}
+		handler(w, r.WithContext(WithPrincipal(ctx, principal)))
+	}
+}
+
+// authenticateAccessToken authenticates a request made with an API key. The
+// principal acts with the owner's role, limited to the key's scopes.
+func authenticateAccessToken(w http.ResponseWriter, r *http.Request, token string, next http.HandlerFunc, allowPasswordChange bool) {
+	ctx := r.Context()
+
+	accessToken, err := auth.queries.GetPersonalAccessTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error looking up API key: %v", err)
+			utils.SendErrorResponse(w, "Error validating token", http.StatusInternalServerError)
+			return
+		}
+		utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	if accessToken.ExpiresAt.Valid && accessToken.ExpiresAt.Time.Before(now) {
+		utils.SendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+		return
+	}
+
+	role, err := auth.queries.GetUserRole(ctx, accessToken.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		role, err = RoleStudent, nil
+	}
+	if err != nil {
+		log.Printf("Error loading role for API key %s: %v", accessToken.ID, err)
+		utils.SendErrorResponse(w, "Error validating token", http.StatusInternalServerError)
+		return
+	}
+
+	clientIP := utils.GetClientIP(r)
+	if !accessToken.LastUsedAt.Valid || now.Sub(accessToken.LastUsedAt.Time) > accessTokenTouchInterval {
+		err := auth.queries.TouchPersonalAccessToken(ctx, database.TouchPersonalAccessTokenParams{
+			ID:         accessToken.ID,
+			LastUsedAt: sql.NullTime{Time: now, Valid: true},
+			LastUsedIp: utils.InetFromIP(net.ParseIP(clientIP)),
+		})
+		if err != nil {
+			log.Printf("Failed to record use of API key %s: %v", accessToken.ID, err)
+		}
+	}
+
+	if accessToken.MustChangePassword.Bool && !allowPasswordChange {
+		utils.SendErrorResponse(w, "Password change required", http.StatusForbidden)
+		return
+	}
+
+	if !allowedUnverified(accessToken.EmailVerified.Bool, r, allowPasswordChange) {
+		utils.SendErrorResponse(w, "Email address not verified", http.StatusForbidden)
+		return
+	}
+
+	principal := &Principal{
+		UserID:        accessToken.UserID,
+		Email:         accessToken.Email,
+		Role:          role,
+		AccessTokenID: accessToken.ID,
+		Scopes:        accessToken.Scopes,
+	}
+
+	ctx = authz.NewRequestContext(ctx, authz.Attributes{ClientIP: clientIP})
+	ctx = authz.WithTokenScopes(ctx, accessToken.Scopes)
+	next(w, r.WithContext(WithPrincipal(ctx, principal)))
+}
+
+// RequireSession middleware rejects API keys, for endpoints that manage the
+// account itself such as sessions, two-factor settings and API keys. Must
+// run after RequireAuth.
+func RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipal(r.Context())
+		if !ok {
+			utils.SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		if principal.UsesAccessToken() {
+			utils.SendErrorResponse(w, "API keys cannot be used for this endpoint", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// RequireRole middleware allows the request only if the principal has one of
+// the given roles. Admins are always allowed. API keys are only limited by
+// scopes on permission checks, so they are never allowed here. Must run after
+// RequireAuth.
+func RequireRole(roles ...string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipal(r.Context())
+			if !ok {
+				utils.SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			if principal.UsesAccessToken() {
+				utils.SendErrorResponse(w, "API keys cannot be used for this endpoint", http.StatusForbidden)
+				return
+			}
+
+			if principal.Role != RoleAdmin && !slices.Contains(roles, principal.Role) {
+				utils.SendErrorResponse(w, "Insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// RequireInstructor middleware allows the request only if the principal owns
+// the course addressed by the route or is on its course_staff. Admins are
+// always allowed. Must run after RequireAuth.
+func RequireInstructor(next http.HandlerFunc) http.HandlerFunc {
+	return requireCourseAccess(next, func(ctx context.Context, courseID uuid.UUID, principal *Principal) (bool, error) {
+		return isCourseInstructor(ctx, courseID, principal.UserID)
+	})
+}
+
+// RequireModerator middleware allows the request only if the principal can
+// moderate the course addressed by the route, i.e. is its instructor or on
+// its course_staff. Admins are always allowed. Must run after RequireAuth.
+func RequireModerator(next http.HandlerFunc) http.HandlerFunc {
+	return RequireInstructor(next)
+}
+
+// RequireEnrollment middleware allows the request only if the principal has
+// an active or completed enrollment in the course addressed by the route.
+// Course staff and admins are always allowed. Must run after RequireAuth.
+func RequireEnrollment(next http.HandlerFunc) http.HandlerFunc {
+	return requireCourseAccess(next, func(ctx context.Context, courseID uuid.UUID, principal *Principal) (bool, error) {
+		enrolled, err := auth.queries.IsEnrolled(ctx, database.IsEnrolledParams{
+			CourseID: courseID,
+			UserID:   principal.UserID,
+		})
+		if err != nil || enrolled {
+			return enrolled, err
+		}
+		return isCourseInstructor(ctx, courseID, principal.UserID)
+	})
+}
+
+// requireCourseAccess resolves the course addressed by the route and runs
+// the given check against it
+func requireCourseAccess(next http.HandlerFunc, check func(context.Context, uuid.UUID, *Principal) (bool, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipal(r.Context())
+		if !ok {
+			utils.SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		// API keys do not inherit the admin bypass
+		if principal.Role == RoleAdmin && !principal.UsesAccessToken() {
+			next(w, r)
+			return
+		}
+
+		courseID, err := courseIDFromRequest(r)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, errInvalidResourceID) {
+				utils.SendErrorResponse(w, "Resource not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Failed to resolve course for %s: %v", r.URL.Path, err)
+			utils.SendErrorResponse(w, "Error checking permissions", http.StatusInternalServerError)
+			return
+		}
+
+		allowed, err := check(r.Context(), courseID, principal)
+		if err != nil {
+			log.Printf("Failed to check course access: %v", err)
+			utils.SendErrorResponse(w, "Error checking permissions", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			utils.SendErrorResponse(w, "Insufficient permissions", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+var errInvalidResourceID = errors.New("invalid resource ID")
+
+// courseIDFromRequest resolves the course a route refers to from its {id}
+// path value, e.g. /api/v1/modules/{id}/lessons resolves through modules
+func courseIDFromRequest(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return uuid.Nil, errInvalidResourceID
+	}
+
+	resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	ctx := r.Context()
+
+	switch resource {
+	case "courses":
+		return id, nil
+	case "modules":
+		return auth.queries.GetModuleCourseID(ctx, id)
+	case "lessons":
+		return auth.queries.GetLessonCourseID(ctx, id)
+	case "assessments":
+		return auth.queries.GetQuizCourseID(ctx, id)
+	case "threads":
+		return auth.queries.GetThreadCourseID(ctx, id)
+	case "posts":
+		return auth.queries.GetPostCourseID(ctx, id)
+	default:
+		return uuid.Nil, errInvalidResourceID
+	}
+}
+
+// allowedUnverified applies the read-only policy for unverified email
+// addresses. Logout and the password change, the routes open to users who
+// must change their password, stay open so that nobody is locked into a
+// session. Resending the verification email needs no login at all.
+func allowedUnverified(emailVerified bool, r *http.Request, accountMaintenance bool) bool {
+	if emailVerified || accountMaintenance || auth.config.Auth.UnverifiedEmailPolicy != config.UnverifiedEmailReadOnly {
+		return true
+	}
+	return isReadOnlyMethod(r.Method)
+}
+
+// isReadOnlyMethod reports whether the HTTP method does not modify state
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// isCourseInstructor reports whether the user owns the course or is on its staff
+func isCourseInstructor(ctx context.Context, courseID, userID uuid.UUID) (bool, error) {
+	return auth.queries.IsCourseInstructor(ctx, database.IsCourseInstructorParams{
+		CourseID: courseID,
+		UserID:   userID,
+	})
+}
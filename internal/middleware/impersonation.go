@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Abdelrahiim/lms/internal/audit"
+	"github.com/Abdelrahiim/lms/internal/utils"
+)
+
+// DenyImpersonation middleware rejects requests made while an admin is
+// impersonating the user. Use it for sensitive actions such as changing
+// credentials, payments or deleting the account. Must run after RequireAuth.
+func DenyImpersonation(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipal(r.Context())
+		if !ok {
+			utils.SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		if principal.IsImpersonated() {
+			utils.SendErrorResponse(w, "This action is not allowed while impersonating a user", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// auditImpersonation writes every request made with an impersonation token
+// to the audit trail under the real admin's ID, including its outcome
+func auditImpersonation(next http.HandlerFunc, principal *Principal) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wrapped := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next(wrapped, r)
+
+		requestID, _ := r.Context().Value(requestIDKey{}).(string)
+		entry := audit.Entry{
+			ActorID:      principal.ActorID,
+			Action:       "impersonation.request",
+			ResourceType: "user",
+			ResourceID:   principal.UserID,
+			NewValues: map[string]any{
+				"method":    r.Method,
+				"path":      r.URL.Path,
+				"query":     r.URL.RawQuery,
+				"status":    wrapped.statusCode,
+				"sessionId": principal.SessionID,
+				"requestId": requestID,
+			},
+		}.FromRequest(r)
+
+		if err := auth.audit.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+			log.Printf("Failed to audit impersonated request %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+}
@@ -13,8 +13,13 @@ import (
 // Middleware type
 type Middleware func(http.HandlerFunc) http.HandlerFunc
 
-// Chain applies middlewares in order
+// requestIDKey is the context key for the request ID
+type requestIDKey struct{}
+
+// Chain applies middlewares in order. API keys only reach f if one of the
+// middlewares checked a permission, as that is where their scopes apply.
 func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	f = requireCheckedScope(f)
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		f = middlewares[i](f)
 	}
@@ -29,7 +34,6 @@ func RequestID(next http.HandlerFunc) http.HandlerFunc {
 			requestID = uuid.New().String()
 		}
 
-		type requestIDKey struct{}
 		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
 		w.Header().Set("X-Request-ID", requestID)
 
@@ -80,7 +84,7 @@ func Recovery(next http.HandlerFunc) http.HandlerFunc {
 func CORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
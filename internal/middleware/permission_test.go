@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Abdelrahiim/lms/internal/authz"
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/google/uuid"
+)
+
+// instructorGrants are the permissions the seeded instructor group holds on
+// courses, as GetUserPermissions returns them
+var instructorGrants = [][]driver.Value{
+	{"courses", "read", "all", []byte("{}"), "instructor", int64(50)},
+	{"courses", "create", "own", []byte("{}"), "instructor", int64(50)},
+	{"courses", "update", "own", []byte("{}"), "instructor", int64(50)},
+	{"courses", "delete", "own", []byte("{}"), "instructor", int64(50)},
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	useGrants(t, instructorGrants)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	update := Chain(ok, RequirePermission("courses", "update", authz.ScopeOwn))
+	undeclared := Chain(ok)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		scopes  []string // nil for a session
+		want    int
+	}{
+		{"session on write route", update, nil, http.StatusNoContent},
+		{"read-only key on write route", update, []string{"courses:read:all"}, http.StatusForbidden},
+		{"key without scopes on write route", update, []string{}, http.StatusForbidden},
+		{"key with write scope on write route", update, []string{"courses:update:own"}, http.StatusNoContent},
+		{"key with wider scope than granted", update, []string{"courses:update:all"}, http.StatusNoContent},
+		{"session on route without permission", undeclared, nil, http.StatusNoContent},
+		{"key on route without permission", undeclared, []string{"courses:update:all"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, authenticatedRequest(http.MethodPut, tt.scopes))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// authenticatedRequest builds a request as RequireAuth leaves it for an
+// instructor, signed in with a session or, given scopes, an API key
+func authenticatedRequest(method string, scopes []string) *http.Request {
+	r := httptest.NewRequest(method, "/api/v1/courses/"+uuid.NewString(), nil)
+	principal := &Principal{UserID: uuid.New(), Role: RoleInstructor, SessionID: uuid.New()}
+
+	ctx := authz.NewRequestContext(r.Context(), authz.Attributes{})
+	if scopes != nil {
+		principal.SessionID = uuid.Nil
+		principal.AccessTokenID = uuid.New()
+		principal.Scopes = scopes
+		ctx = authz.WithTokenScopes(ctx, scopes)
+	}
+	return r.WithContext(WithPrincipal(ctx, principal))
+}
+
+// useGrants points the auth middleware at a database in which every user
+// holds the given permissions
+func useGrants(t *testing.T, grants [][]driver.Value) {
+	t.Helper()
+	db := sql.OpenDB(grantsConnector{grants})
+	t.Cleanup(func() { db.Close() })
+
+	previous := auth
+	auth = authDeps{authorizer: authz.New(database.New(db))}
+	t.Cleanup(func() { auth = previous })
+}
+
+// grantsConnector is a database/sql driver answering every query with the
+// rows of GetUserPermissions
+type grantsConnector struct {
+	rows [][]driver.Value
+}
+
+func (c grantsConnector) Connect(context.Context) (driver.Conn, error) { return grantsConn(c), nil }
+func (c grantsConnector) Driver() driver.Driver                        { return nil }
+
+type grantsConn grantsConnector
+
+func (c grantsConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &grantsRows{rows: c.rows}, nil
+}
+func (grantsConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (grantsConn) Close() error                        { return nil }
+func (grantsConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type grantsRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *grantsRows) Columns() []string {
+	return []string{"resource", "action", "scope", "constraints", "group_name", "priority"}
+}
+func (r *grantsRows) Close() error { return nil }
+func (r *grantsRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ar"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	ar_translations "github.com/go-playground/validator/v10/translations/ar"
+	de_translations "github.com/go-playground/validator/v10/translations/de"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	"golang.org/x/text/language"
+)
+
+// validationLocale is a language validation messages are available in
+type validationLocale struct {
+	tag      language.Tag
+	locale   locales.Translator
+	register func(v *validator.Validate, trans ut.Translator) error
+	// messages for the validators registered by this package, with {0} as
+	// the field name
+	messages map[string]string
+	// texts are messages of checks made outside the validator, looked up by
+	// key with Translate
+	texts map[string]string
+}
+
+// validationLocales lists the supported languages; the first is the fallback
+var validationLocales = []validationLocale{
+	{language.English, en.New(), en_translations.RegisterDefaultTranslations, map[string]string{
+		"timezone":           "{0} must be a valid IANA timezone",
+		"iso3166_1_alpha2":   "{0} must be a valid ISO 3166-1 alpha-2 country code",
+		"bcp47_language_tag": "{0} must be a valid BCP 47 language tag",
+	}, map[string]string{
+		"password_min_length":    "password must be at least {0} characters long",
+		"password_max_length":    "password must be at most {0} bytes long",
+		"password_uppercase":     "password must contain an uppercase letter",
+		"password_lowercase":     "password must contain a lowercase letter",
+		"password_digit":         "password must contain a digit",
+		"password_symbol":        "password must contain a symbol",
+		"password_personal_info": "password must not contain your email address or name",
+		"password_common":        "password is too common, choose a less predictable one",
+		"password_breached":      "password has appeared in a data breach, choose a different one",
+		"password_reused":        "password must not match any of your recent passwords",
+	}},
+	{language.Arabic, ar.New(), ar_translations.RegisterDefaultTranslations, map[string]string{
+		"timezone":           "يجب أن يكون {0} منطقة زمنية صالحة من IANA",
+		"iso3166_1_alpha2":   "يجب أن يكون {0} رمز دولة صالحًا وفق ISO 3166-1",
+		"bcp47_language_tag": "يجب أن يكون {0} وسم لغة صالحًا وفق BCP 47",
+	}, map[string]string{
+		"password_min_length":    "يجب أن تتكون كلمة المرور من {0} أحرف على الأقل",
+		"password_max_length":    "يجب ألا تتجاوز كلمة المرور {0} بايت",
+		"password_uppercase":     "يجب أن تحتوي كلمة المرور على حرف كبير",
+		"password_lowercase":     "يجب أن تحتوي كلمة المرور على حرف صغير",
+		"password_digit":         "يجب أن تحتوي كلمة المرور على رقم",
+		"password_symbol":        "يجب أن تحتوي كلمة المرور على رمز",
+		"password_personal_info": "يجب ألا تحتوي كلمة المرور على بريدك الإلكتروني أو اسمك",
+		"password_common":        "كلمة المرور شائعة جدًا، اختر كلمة يصعب تخمينها",
+		"password_breached":      "ظهرت كلمة المرور هذه في تسريب للبيانات، اختر كلمة أخرى",
+		"password_reused":        "يجب ألا تطابق كلمة المرور أيًا من كلمات المرور الأخيرة",
+	}},
+	{language.German, de.New(), de_translations.RegisterDefaultTranslations, map[string]string{
+		"timezone":           "{0} muss eine gültige IANA-Zeitzone sein",
+		"iso3166_1_alpha2":   "{0} muss ein gültiger ISO-3166-1-Ländercode sein",
+		"bcp47_language_tag": "{0} muss ein gültiges BCP-47-Sprachkennzeichen sein",
+	}, map[string]string{
+		"password_min_length":    "Das Passwort muss mindestens {0} Zeichen lang sein",
+		"password_max_length":    "Das Passwort darf höchstens {0} Bytes lang sein",
+		"password_uppercase":     "Das Passwort muss einen Großbuchstaben enthalten",
+		"password_lowercase":     "Das Passwort muss einen Kleinbuchstaben enthalten",
+		"password_digit":         "Das Passwort muss eine Ziffer enthalten",
+		"password_symbol":        "Das Passwort muss ein Sonderzeichen enthalten",
+		"password_personal_info": "Das Passwort darf weder deine E-Mail-Adresse noch deinen Namen enthalten",
+		"password_common":        "Das Passwort ist zu verbreitet, wähle ein weniger vorhersehbares",
+		"password_breached":      "Das Passwort ist in einem Datenleck aufgetaucht, wähle ein anderes",
+		"password_reused":        "Das Passwort darf keinem deiner letzten Passwörter entsprechen",
+	}},
+	{language.Spanish, es.New(), es_translations.RegisterDefaultTranslations, map[string]string{
+		"timezone":           "{0} debe ser una zona horaria IANA válida",
+		"iso3166_1_alpha2":   "{0} debe ser un código de país ISO 3166-1 válido",
+		"bcp47_language_tag": "{0} debe ser una etiqueta de idioma BCP 47 válida",
+	}, map[string]string{
+		"password_min_length":    "la contraseña debe tener al menos {0} caracteres",
+		"password_max_length":    "la contraseña debe tener como máximo {0} bytes",
+		"password_uppercase":     "la contraseña debe contener una letra mayúscula",
+		"password_lowercase":     "la contraseña debe contener una letra minúscula",
+		"password_digit":         "la contraseña debe contener un dígito",
+		"password_symbol":        "la contraseña debe contener un símbolo",
+		"password_personal_info": "la contraseña no debe contener tu correo electrónico ni tu nombre",
+		"password_common":        "la contraseña es demasiado común, elige una menos predecible",
+		"password_breached":      "la contraseña ha aparecido en una filtración de datos, elige otra",
+		"password_reused":        "la contraseña no debe coincidir con ninguna de tus contraseñas recientes",
+	}},
+	{language.French, fr.New(), fr_translations.RegisterDefaultTranslations, map[string]string{
+		"timezone":           "{0} doit être un fuseau horaire IANA valide",
+		"iso3166_1_alpha2":   "{0} doit être un code pays ISO 3166-1 valide",
+		"bcp47_language_tag": "{0} doit être une étiquette de langue BCP 47 valide",
+	}, map[string]string{
+		"password_min_length":    "le mot de passe doit contenir au moins {0} caractères",
+		"password_max_length":    "le mot de passe doit faire au plus {0} octets",
+		"password_uppercase":     "le mot de passe doit contenir une lettre majuscule",
+		"password_lowercase":     "le mot de passe doit contenir une lettre minuscule",
+		"password_digit":         "le mot de passe doit contenir un chiffre",
+		"password_symbol":        "le mot de passe doit contenir un symbole",
+		"password_personal_info": "le mot de passe ne doit contenir ni votre adresse e-mail ni votre nom",
+		"password_common":        "le mot de passe est trop courant, choisissez-en un moins prévisible",
+		"password_breached":      "le mot de passe est apparu dans une fuite de données, choisissez-en un autre",
+		"password_reused":        "le mot de passe ne doit correspondre à aucun de vos mots de passe récents",
+	}},
+}
+
+var (
+	// translators holds one translator per supported language
+	translators *ut.UniversalTranslator
+	// languageMatcher picks the closest supported language
+	languageMatcher language.Matcher
+)
+
+// registerTranslations installs translated messages for every supported
+// language on the validator
+func registerTranslations() {
+	fallback := validationLocales[0]
+	translators = ut.New(fallback.locale)
+
+	tags := make([]language.Tag, 0, len(validationLocales))
+	for _, l := range validationLocales {
+		tags = append(tags, l.tag)
+		if l.locale.Locale() != fallback.locale.Locale() {
+			translators.AddTranslator(l.locale, true)
+		}
+
+		trans, _ := translators.GetTranslator(l.locale.Locale())
+		if err := l.register(validate, trans); err != nil {
+			log.Fatalf("failed to register %s validation messages: %v", l.locale.Locale(), err)
+		}
+		for tag, message := range l.messages {
+			if err := validate.RegisterTranslation(tag, trans, registerMessage(tag, message), translateMessage); err != nil {
+				log.Fatalf("failed to register %s validation messages: %v", l.locale.Locale(), err)
+			}
+		}
+		for key, text := range l.texts {
+			if err := trans.Add(key, text, false); err != nil {
+				log.Fatalf("failed to register %s validation messages: %v", l.locale.Locale(), err)
+			}
+		}
+	}
+	languageMatcher = language.NewMatcher(tags)
+}
+
+// requestTranslator returns the translator for the language the client asked
+// for in Accept-Language or, failing that, the authenticated user's
+// preferred_language. The user is only looked up when there are errors to
+// translate.
+func requestTranslator(r *http.Request) ut.Translator {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		tags = nil
+		if principal, ok := GetPrincipal(r.Context()); ok && auth.queries != nil {
+			preferred, err := auth.queries.GetUserPreferredLanguage(r.Context(), principal.UserID)
+			if err == nil && preferred.Valid {
+				if tag, err := language.Parse(preferred.String); err == nil {
+					tags = []language.Tag{tag}
+				}
+			}
+		}
+	}
+
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		index = 0
+	}
+	trans, _ := translators.GetTranslator(validationLocales[index].locale.Locale())
+	return trans
+}
+
+// Translator returns a lookup of the texts registered in the request's
+// language. The lookup substitutes params for {0}, {1} and so on and reports
+// false for unknown keys.
+func Translator(r *http.Request) func(key string, params ...string) (string, bool) {
+	trans := requestTranslator(r)
+	return func(key string, params ...string) (string, bool) {
+		text, err := trans.T(key, params...)
+		return text, err == nil
+	}
+}
+
+// translateError returns the message for a validation error in the
+// translator's language, falling back to getErrorMessage
+func translateError(err validator.FieldError, trans ut.Translator) string {
+	if message := err.Translate(trans); message != err.Error() {
+		return message
+	}
+	return getErrorMessage(err)
+}
+
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translateMessage(trans ut.Translator, err validator.FieldError) string {
+	message, terr := trans.T(err.Tag(), err.Field())
+	if terr != nil {
+		return getErrorMessage(err)
+	}
+	return message
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
+	_ "time/tzdata" // timezone validation must not depend on the host
 
 	"github.com/Abdelrahiim/lms/internal/utils"
 	"github.com/go-playground/validator/v10"
@@ -31,6 +33,9 @@ type ErrorResponse struct {
 // Validator instance
 var validate *validator.Validate
 
+// validatedPayloadKey is the context key for the validated request payload
+type validatedPayloadKey struct{}
+
 func init() {
 	validate = validator.New()
 
@@ -43,31 +48,36 @@ func init() {
 		return name
 	})
 
-	// Register custom validators
+	// Register custom validators and their translated messages
 	registerCustomValidators()
+	registerTranslations()
 }
 
 // registerCustomValidators adds custom validation rules
 func registerCustomValidators() {
-	// Custom timezone validator
+	// IANA timezone names, checked against the tz database embedded in the
+	// binary. "Local" names the server's zone, not a real one.
 	err := validate.RegisterValidation("timezone", func(fl validator.FieldLevel) bool {
 		tz := fl.Field().String()
 		if tz == "" {
 			return true // Allow empty for optional fields
 		}
-		// Add your timezone validation logic here
-		// For now, just check if it's not empty
-		return len(tz) > 0
+		if tz == "Local" {
+			return false
+		}
+		_, err := time.LoadLocation(tz)
+		return err == nil
 	})
 	if err != nil {
 		log.Fatal("failed to register custom validator: %w", err)
 	}
 }
 
-// ValidateJSON middleware validates JSON request body against a struct
+// ValidateJSON middleware validates JSON request body against a struct.
+// DELETE requests are validated too, for routes that need a confirmation.
 func ValidateJSON[T any](next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch && r.Method != http.MethodDelete {
 			next(w, r)
 			return
 		}
@@ -89,27 +99,11 @@ func ValidateJSON[T any](next http.HandlerFunc) http.HandlerFunc {
 
 		// Validate the struct
 		if err := validate.Struct(payload); err != nil {
-			validationErrors := []ValidationError{}
-
-			for _, err := range err.(validator.ValidationErrors) {
-				validationErrors = append(validationErrors, ValidationError{
-					Field:   err.Field(),
-					Tag:     err.Tag(),
-					Value:   fmt.Sprintf("%v", err.Value()),
-					Message: getErrorMessage(err),
-				})
-			}
-
-			respondWithError(w, http.StatusBadRequest, ErrorResponse{
-				Error:   "validation_failed",
-				Message: "Request validation failed",
-				Errors:  validationErrors,
-			})
+			SendValidationErrors(w, validationErrors(r, err, ""))
 			return
 		}
 
 		// Store validated payload in context for handler use
-		type validatedPayloadKey struct{}
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, validatedPayloadKey{}, payload)
 
@@ -119,7 +113,6 @@ func ValidateJSON[T any](next http.HandlerFunc) http.HandlerFunc {
 
 // GetValidatedPayload retrieves the validated payload from context
 func GetValidatedPayload[T any](r *http.Request) (T, bool) {
-	type validatedPayloadKey struct{}
 	value := r.Context().Value(validatedPayloadKey{})
 	if value == nil {
 		var zero T
@@ -129,6 +122,40 @@ func GetValidatedPayload[T any](r *http.Request) (T, bool) {
 	return payload, ok
 }
 
+// ValidateField validates a value nested under field of a payload that
+// passed ValidateJSON, such as a JSON document whose shape depends on
+// another field. The errors name the full path of the invalid value, e.g.
+// content.chapters[1].title.
+func ValidateField(r *http.Request, field string, value any) []ValidationError {
+	if err := validate.Struct(value); err != nil {
+		return validationErrors(r, err, field)
+	}
+	return nil
+}
+
+// validationErrors converts the validator's errors, translated for the
+// request. Under a field, errors are named by their path below it.
+func validationErrors(r *http.Request, err error, field string) []ValidationError {
+	errs := []ValidationError{}
+	trans := requestTranslator(r)
+
+	for _, err := range err.(validator.ValidationErrors) {
+		name := err.Field()
+		if field != "" {
+			// The namespace starts with the Go name of the validated struct
+			_, path, _ := strings.Cut(err.Namespace(), ".")
+			name = field + "." + path
+		}
+		errs = append(errs, ValidationError{
+			Field:   name,
+			Tag:     err.Tag(),
+			Value:   fmt.Sprintf("%v", err.Value()),
+			Message: translateError(err, trans),
+		})
+	}
+	return errs
+}
+
 // getErrorMessage returns user-friendly error messages
 func getErrorMessage(err validator.FieldError) string {
 	switch err.Tag() {
@@ -145,12 +172,26 @@ func getErrorMessage(err validator.FieldError) string {
 	case "datetime":
 		return fmt.Sprintf("%s must be a valid date in format %s", err.Field(), err.Param())
 	case "timezone":
-		return fmt.Sprintf("%s must be a valid timezone", err.Field())
+		return fmt.Sprintf("%s must be a valid IANA timezone", err.Field())
+	case "iso3166_1_alpha2":
+		return fmt.Sprintf("%s must be a valid ISO 3166-1 alpha-2 country code", err.Field())
+	case "bcp47_language_tag":
+		return fmt.Sprintf("%s must be a valid BCP 47 language tag", err.Field())
 	default:
 		return fmt.Sprintf("%s is invalid", err.Field())
 	}
 }
 
+// SendValidationErrors responds with validation errors found by a handler
+// after the payload passed ValidateJSON, in the same format
+func SendValidationErrors(w http.ResponseWriter, errors []ValidationError) {
+	respondWithError(w, http.StatusBadRequest, ErrorResponse{
+		Error:   "validation_failed",
+		Message: "Request validation failed",
+		Errors:  errors,
+	})
+}
+
 // respondWithError sends JSON error response
 func respondWithError(w http.ResponseWriter, statusCode int, errorResponse ErrorResponse) {
 	w.Header().Set("Content-Type", "application/json")
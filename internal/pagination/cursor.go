@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// cursor is the position after which the next page starts. It is sent to
+// clients as base64url(JSON) "." base64url(HMAC-SHA256) so it can neither
+// be forged nor reused with a different sort or different filters.
+type cursor struct {
+	Values      []string `json:"v"`
+	Fingerprint string   `json:"f"`
+}
+
+// errInvalidCursor is reported for cursors that were tampered with or are
+// not cursors at all
+var errInvalidCursor = &Error{ParamCursor, "invalid cursor"}
+
+// encodeCursor writes the cursor pointing after row
+func (p *Paginator[T]) encodeCursor(req *Request, row T) string {
+	c := cursor{Fingerprint: req.fingerprint}
+	for _, key := range req.keys {
+		c.Values = append(c.Values, formatValue(key.typ, p.spec.Fields[key.name].Value(row)))
+	}
+
+	// Marshalling a struct of strings cannot fail
+	data, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload))
+}
+
+// decodeCursor verifies a cursor and returns its sort values
+func (p *Paginator[T]) decodeCursor(value string, req *Request) ([]any, error) {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return nil, errInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+	if c.Fingerprint != req.fingerprint || len(c.Values) != len(req.keys) {
+		return nil, &Error{ParamCursor, "cursor belongs to a different sort or filter"}
+	}
+
+	after := make([]any, len(c.Values))
+	for i, key := range req.keys {
+		if after[i], err = parseValue(key.typ, c.Values[i]); err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+	return after, nil
+}
+
+// sign computes the cursor signature
+func (p *Paginator[T]) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, p.opts.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// fingerprint identifies the sort and filters a cursor was issued for
+func fingerprint(keys []sortKey, conditions []condition) string {
+	var b strings.Builder
+	for _, key := range keys {
+		if key.desc {
+			b.WriteByte('-')
+		}
+		b.WriteString(key.name + ",")
+	}
+	for _, cond := range conditions {
+		b.WriteString("&" + cond.name + "[" + string(cond.op) + "]=" + cond.raw)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
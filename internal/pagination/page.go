@@ -0,0 +1,71 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Page is the standard envelope of list responses
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	Total      *int64 `json:"total,omitempty"` // Only set when requested
+}
+
+// Page builds the response from the rows returned by Request.Query. It
+// drops the extra row that signals another page and writes the cursor
+// pointing after the last row kept.
+func (p *Paginator[T]) Page(req *Request, rows []T) Page[T] {
+	page := Page[T]{Items: rows}
+	if len(rows) > req.limit {
+		page.Items = rows[:req.limit]
+		page.HasMore = true
+		page.NextCursor = p.encodeCursor(req, page.Items[req.limit-1])
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// MapItems converts the items of a page, e.g. from database rows to their
+// API form
+func MapItems[T, U any](page Page[T], convert func(T) U) Page[U] {
+	items := make([]U, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, convert(item))
+	}
+	return Page[U]{Items: items, NextCursor: page.NextCursor, HasMore: page.HasMore, Total: page.Total}
+}
+
+// SetLinkHeader adds an RFC 8288 Link header pointing to the first page
+// and, when there is one, the next page of r. It must be called before the
+// response status is written.
+func (page Page[T]) SetLinkHeader(w http.ResponseWriter, r *http.Request) {
+	var links []string
+
+	query := r.URL.Query()
+	if query.Has(ParamCursor) {
+		query.Del(ParamCursor)
+		links = append(links, link(r, query.Encode(), "first"))
+	}
+	if page.HasMore {
+		query.Set(ParamCursor, page.NextCursor)
+		links = append(links, link(r, query.Encode(), "next"))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// link formats one link-value for the request path with the given query
+func link(r *http.Request, query, rel string) string {
+	target := r.URL.Path
+	if query != "" {
+		target += "?" + query
+	}
+	return fmt.Sprintf(`<%s>; rel="%s"`, target, rel)
+}
@@ -0,0 +1,278 @@
+// Package pagination implements keyset pagination for list endpoints.
+//
+// A Paginator is declared once per endpoint with the fields a client may
+// sort and filter on. It parses the limit, cursor, sort and filter query
+// parameters into a Request, which wraps a list query (typically the SQL of
+// a sqlc query) into a keyset-paginated one:
+//
+//	GET /api/v1/courses/mine?sort=-createdAt,title&level[in]=beginner,advanced&limit=20
+//
+// Sorting uses a comma-separated list of fields, each prefixed with - for
+// descending order. Filters are written as field=value or field[op]=value.
+// Every response carries an opaque, signed cursor for the next page which
+// is only valid for the same sort and filters.
+package pagination
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+)
+
+// Query parameters read by Parse
+const (
+	ParamLimit  = "limit"
+	ParamCursor = "cursor"
+	ParamSort   = "sort"
+	ParamTotal  = "total"
+)
+
+// Field is a column of the list query exposed to clients
+type Field[T any] struct {
+	// Column is the column of the list query, e.g. created_at
+	Column string
+	Type   Type
+	// Nullable marks a column that may be NULL. It is sorted as if NULL
+	// were the zero value of Type, so rows without a value are neither
+	// skipped nor repeated across pages; Value must return that zero value
+	// for them, as e.g. sql.NullTime.Time does.
+	Nullable bool
+	// Sortable allows the field in the sort parameter
+	Sortable bool
+	// Operators are the filters allowed on the field; none means the field
+	// cannot be filtered on
+	Operators []Operator
+	// Value reads the field from a row. Required for sortable fields, whose
+	// values make up the cursor.
+	Value func(T) any
+}
+
+// Spec declares the fields of a list endpoint
+type Spec[T any] struct {
+	// Fields are keyed by the name used in query parameters
+	Fields map[string]Field[T]
+	// DefaultSort is used when the request has no sort parameter, e.g.
+	// "-createdAt"
+	DefaultSort string
+	// Tiebreaker names a unique sortable field. It is appended to every sort
+	// so that rows with equal sort values keep a stable order.
+	Tiebreaker string
+}
+
+// Options are the paging settings shared by every endpoint
+type Options struct {
+	key          []byte
+	defaultLimit int
+	maxLimit     int
+}
+
+// Paginator parses and answers paged requests for one list endpoint
+type Paginator[T any] struct {
+	spec Spec[T]
+	opts Options
+}
+
+// Error is a malformed paging, sorting or filter parameter. It should be
+// reported to the client as a bad request.
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+var (
+	ephemeralKey     []byte
+	ephemeralKeyOnce sync.Once
+)
+
+// NewOptions creates the paging settings from the configuration. Without
+// a cursor secret a random key is generated once per process, so cursors
+// stop working after a restart and across instances.
+func NewOptions(cfg config.PaginationConfig) Options {
+	key := []byte(cfg.CursorSecret)
+	if len(key) == 0 {
+		ephemeralKeyOnce.Do(func() {
+			log.Println("Warning: PAGINATION_CURSOR_SECRET is not set, cursors will not survive a restart")
+			ephemeralKey = make([]byte, 32)
+			if _, err := rand.Read(ephemeralKey); err != nil {
+				panic(fmt.Sprintf("pagination: generating cursor key: %v", err))
+			}
+		})
+		key = ephemeralKey
+	}
+	return Options{key: key, defaultLimit: cfg.DefaultLimit, maxLimit: cfg.MaxLimit}
+}
+
+// ParseLimit reads the page size of a request, falling back to the default
+// limit. It is exported for list endpoints that cannot use keyset paging
+// but should still honour the configured page sizes.
+func (o Options) ParseLimit(query url.Values) (int, error) {
+	v := query.Get(ParamLimit)
+	if v == "" {
+		return o.defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > o.maxLimit {
+		return 0, &Error{ParamLimit, fmt.Sprintf("must be between 1 and %d", o.maxLimit)}
+	}
+	return limit, nil
+}
+
+// New creates a Paginator for spec. It panics if the spec is inconsistent,
+// as that is a programming error.
+func New[T any](opts Options, spec Spec[T]) *Paginator[T] {
+	tiebreaker, ok := spec.Fields[spec.Tiebreaker]
+	if !ok || !tiebreaker.Sortable {
+		panic(fmt.Sprintf("pagination: tiebreaker %q is not a sortable field", spec.Tiebreaker))
+	}
+	for name, field := range spec.Fields {
+		if field.Sortable && field.Value == nil {
+			panic(fmt.Sprintf("pagination: sortable field %q has no Value", name))
+		}
+	}
+	p := &Paginator[T]{spec: spec, opts: opts}
+	if _, err := p.parseSort(spec.DefaultSort); err != nil {
+		panic(fmt.Sprintf("pagination: invalid default sort: %v", err))
+	}
+	return p
+}
+
+// Parse reads the paging, sorting and filter parameters of a request.
+// Unknown parameters are ignored so endpoints can add their own; filters on
+// fields that may not be filtered on are rejected.
+func (p *Paginator[T]) Parse(query url.Values) (*Request, error) {
+	limit, err := p.opts.ParseLimit(query)
+	if err != nil {
+		return nil, err
+	}
+	req := &Request{limit: limit}
+
+	if v := query.Get(ParamTotal); v != "" {
+		total, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, &Error{ParamTotal, "must be true or false"}
+		}
+		req.IncludeTotal = total
+	}
+
+	sortParam := query.Get(ParamSort)
+	if sortParam == "" {
+		sortParam = p.spec.DefaultSort
+	}
+	keys, err := p.parseSort(sortParam)
+	if err != nil {
+		return nil, err
+	}
+	req.keys = keys
+
+	if req.conditions, err = p.parseFilters(query); err != nil {
+		return nil, err
+	}
+
+	req.fingerprint = fingerprint(req.keys, req.conditions)
+
+	if v := query.Get(ParamCursor); v != "" {
+		if req.after, err = p.decodeCursor(v, req); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// parseSort resolves a sort parameter such as "-createdAt,title" and
+// appends the tiebreaker unless it is already sorted on
+func (p *Paginator[T]) parseSort(param string) ([]sortKey, error) {
+	var keys []sortKey
+	seen := map[string]bool{}
+	for _, item := range strings.Split(param, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, desc := strings.CutPrefix(item, "-")
+		field, ok := p.spec.Fields[name]
+		if !ok || !field.Sortable {
+			return nil, &Error{ParamSort, fmt.Sprintf("cannot sort by %q; allowed: %s", name, strings.Join(p.sortable(), ", "))}
+		}
+		if seen[name] {
+			return nil, &Error{ParamSort, fmt.Sprintf("%q is listed twice", name)}
+		}
+		seen[name] = true
+		keys = append(keys, sortKey{name: name, column: field.Column, typ: field.Type, nullable: field.Nullable, desc: desc})
+	}
+
+	if !seen[p.spec.Tiebreaker] {
+		field := p.spec.Fields[p.spec.Tiebreaker]
+		desc := len(keys) > 0 && keys[len(keys)-1].desc
+		keys = append(keys, sortKey{name: p.spec.Tiebreaker, column: field.Column, typ: field.Type, nullable: field.Nullable, desc: desc})
+	}
+	return keys, nil
+}
+
+// parseFilters reads every field=value and field[op]=value parameter that
+// names a field of the spec
+func (p *Paginator[T]) parseFilters(query url.Values) ([]condition, error) {
+	var conditions []condition
+	for param, values := range query {
+		name, op := param, OpEq
+		if open := strings.IndexByte(param, '['); open > 0 && strings.HasSuffix(param, "]") {
+			name, op = param[:open], Operator(param[open+1:len(param)-1])
+		}
+
+		field, ok := p.spec.Fields[name]
+		if !ok {
+			if name != param {
+				return nil, &Error{param, fmt.Sprintf("unknown filter field %q", name)}
+			}
+			continue
+		}
+		if !slices.Contains(field.Operators, op) {
+			return nil, &Error{param, fmt.Sprintf("operator %q is not allowed on %s", op, name)}
+		}
+
+		for _, raw := range values {
+			cond := condition{name: name, column: field.Column, op: op, raw: raw}
+			parts := []string{raw}
+			if op == OpIn {
+				parts = strings.Split(raw, ",")
+			}
+			for _, part := range parts {
+				value, err := parseValue(field.Type, strings.TrimSpace(part))
+				if err != nil {
+					return nil, &Error{param, err.Error()}
+				}
+				cond.values = append(cond.values, value)
+			}
+			conditions = append(conditions, cond)
+		}
+	}
+
+	// Map iteration order is random; the cursor fingerprint needs a stable one
+	slices.SortFunc(conditions, func(a, b condition) int {
+		return strings.Compare(a.name+string(a.op)+a.raw, b.name+string(b.op)+b.raw)
+	})
+	return conditions, nil
+}
+
+// sortable lists the fields that may be sorted on, for error messages
+func (p *Paginator[T]) sortable() []string {
+	var names []string
+	for name, field := range p.spec.Fields {
+		if field.Sortable {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,241 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"github.com/google/uuid"
+)
+
+type testRow struct {
+	ID        uuid.UUID
+	Title     string
+	UpdatedAt time.Time // Zero when NULL
+}
+
+var testSpec = Spec[testRow]{
+	Fields: map[string]Field[testRow]{
+		"id": {
+			Column: "id", Type: UUID, Sortable: true,
+			Value: func(r testRow) any { return r.ID },
+		},
+		"title": {
+			Column: "title", Type: String, Sortable: true,
+			Value: func(r testRow) any { return r.Title },
+		},
+		"updatedAt": {
+			Column: "updated_at", Type: Time, Nullable: true, Sortable: true, Operators: Comparable,
+			Value: func(r testRow) any { return r.UpdatedAt },
+		},
+		"level": {Column: "level", Type: String, Operators: Equality},
+	},
+	DefaultSort: "-updatedAt",
+	Tiebreaker:  "id",
+}
+
+func newTestPaginator(secret string) *Paginator[testRow] {
+	return New(NewOptions(config.PaginationConfig{CursorSecret: secret, DefaultLimit: 2, MaxLimit: 10}), testSpec)
+}
+
+// nextCursor returns the cursor a page of the given request hands out
+// after row
+func nextCursor(t *testing.T, p *Paginator[testRow], query string, row testRow) string {
+	t.Helper()
+	req, err := p.Parse(mustParseQuery(t, query))
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", query, err)
+	}
+	page := p.Page(req, []testRow{{ID: uuid.New()}, row, {ID: uuid.New()}})
+	if !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("Page() = %+v, want a next cursor", page)
+	}
+	return page.NextCursor
+}
+
+func mustParseQuery(t *testing.T, query string) url.Values {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("invalid query %q: %v", query, err)
+	}
+	return values
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	p := newTestPaginator("secret")
+	row := testRow{ID: uuid.New(), Title: "Go", UpdatedAt: time.Date(2026, 3, 1, 12, 30, 0, 500, time.UTC)}
+	never := testRow{ID: row.ID, Title: row.Title}
+
+	tests := []struct {
+		name  string
+		query string
+		row   testRow
+		want  []any
+	}{
+		{"default sort", "", row, []any{row.UpdatedAt, row.ID}},
+		{"explicit sort", "sort=title", row, []any{row.Title, row.ID}},
+		{"sort with filter", "sort=title,-updatedAt&level=beginner", row, []any{row.Title, row.UpdatedAt, row.ID}},
+		{"null sort value", "sort=updatedAt", never, []any{time.Time{}, row.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := nextCursor(t, p, tt.query, tt.row)
+
+			query := mustParseQuery(t, tt.query)
+			query.Set(ParamCursor, cursor)
+			req, err := p.Parse(query)
+			if err != nil {
+				t.Fatalf("Parse() with cursor error: %v", err)
+			}
+			if len(req.after) != len(tt.want) {
+				t.Fatalf("cursor values = %v, want %v", req.after, tt.want)
+			}
+			for i, want := range tt.want {
+				got := req.after[i]
+				if ts, ok := want.(time.Time); ok {
+					if !ts.Equal(got.(time.Time)) {
+						t.Errorf("cursor value %d = %v, want %v", i, got, want)
+					}
+					continue
+				}
+				if got != want {
+					t.Errorf("cursor value %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCursorTampering(t *testing.T) {
+	p := newTestPaginator("secret")
+	cursor := nextCursor(t, p, "sort=title", testRow{ID: uuid.New(), Title: "Go"})
+	payload, signature, _ := strings.Cut(cursor, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":["Zzz","` + uuid.NewString() + `"],"f":"x"}`))
+
+	tests := []struct {
+		name    string
+		query   string
+		cursor  string
+		signer  *Paginator[testRow]
+		message string
+	}{
+		{name: "valid", query: "sort=title", cursor: cursor, signer: p},
+		{name: "forged payload", query: "sort=title", cursor: forged + "." + signature, signer: p, message: "invalid cursor"},
+		{name: "altered signature", query: "sort=title", cursor: payload + "." + flipFirstChar(signature), signer: p, message: "invalid cursor"},
+		{name: "missing signature", query: "sort=title", cursor: payload, signer: p, message: "invalid cursor"},
+		{name: "not a cursor", query: "sort=title", cursor: "garbage", signer: p, message: "invalid cursor"},
+		{name: "other secret", query: "sort=title", cursor: cursor, signer: newTestPaginator("other"), message: "invalid cursor"},
+		{name: "other sort", query: "sort=-title", cursor: cursor, signer: p, message: "cursor belongs to a different sort or filter"},
+		{name: "other filter", query: "sort=title&level=advanced", cursor: cursor, signer: p, message: "cursor belongs to a different sort or filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := mustParseQuery(t, tt.query)
+			query.Set(ParamCursor, tt.cursor)
+			_, err := tt.signer.Parse(query)
+
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("Parse() error: %v", err)
+				}
+				return
+			}
+			var pageErr *Error
+			if !errors.As(err, &pageErr) || pageErr.Param != ParamCursor || pageErr.Message != tt.message {
+				t.Errorf("Parse() error = %v, want %s: %s", err, ParamCursor, tt.message)
+			}
+		})
+	}
+}
+
+// flipFirstChar changes a base64 string in its first character, which
+// unlike the last one carries no padding bits
+func flipFirstChar(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+func TestQuery(t *testing.T) {
+	id := uuid.New()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	keys := map[string]sortKey{
+		"title":     {name: "title", column: "title", typ: String},
+		"updatedAt": {name: "updatedAt", column: "updated_at", typ: Time, nullable: true},
+		"id":        {name: "id", column: "id", typ: UUID},
+	}
+	desc := func(key sortKey) sortKey {
+		key.desc = true
+		return key
+	}
+
+	tests := []struct {
+		name     string
+		req      Request
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name: "first page",
+			req:  Request{limit: 20, keys: []sortKey{keys["title"], keys["id"]}},
+			wantSQL: "SELECT *\nFROM (\nSELECT * FROM courses WHERE instructor_id = $1\n) AS page" +
+				"\nORDER BY page.title ASC, page.id ASC\nLIMIT 21",
+			wantArgs: []any{"owner"},
+		},
+		{
+			name: "keyset with mixed directions",
+			req:  Request{limit: 20, keys: []sortKey{keys["title"], desc(keys["id"])}, after: []any{"Go", id}},
+			wantSQL: "SELECT *\nFROM (\nSELECT * FROM courses WHERE instructor_id = $1\n) AS page" +
+				"\nWHERE ((page.title > $2) OR (page.title = $2 AND page.id < $3))" +
+				"\nORDER BY page.title ASC, page.id DESC\nLIMIT 21",
+			wantArgs: []any{"owner", "Go", id},
+		},
+		{
+			name: "nullable column falls back to its zero value",
+			req:  Request{limit: 5, keys: []sortKey{desc(keys["updatedAt"]), desc(keys["id"])}, after: []any{at, id}},
+			wantSQL: "SELECT *\nFROM (\nSELECT * FROM courses WHERE instructor_id = $1\n) AS page" +
+				"\nWHERE ((COALESCE(page.updated_at, '0001-01-01T00:00:00Z') < $2)" +
+				" OR (COALESCE(page.updated_at, '0001-01-01T00:00:00Z') = $2 AND page.id < $3))" +
+				"\nORDER BY COALESCE(page.updated_at, '0001-01-01T00:00:00Z') DESC, page.id DESC\nLIMIT 6",
+			wantArgs: []any{"owner", at, id},
+		},
+		{
+			name: "filters before the keyset",
+			req: Request{
+				limit: 20,
+				keys:  []sortKey{keys["id"]},
+				conditions: []condition{
+					{name: "level", column: "level", op: OpIn, values: []any{"beginner", "advanced"}},
+					{name: "updatedAt", column: "updated_at", op: OpGte, values: []any{at}},
+				},
+				after: []any{id},
+			},
+			wantSQL: "SELECT *\nFROM (\nSELECT * FROM courses WHERE instructor_id = $1\n) AS page" +
+				"\nWHERE page.level IN ($2, $3)\n    AND page.updated_at >= $4\n    AND ((page.id > $5))" +
+				"\nORDER BY page.id ASC\nLIMIT 21",
+			wantArgs: []any{"owner", "beginner", "advanced", at, id},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.req.Query("SELECT * FROM courses WHERE instructor_id = $1;", "owner")
+			if sql != tt.wantSQL {
+				t.Errorf("Query() sql =\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Query() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package pagination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Request is a parsed page request
+type Request struct {
+	// IncludeTotal is set when the client asked for the total number of
+	// matching rows with total=true. Counting is left to the handler because
+	// it costs an extra query.
+	IncludeTotal bool
+
+	limit       int
+	keys        []sortKey
+	conditions  []condition
+	after       []any // Sort values of the last row of the previous page
+	fingerprint string
+}
+
+// sortKey is one column of the ORDER BY clause
+type sortKey struct {
+	name     string
+	column   string
+	typ      Type
+	nullable bool
+	desc     bool
+}
+
+// expr is the SQL the key sorts and compares on. Nullable columns fall back
+// to the zero value of their type, written as an untyped literal so that it
+// takes the column's type.
+func (k sortKey) expr() string {
+	if !k.nullable {
+		return "page." + k.column
+	}
+	return fmt.Sprintf("COALESCE(page.%s, '%s')", k.column, formatValue(k.typ, zeroValues[k.typ]))
+}
+
+// condition is one filter parameter
+type condition struct {
+	name   string
+	column string
+	op     Operator
+	raw    string
+	values []any
+}
+
+// Limit is the requested page size
+func (r *Request) Limit() int {
+	return r.limit
+}
+
+// Query wraps a list query into the requested page: filters, the keyset
+// condition for the cursor, the sort order and the limit are applied to the
+// query's result. base may use placeholders $1 to $len(args), such as the
+// SQL of a sqlc query; the returned arguments extend args. One row more
+// than the limit is fetched so Page can tell whether another page follows.
+func (r *Request) Query(base string, args ...any) (string, []any) {
+	b := &builder{args: args}
+	where := b.filters(r.conditions)
+	if r.after != nil {
+		where = append(where, b.keyset(r.keys, r.after))
+	}
+
+	order := make([]string, 0, len(r.keys))
+	for _, key := range r.keys {
+		direction := "ASC"
+		if key.desc {
+			direction = "DESC"
+		}
+		order = append(order, key.expr()+" "+direction)
+	}
+
+	var sql strings.Builder
+	sql.WriteString(wrap("*", base))
+	if len(where) > 0 {
+		sql.WriteString("\nWHERE " + strings.Join(where, "\n    AND "))
+	}
+	sql.WriteString("\nORDER BY " + strings.Join(order, ", "))
+	fmt.Fprintf(&sql, "\nLIMIT %d", r.limit+1)
+	return sql.String(), b.args
+}
+
+// CountQuery counts the rows of base that match the filters, regardless of
+// the cursor
+func (r *Request) CountQuery(base string, args ...any) (string, []any) {
+	b := &builder{args: args}
+	where := b.filters(r.conditions)
+
+	var sql strings.Builder
+	sql.WriteString(wrap("count(*)", base))
+	if len(where) > 0 {
+		sql.WriteString("\nWHERE " + strings.Join(where, "\n    AND "))
+	}
+	return sql.String(), b.args
+}
+
+// wrap selects from base as a subquery. The line breaks keep a leading or
+// trailing SQL comment, as in sqlc's query constants, from swallowing the
+// parenthesis.
+func wrap(columns, base string) string {
+	base = strings.TrimSuffix(strings.TrimSpace(base), ";")
+	return "SELECT " + columns + "\nFROM (\n" + base + "\n) AS page"
+}
+
+// builder collects placeholder arguments
+type builder struct {
+	args []any
+}
+
+// bind adds an argument and returns its placeholder
+func (b *builder) bind(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// filters renders the filter conditions
+func (b *builder) filters(conditions []condition) []string {
+	where := make([]string, 0, len(conditions))
+	for _, cond := range conditions {
+		if cond.op == OpIn {
+			placeholders := make([]string, 0, len(cond.values))
+			for _, value := range cond.values {
+				placeholders = append(placeholders, b.bind(value))
+			}
+			where = append(where, fmt.Sprintf("page.%s IN (%s)", cond.column, strings.Join(placeholders, ", ")))
+			continue
+		}
+		where = append(where, fmt.Sprintf("page.%s %s %s", cond.column, sqlOperators[cond.op], b.bind(cond.values[0])))
+	}
+	return where
+}
+
+// keyset renders the condition for rows after the cursor position. Sort
+// directions may differ per column, so it is spelled out instead of using a
+// row comparison:
+//
+//	a > $1 OR (a = $1 AND b < $2) OR (a = $1 AND b = $2 AND id > $3)
+func (b *builder) keyset(keys []sortKey, after []any) string {
+	placeholders := make([]string, len(keys))
+	for i, value := range after {
+		placeholders[i] = b.bind(value)
+	}
+
+	alternatives := make([]string, 0, len(keys))
+	for i, key := range keys {
+		terms := make([]string, 0, i+1)
+		for j := range i {
+			terms = append(terms, fmt.Sprintf("%s = %s", keys[j].expr(), placeholders[j]))
+		}
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", key.expr(), op, placeholders[i]))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
@@ -0,0 +1,132 @@
+package pagination
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type is the type of a field's values. It decides how filter values and
+// cursor positions are parsed.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Time // RFC 3339
+	UUID
+)
+
+// zeroValues are the values nullable sort columns take in place of NULL
+var zeroValues = map[Type]any{
+	String: "",
+	Int:    int64(0),
+	Float:  float64(0),
+	Bool:   false,
+	Time:   time.Time{},
+	UUID:   uuid.Nil,
+}
+
+// Operator compares a field with a filter value
+type Operator string
+
+const (
+	OpEq  Operator = "eq"
+	OpNe  Operator = "ne"
+	OpLt  Operator = "lt"
+	OpLte Operator = "lte"
+	OpGt  Operator = "gt"
+	OpGte Operator = "gte"
+	OpIn  Operator = "in" // Comma-separated values
+)
+
+// Operator sets for common kinds of fields
+var (
+	Equality   = []Operator{OpEq, OpNe, OpIn}
+	Comparable = []Operator{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte}
+)
+
+// sqlOperators maps operators other than OpIn to SQL
+var sqlOperators = map[Operator]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// parseValue converts a query parameter or cursor position to the Go type
+// passed to the database
+func parseValue(t Type, s string) (any, error) {
+	switch t {
+	case Int:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		return v, nil
+	case Float:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return v, nil
+	case Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", s)
+		}
+		return v, nil
+	case Time:
+		v, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 timestamp", s)
+		}
+		return v, nil
+	case UUID:
+		v, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a UUID", s)
+		}
+		return v, nil
+	default:
+		return s, nil
+	}
+}
+
+// formatValue is the inverse of parseValue, used to write cursors. Values
+// read from rows may have a wider type than the field, e.g. int32 for Int.
+func formatValue(t Type, v any) string {
+	switch t {
+	case Int:
+		switch n := v.(type) {
+		case int:
+			return strconv.Itoa(n)
+		case int32:
+			return strconv.FormatInt(int64(n), 10)
+		case int64:
+			return strconv.FormatInt(n, 10)
+		}
+	case Float:
+		switch n := v.(type) {
+		case float32:
+			return strconv.FormatFloat(float64(n), 'g', -1, 32)
+		case float64:
+			return strconv.FormatFloat(n, 'g', -1, 64)
+		}
+	case Bool:
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b)
+		}
+	case Time:
+		if ts, ok := v.(time.Time); ok {
+			return ts.Format(time.RFC3339Nano)
+		}
+	}
+	return fmt.Sprint(v)
+}
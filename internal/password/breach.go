@@ -0,0 +1,69 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" // #nosec G505 -- the Pwned Passwords range API is keyed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// breachCheckTimeout bounds a range lookup so a slow API cannot hold up
+// registration or a password change
+const breachCheckTimeout = 3 * time.Second
+
+// BreachChecker looks passwords up in the Pwned Passwords corpus of
+// breached passwords. Only the first five hex characters of the password's
+// SHA-1 hash leave the server (k-anonymity), and responses are padded so
+// their size does not reveal the match either.
+type BreachChecker struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewBreachChecker creates a BreachChecker for the range API at baseURL
+func NewBreachChecker(baseURL string, client *http.Client) *BreachChecker {
+	return &BreachChecker{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Breached reports whether the password appears in a known breach
+func (c *BreachChecker) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password)) // #nosec G401
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	ctx, cancel := context.WithTimeout(ctx, breachCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating range request: %w", err)
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error querying breached passwords: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("breached passwords API returned %d", resp.StatusCode)
+	}
+
+	// Each line is SUFFIX:COUNT; padding entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && candidate == suffix {
+			return count != "0", nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("error reading breached passwords: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,168 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported hashing algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrMismatch is returned when a password does not match its hash
+var ErrMismatch = errors.New("password does not match")
+
+// argon2Params are the cost parameters encoded in an argon2id hash
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes
+// made by any supported one. Hashes are self-describing: bcrypt embeds its
+// cost, and argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$salt$key).
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+	dummyHash  string
+}
+
+// NewHasher creates a Hasher from the password hashing settings in cfg
+func NewHasher(cfg config.AuthConfig) (*Hasher, error) {
+	h := &Hasher{
+		algorithm:  cfg.PasswordHashAlgorithm,
+		bcryptCost: cfg.BcryptCost,
+		argon2: argon2Params{
+			memory:      uint32(cfg.Argon2Memory),
+			iterations:  uint32(cfg.Argon2Iterations),
+			parallelism: uint8(cfg.Argon2Parallelism),
+		},
+	}
+
+	// Unknown accounts are checked against a hash that costs as much as a
+	// real one, so response times do not reveal which accounts exist
+	dummyHash, err := h.Hash(rand.Text())
+	if err != nil {
+		return nil, err
+	}
+	h.dummyHash = dummyHash
+
+	return h, nil
+}
+
+// Hash hashes the password with the configured algorithm and parameters
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("error hashing password: %w", err)
+		}
+		return string(hashedBytes), nil
+	case AlgorithmArgon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("error hashing password: %w", err)
+		}
+		key := argon2.IDKey([]byte(password), salt, h.argon2.iterations, h.argon2.memory, h.argon2.parallelism, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.argon2.memory, h.argon2.iterations, h.argon2.parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm: %q", h.algorithm)
+	}
+}
+
+// Verify compares a password with a hash made by any supported algorithm.
+// It returns ErrMismatch when the password is wrong.
+func (h *Hasher) Verify(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	if err != nil {
+		return fmt.Errorf("error verifying password: %w", err)
+	}
+	return nil
+}
+
+// VerifyDummy spends as long as Verify would on a real hash. Use it when the
+// account does not exist.
+func (h *Hasher) VerifyDummy(password string) {
+	h.Verify(h.dummyHash, password) //nolint:errcheck
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or
+// other parameters than the configured ones
+func (h *Hasher) NeedsRehash(hash string) bool {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.bcryptCost
+	case AlgorithmArgon2id:
+		params, _, key, err := decodeArgon2id(hash)
+		return err != nil || params != h.argon2 || len(key) != argon2KeyLength
+	default:
+		return false
+	}
+}
+
+// decodeArgon2id splits a PHC formatted argon2id hash into its parts
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version: %q", parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("malformed argon2id key")
+	}
+
+	return params, salt, key, nil
+}
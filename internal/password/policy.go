@@ -0,0 +1,180 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+)
+
+// MaxBytes is the longest password accepted. bcrypt ignores anything past
+// 72 bytes, so longer passwords would give a false sense of strength.
+const MaxBytes = 72
+
+// Rules a password can break
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleUppercase    = "uppercase"
+	RuleLowercase    = "lowercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleCommon       = "common"
+	RuleBreached     = "breached"
+	RuleReused       = "reused"
+)
+
+// Violation is a policy rule a password breaks. Limit is the length the
+// min_length and max_length rules require.
+type Violation struct {
+	Rule    string
+	Message string
+	Limit   int
+}
+
+//go:embed common-passwords.txt
+var commonPasswordList string
+
+// commonPasswords is the bundled offline list of the most common passwords.
+// It is always checked; the online breach check is opt-in.
+var commonPasswords = parseCommonPasswords(commonPasswordList)
+
+// Policy decides whether a password is strong enough
+type Policy struct {
+	minLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	breaches      *BreachChecker // nil when the breach check is disabled
+}
+
+// NewPolicy creates a Policy from the password settings in cfg
+func NewPolicy(cfg config.AuthConfig) *Policy {
+	policy := &Policy{
+		minLength:     cfg.PasswordMinLength,
+		requireUpper:  cfg.PasswordRequireUpper,
+		requireLower:  cfg.PasswordRequireLower,
+		requireDigit:  cfg.PasswordRequireDigit,
+		requireSymbol: cfg.PasswordRequireSymbol,
+	}
+	if cfg.PasswordBreachCheck {
+		policy.breaches = NewBreachChecker(cfg.PasswordBreachAPIURL, &http.Client{Timeout: breachCheckTimeout})
+	}
+	return policy
+}
+
+// Check returns every rule the password breaks, or nil if it is acceptable.
+// personal holds the account's email and names, none of which may appear in
+// the password. A password passing every other rule is looked up in the
+// breach corpus; if that lookup fails the password is let through rather
+// than blocking sign-ups on a third party.
+func (p *Policy) Check(ctx context.Context, password string, personal ...string) []Violation {
+	var violations []Violation
+
+	if len([]rune(password)) < p.minLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("password must be at least %d characters long", p.minLength), p.minLength})
+	}
+	if len(password) > MaxBytes {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("password must be at most %d bytes long", MaxBytes), MaxBytes})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.requireUpper && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "password must contain an uppercase letter"})
+	}
+	if p.requireLower && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "password must contain a lowercase letter"})
+	}
+	if p.requireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "password must contain a digit"})
+	}
+	if p.requireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "password must contain a symbol"})
+	}
+
+	if containsPersonalInfo(password, personal) {
+		violations = append(violations, Violation{Rule: RulePersonalInfo, Message: "password must not contain your email address or name"})
+	}
+	if IsCommon(password) {
+		violations = append(violations, Violation{Rule: RuleCommon, Message: "password is too common, choose a less predictable one"})
+	}
+
+	if len(violations) == 0 && p.breaches != nil {
+		breached, err := p.breaches.Breached(ctx, password)
+		if err != nil {
+			log.Printf("Skipping breached password check: %v", err)
+		} else if breached {
+			violations = append(violations, Violation{Rule: RuleBreached, Message: "password has appeared in a data breach, choose a different one"})
+		}
+	}
+
+	return violations
+}
+
+// IsCommon reports whether the password, ignoring case and trailing digits
+// or symbols, is on the bundled list of common passwords
+func IsCommon(password string) bool {
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+
+	// "Password2024!" is as guessable as "password"
+	base := strings.TrimRightFunc(lower, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	_, ok := commonPasswords[base]
+	return ok && base != ""
+}
+
+// containsPersonalInfo reports whether the password contains the email, its
+// local part or any name of at least three characters
+func containsPersonalInfo(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		candidates := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, candidate := range candidates {
+			if len([]rune(candidate)) >= 3 && strings.Contains(lower, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseCommonPasswords(list string) map[string]struct{} {
+	passwords := map[string]struct{}{}
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
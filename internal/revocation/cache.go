@@ -0,0 +1,215 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel the revocation triggers
+// publish on. Payloads are "session:<id>" or "user:<id>".
+const Channel = "auth_revocations"
+
+// entry is the cached state of one session
+type entry struct {
+	userID    uuid.UUID
+	revoked   bool
+	checkedAt time.Time
+}
+
+// Cache answers whether the session behind an access token is still valid.
+// Results are kept for ttl and dropped as soon as a notification arrives
+// for the session or its user, so revocations take effect on every instance
+// within seconds without a database round trip on each request.
+type Cache struct {
+	queries *database.Queries
+	ttl     time.Duration
+
+	// subscribed is set while notifications are being received. Without
+	// them a cached entry could outlive a revocation, so every check goes
+	// to the database instead.
+	subscribed atomic.Bool
+
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]entry
+}
+
+// New creates a new Cache instance
+func New(queries *database.Queries, ttl time.Duration) *Cache {
+	return &Cache{
+		queries:  queries,
+		ttl:      ttl,
+		sessions: map[uuid.UUID]entry{},
+	}
+}
+
+// IsRevoked reports whether the session was revoked, has disappeared, does
+// not belong to userID, or belongs to an inactive, suspended or deleted account
+func (c *Cache) IsRevoked(ctx context.Context, sessionID, userID uuid.UUID) (bool, error) {
+	subscribed := c.subscribed.Load()
+
+	c.mu.RLock()
+	cached, ok := c.sessions[sessionID]
+	c.mu.RUnlock()
+	if ok && subscribed && time.Since(cached.checkedAt) < c.ttl {
+		return cached.revoked || cached.userID != userID, nil
+	}
+
+	status, err := c.queries.GetSessionStatus(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error loading session status: %w", err)
+	}
+
+	revoked := !status.SessionActive.Bool ||
+		(status.UserActive.Valid && !status.UserActive.Bool) ||
+		status.SuspendedAt.Valid ||
+		status.DeletedAt.Valid
+
+	if subscribed {
+		c.mu.Lock()
+		c.sessions[sessionID] = entry{userID: status.UserID, revoked: revoked, checkedAt: time.Now()}
+		c.mu.Unlock()
+	}
+
+	return revoked || status.UserID != userID, nil
+}
+
+// Listen subscribes to revocation notifications and evicts affected cache
+// entries until ctx is cancelled. Until the subscription is in place, and
+// whenever the connection drops, the cache is bypassed and flushed, since
+// notifications may be missed meanwhile.
+func (c *Cache) Listen(ctx context.Context, dsn string) {
+	// listening is set once LISTEN succeeded; the listener repeats it on
+	// every reconnect
+	var listening atomic.Bool
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Revocation listener: %v", err)
+		}
+		switch event {
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			c.unsubscribe()
+		case pq.ListenerEventReconnected:
+			if listening.Load() {
+				c.subscribe()
+			}
+		}
+	})
+	defer listener.Close() //nolint:errcheck
+	defer c.unsubscribe()
+
+	// Listen blocks until connected and only fails when the server refuses,
+	// e.g. for missing privileges, which may be fixed while we wait
+	for delay := time.Second; ; delay = min(2*delay, listenRetryMaxDelay) {
+		err := listener.Listen(Channel)
+		if err == nil || errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			break
+		}
+		log.Printf("Revocation listener: error listening on %s, retrying in %s: %v", Channel, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+	listening.Store(true)
+	c.subscribe()
+
+	sweep := time.NewTicker(c.ttl)
+	defer sweep.Stop()
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// A nil notification signals a reconnect
+			if notification == nil {
+				c.Flush()
+				continue
+			}
+			c.handle(notification.Extra)
+		case <-sweep.C:
+			c.evictExpired()
+		case <-ping.C:
+			// Detect dead connections that never reported an error
+			go listener.Ping() //nolint:errcheck
+		}
+	}
+}
+
+// listenRetryMaxDelay bounds the backoff between attempts to subscribe
+const listenRetryMaxDelay = time.Minute
+
+// subscribe starts using the cache from an empty state
+func (c *Cache) subscribe() {
+	c.Flush()
+	c.subscribed.Store(true)
+}
+
+// unsubscribe stops using the cache until notifications flow again
+func (c *Cache) unsubscribe() {
+	c.subscribed.Store(false)
+	c.Flush()
+}
+
+// evictExpired drops entries older than ttl. They would be reloaded before
+// use anyway, and sessions that are no longer used would otherwise stay
+// cached for the life of the process.
+func (c *Cache) evictExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sessionID, cached := range c.sessions {
+		if time.Since(cached.checkedAt) >= c.ttl {
+			delete(c.sessions, sessionID)
+		}
+	}
+}
+
+// Flush drops every cached entry
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.sessions)
+}
+
+// handle evicts the entries named by a notification payload
+func (c *Cache) handle(payload string) {
+	kind, rawID, ok := strings.Cut(payload, ":")
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch kind {
+	case "session":
+		delete(c.sessions, id)
+	case "user":
+		for sessionID, cached := range c.sessions {
+			if cached.userID == id {
+				delete(c.sessions, sessionID)
+			}
+		}
+	}
+}
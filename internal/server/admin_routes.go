@@ -3,15 +3,52 @@ package server
 import (
 	"net/http"
 
+	"github.com/Abdelrahiim/lms/internal/handler"
 	"github.com/Abdelrahiim/lms/internal/middleware"
 )
 
 // registerAdminRoutes handles system administration
 func (s *Server) registerAdminRoutes(mux *http.ServeMux, globalMiddleware []middleware.Middleware) {
-	// TODO: Initialize admin handler when implemented
-	// adminHandler := handler.NewAdminHandler(s.db, s.queries, s.config)
+	adminHandler := handler.NewAdminHandler(s.db, s.queries, s.config, s.tokens)
 
 	// User management
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/unlock", chain(
+		adminHandler.UnlockUser,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin))...,
+	))
+
+	mux.HandleFunc("DELETE /api/v1/admin/users/{id}", chain(
+		adminHandler.DeleteUser,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin), middleware.DenyImpersonation)...,
+	))
+
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/restore", chain(
+		adminHandler.RestoreUser,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin), middleware.DenyImpersonation)...,
+	))
+
+	// Impersonation. Stopping is done with the impersonation token itself,
+	// whose principal is the impersonated user rather than the admin.
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/impersonate", chain(
+		adminHandler.StartImpersonation,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin), middleware.ValidateJSON[handler.StartImpersonationRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/admin/impersonation/stop", chain(
+		adminHandler.StopImpersonation,
+		append(globalMiddleware, middleware.RequireAuth)...,
+	))
+
+	// API keys
+	mux.HandleFunc("GET /api/v1/admin/users/{id}/tokens", chain(
+		adminHandler.ListUserAccessTokens,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin))...,
+	))
+
+	mux.HandleFunc("DELETE /api/v1/admin/tokens/{id}", chain(
+		adminHandler.RevokeAccessToken,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleAdmin))...,
+	))
 	// mux.HandleFunc("GET /api/v1/admin/users", chain(
 	//     adminHandler.ListUsers,
 	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole("admin"))...,
@@ -20,10 +57,6 @@ func (s *Server) registerAdminRoutes(mux *http.ServeMux, globalMiddleware []midd
 	//     adminHandler.UpdateUserRole,
 	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole("admin"), middleware.ValidateJSON[handler.UpdateRoleRequest])...,
 	// ))
-	// mux.HandleFunc("DELETE /api/v1/admin/users/{id}", chain(
-	//     adminHandler.DeleteUser,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole("admin"))...,
-	// ))
 
 	// System settings
 	// mux.HandleFunc("GET /api/v1/admin/settings", chain(
@@ -5,11 +5,12 @@ import (
 
 	"github.com/Abdelrahiim/lms/internal/handler"
 	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/oidc"
 )
 
 // registerAuthRoutes handles authentication and session management
 func (s *Server) registerAuthRoutes(mux *http.ServeMux, globalMiddleware []middleware.Middleware) {
-	authHandler := handler.NewAuthHandler(s.db, s.queries, s.config)
+	authHandler := handler.NewAuthHandler(s.db, s.queries, s.config, s.mailer, s.tokens, s.hasher)
 
 	// Authentication endpoints
 	mux.HandleFunc("POST /api/v1/auth/register", chain(
@@ -24,7 +25,7 @@ func (s *Server) registerAuthRoutes(mux *http.ServeMux, globalMiddleware []middl
 
 	mux.HandleFunc("POST /api/v1/auth/logout", chain(
 		authHandler.Logout,
-		globalMiddleware...,
+		append(globalMiddleware, middleware.RequireAuthAllowingPasswordChange, middleware.RequireSession)...,
 	))
 
 	mux.HandleFunc("POST /api/v1/auth/refresh", chain(
@@ -32,13 +33,120 @@ func (s *Server) registerAuthRoutes(mux *http.ServeMux, globalMiddleware []middl
 		globalMiddleware...,
 	))
 
+	// Session management
+	mux.HandleFunc("GET /api/v1/auth/sessions", chain(
+		authHandler.ListSessions,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession)...,
+	))
+
+	mux.HandleFunc("DELETE /api/v1/auth/sessions/{id}", chain(
+		authHandler.DeleteSession,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/sessions/revoke-others", chain(
+		authHandler.RevokeOtherSessions,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	// API keys
+	mux.HandleFunc("GET /api/v1/auth/tokens", chain(
+		authHandler.ListAccessTokens,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession)...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/tokens", chain(
+		authHandler.CreateAccessToken,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.CreateAccessTokenRequest])...,
+	))
+
+	mux.HandleFunc("DELETE /api/v1/auth/tokens/{id}", chain(
+		authHandler.DeleteAccessToken,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	// Email verification
+	mux.HandleFunc("POST /api/v1/auth/verify-email", chain(
+		authHandler.VerifyEmail,
+		append(globalMiddleware, middleware.ValidateJSON[handler.VerifyEmailRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/resend-verification", chain(
+		authHandler.ResendVerification,
+		append(globalMiddleware, middleware.ValidateJSON[handler.ResendVerificationRequest])...,
+	))
+
 	// Password management
-	// mux.HandleFunc("POST /api/v1/auth/forgot-password", chain(
-	//     authHandler.ForgotPassword,
-	//     append(globalMiddleware, middleware.ValidateJSON[handler.ForgotPasswordRequest])...,
-	// ))
-	// mux.HandleFunc("POST /api/v1/auth/reset-password", chain(
-	//     authHandler.ResetPassword,
-	//     append(globalMiddleware, middleware.ValidateJSON[handler.ResetPasswordRequest])...,
-	// ))
+	mux.HandleFunc("POST /api/v1/auth/forgot-password", chain(
+		authHandler.ForgotPassword,
+		append(globalMiddleware, middleware.ValidateJSON[handler.ForgotPasswordRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/reset-password", chain(
+		authHandler.ResetPassword,
+		append(globalMiddleware, middleware.ValidateJSON[handler.ResetPasswordRequest])...,
+	))
+
+	// The only endpoint besides logout open to users who must change their password
+	mux.HandleFunc("POST /api/v1/auth/change-password", chain(
+		authHandler.ChangePassword,
+		append(globalMiddleware, middleware.RequireAuthAllowingPasswordChange, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.ChangePasswordRequest])...,
+	))
+
+	// Passwordless login, enabled per environment
+	if s.config.Auth.MagicLinkEnabled {
+		mux.HandleFunc("POST /api/v1/auth/magic-link", chain(
+			authHandler.RequestMagicLink,
+			append(globalMiddleware, middleware.ValidateJSON[handler.MagicLinkRequest])...,
+		))
+
+		mux.HandleFunc("POST /api/v1/auth/magic-link/consume", chain(
+			authHandler.ConsumeMagicLink,
+			append(globalMiddleware, middleware.ValidateJSON[handler.ConsumeMagicLinkRequest])...,
+		))
+	}
+
+	// Two-factor authentication
+	mux.HandleFunc("POST /api/v1/auth/2fa/verify", chain(
+		authHandler.VerifyTwoFactor,
+		append(globalMiddleware, middleware.ValidateJSON[handler.VerifyTwoFactorRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/2fa/setup", chain(
+		authHandler.SetupTwoFactor,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/2fa/enable", chain(
+		authHandler.EnableTwoFactor,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.EnableTwoFactorRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/2fa/disable", chain(
+		authHandler.DisableTwoFactor,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.DisableTwoFactorRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/2fa/backup-codes", chain(
+		authHandler.RegenerateBackupCodes,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.RegenerateBackupCodesRequest])...,
+	))
+
+	// Single sign-on through OpenID Connect providers
+	oidcHandler := handler.NewOIDCHandler(authHandler, oidc.New(s.config.OIDC))
+
+	mux.HandleFunc("GET /api/v1/auth/oidc/{provider}/login", chain(
+		oidcHandler.Login,
+		globalMiddleware...,
+	))
+
+	mux.HandleFunc("GET /api/v1/auth/oidc/{provider}/callback", chain(
+		oidcHandler.Callback,
+		globalMiddleware...,
+	))
+
+	mux.HandleFunc("POST /api/v1/auth/oidc/exchange", chain(
+		oidcHandler.ExchangeCode,
+		append(globalMiddleware, middleware.ValidateJSON[handler.OIDCExchangeRequest])...,
+	))
 }
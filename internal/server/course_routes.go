@@ -3,49 +3,106 @@ package server
 import (
 	"net/http"
 
+	"github.com/Abdelrahiim/lms/internal/authz"
+	"github.com/Abdelrahiim/lms/internal/handler"
 	"github.com/Abdelrahiim/lms/internal/middleware"
 )
 
 // registerCourseRoutes handles course management and enrollment
 func (s *Server) registerCourseRoutes(mux *http.ServeMux, globalMiddleware []middleware.Middleware) {
-	// TODO: Initialize course handler when implemented
-	// courseHandler := handler.NewCourseHandler(s.db, s.queries, s.config)
+	courseHandler := handler.NewCourseHandler(s.db, s.queries, s.config)
 
-	// Course discovery and enrollment
-	// mux.HandleFunc("GET /api/v1/courses", chain(
-	//     courseHandler.ListCourses,
-	//     globalMiddleware...,
-	// ))
-	// mux.HandleFunc("GET /api/v1/courses/{id}", chain(
-	//     courseHandler.GetCourse,
-	//     globalMiddleware...,
-	// ))
+	// Course discovery
+	mux.HandleFunc("GET /api/v1/courses", chain(
+		courseHandler.ListCourses,
+		globalMiddleware...,
+	))
+	mux.HandleFunc("GET /api/v1/courses/{id}", chain(
+		courseHandler.GetCourse,
+		append(globalMiddleware, middleware.OptionalAuth)...,
+	))
+	// TODO: Enrollment
 	// mux.HandleFunc("POST /api/v1/courses/{id}/enroll", chain(
 	//     courseHandler.EnrollInCourse,
 	//     append(globalMiddleware, middleware.RequireAuth)...,
 	// ))
 
 	// Course content (modules and lessons)
-	// mux.HandleFunc("GET /api/v1/courses/{id}/modules", chain(
-	//     courseHandler.GetCourseModules,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireEnrollment)...,
-	// ))
-	// mux.HandleFunc("GET /api/v1/modules/{id}/lessons", chain(
-	//     courseHandler.GetModuleLessons,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireEnrollment)...,
-	// ))
+	mux.HandleFunc("GET /api/v1/courses/{id}/modules", chain(
+		courseHandler.GetCourseModules,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "read", authz.ScopeAll), middleware.RequireEnrollment)...,
+	))
+	mux.HandleFunc("GET /api/v1/modules/{id}/lessons", chain(
+		courseHandler.GetModuleLessons,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "read", authz.ScopeAll), middleware.RequireEnrollment)...,
+	))
 	// mux.HandleFunc("POST /api/v1/lessons/{id}/complete", chain(
 	//     courseHandler.CompleteLesson,
 	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireEnrollment)...,
 	// ))
 
-	// Instructor course management
-	// mux.HandleFunc("POST /api/v1/courses", chain(
-	//     courseHandler.CreateCourse,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole("instructor"), middleware.ValidateJSON[handler.CreateCourseRequest])...,
-	// ))
-	// mux.HandleFunc("PUT /api/v1/courses/{id}", chain(
-	//     courseHandler.UpdateCourse,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.RequireInstructor, middleware.ValidateJSON[handler.UpdateCourseRequest])...,
-	// ))
+	// Instructor course management. Write routes check a permission before
+	// the course relation so that API keys are held to their scopes.
+	mux.HandleFunc("GET /api/v1/courses/mine", chain(
+		courseHandler.ListMyCourses,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireRole(middleware.RoleInstructor))...,
+	))
+	mux.HandleFunc("POST /api/v1/courses", chain(
+		courseHandler.CreateCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "create", authz.ScopeOwn), middleware.ValidateJSON[handler.CreateCourseRequest])...,
+	))
+	mux.HandleFunc("PUT /api/v1/courses/{id}", chain(
+		courseHandler.UpdateCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.UpdateCourseRequest])...,
+	))
+	mux.HandleFunc("DELETE /api/v1/courses/{id}", chain(
+		courseHandler.DeleteCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "delete", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+	mux.HandleFunc("POST /api/v1/courses/{id}/publish", chain(
+		courseHandler.PublishCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+	mux.HandleFunc("POST /api/v1/courses/{id}/unpublish", chain(
+		courseHandler.UnpublishCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+	mux.HandleFunc("POST /api/v1/courses/{id}/archive", chain(
+		courseHandler.ArchiveCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+	mux.HandleFunc("POST /api/v1/courses/{id}/unarchive", chain(
+		courseHandler.UnarchiveCourse,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+
+	// Instructor content management
+	mux.HandleFunc("POST /api/v1/courses/{id}/modules", chain(
+		courseHandler.CreateModule,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.ModuleRequest])...,
+	))
+	mux.HandleFunc("PATCH /api/v1/courses/{id}/outline", chain(
+		courseHandler.UpdateCourseOutline,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.CourseOutlineRequest])...,
+	))
+	mux.HandleFunc("PUT /api/v1/modules/{id}", chain(
+		courseHandler.UpdateModule,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.ModuleRequest])...,
+	))
+	mux.HandleFunc("DELETE /api/v1/modules/{id}", chain(
+		courseHandler.DeleteModule,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
+	mux.HandleFunc("POST /api/v1/modules/{id}/lessons", chain(
+		courseHandler.CreateLesson,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.LessonRequest])...,
+	))
+	mux.HandleFunc("PUT /api/v1/lessons/{id}", chain(
+		courseHandler.UpdateLesson,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor, middleware.ValidateJSON[handler.LessonRequest])...,
+	))
+	mux.HandleFunc("DELETE /api/v1/lessons/{id}", chain(
+		courseHandler.DeleteLesson,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("courses", "update", authz.ScopeOwn), middleware.RequireInstructor)...,
+	))
 }
@@ -2,8 +2,11 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/Abdelrahiim/lms/internal/handler"
 	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/storage"
 )
 
 // RegisterRoutes sets up all application routes with proper middleware chaining
@@ -27,6 +30,22 @@ func (s *Server) RegisterRoutes() http.Handler {
 		}
 	}, globalMiddleware...))
 
+	// Public keys for verifying access tokens
+	wellKnownHandler := handler.NewWellKnownHandler(s.keys)
+	mux.HandleFunc("GET /.well-known/jwks.json", chain(
+		wellKnownHandler.JWKS,
+		globalMiddleware...,
+	))
+
+	// Uploaded files, when kept on local disk and served under a path
+	if files, ok := s.storage.(*storage.LocalStorage); ok && strings.HasPrefix(s.config.Storage.BaseURL, "/") {
+		prefix := strings.TrimSuffix(s.config.Storage.BaseURL, "/")
+		mux.HandleFunc("GET "+prefix+"/", chain(
+			http.StripPrefix(prefix, files.Handler()).ServeHTTP,
+			globalMiddleware...,
+		))
+	}
+
 	// Register route groups
 	s.registerAuthRoutes(mux, globalMiddleware)
 	s.registerUserRoutes(mux, globalMiddleware)
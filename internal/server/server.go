@@ -12,6 +12,15 @@ import (
 
 	"github.com/Abdelrahiim/lms/internal/config"
 	"github.com/Abdelrahiim/lms/internal/database"
+	"github.com/Abdelrahiim/lms/internal/deletion"
+	"github.com/Abdelrahiim/lms/internal/export"
+	"github.com/Abdelrahiim/lms/internal/jwtkeys"
+	"github.com/Abdelrahiim/lms/internal/mailer"
+	"github.com/Abdelrahiim/lms/internal/middleware"
+	"github.com/Abdelrahiim/lms/internal/password"
+	"github.com/Abdelrahiim/lms/internal/revocation"
+	"github.com/Abdelrahiim/lms/internal/storage"
+	"github.com/Abdelrahiim/lms/internal/utils"
 	_ "github.com/lib/pq"
 )
 
@@ -19,7 +28,16 @@ type Server struct {
 	config     *config.Config
 	db         *sql.DB
 	queries    *database.Queries
+	mailer     mailer.Mailer
+	storage    storage.Storage
+	exporter   *export.Exporter
+	hasher     *password.Hasher
+	keys       *jwtkeys.KeySet
+	tokens     utils.TokenOptions
 	httpServer *http.Server
+
+	// stopBackground stops goroutines started alongside the HTTP server
+	stopBackground context.CancelFunc
 }
 
 func New(cfg *config.Config) (*Server, error) {
@@ -42,12 +60,72 @@ func New(cfg *config.Config) (*Server, error) {
 	// Create SQLC queries
 	queries := database.New(db)
 
+	// Create mailer
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create file storage
+	files, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create data export worker
+	exporter, err := export.New(cfg, queries, files, mail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create password hasher
+	hasher, err := password.NewHasher(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load token signing keys
+	keys, err := jwtkeys.Load(jwtkeys.Options{
+		Dir:              cfg.Auth.JWTKeyDir,
+		Algorithm:        cfg.Auth.JWTAlgorithm,
+		RotationInterval: cfg.Auth.JWTKeyRotationInterval,
+		TokenLifetime:    max(cfg.Auth.JWTExpiry, cfg.Auth.MFATokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens := utils.TokenOptions{
+		Keys:     keys,
+		Issuer:   cfg.Auth.JWTIssuer,
+		Audience: cfg.Auth.JWTAudience,
+	}
+
+	// Track session revocations pushed by Postgres, rotate signing keys,
+	// build data exports, anonymise deleted accounts and prune login attempts
+	background, stopBackground := context.WithCancel(context.Background())
+	revocations := revocation.New(queries, cfg.Auth.SessionCacheTTL)
+	go revocations.Listen(background, cfg.Database.DSN())
+	go keys.Run(background)
+	go exporter.Run(background)
+	go deletion.New(cfg, db, queries, files, exporter).Run(background)
+	go deletion.NewLoginAttemptPruner(cfg, queries).Run(background)
+
 	s := &Server{
-		config:  cfg,
-		db:      db,
-		queries: queries,
+		config:         cfg,
+		db:             db,
+		queries:        queries,
+		mailer:         mail,
+		storage:        files,
+		exporter:       exporter,
+		hasher:         hasher,
+		keys:           keys,
+		tokens:         tokens,
+		stopBackground: stopBackground,
 	}
 
+	// Wire auth middleware dependencies
+	middleware.InitAuth(cfg, queries, tokens, revocations)
+
 	// Setup routes
 	mux := s.RegisterRoutes()
 
@@ -84,6 +162,8 @@ func (s *Server) handleShutdown() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	s.stopBackground()
+
 	if err := s.db.Close(); err != nil {
 		log.Printf("Error closing database: %v", err)
 	}
@@ -3,25 +3,56 @@ package server
 import (
 	"net/http"
 
+	"github.com/Abdelrahiim/lms/internal/authz"
+	"github.com/Abdelrahiim/lms/internal/handler"
 	"github.com/Abdelrahiim/lms/internal/middleware"
 )
 
 // registerUserRoutes handles user profile and settings
 func (s *Server) registerUserRoutes(mux *http.ServeMux, globalMiddleware []middleware.Middleware) {
-	// TODO: Initialize user handler when implemented
-	// userHandler := handler.NewUserHandler(s.db, s.queries, s.config)
+	userHandler := handler.NewUserHandler(s.db, s.queries, s.config, s.storage, s.exporter, s.hasher, s.mailer)
 
 	// User profile endpoints
-	// mux.HandleFunc("GET /api/v1/users/profile", chain(
-	//     userHandler.GetProfile,
-	//     append(globalMiddleware, middleware.RequireAuth)...,
-	// ))
-	// mux.HandleFunc("PUT /api/v1/users/profile", chain(
-	//     userHandler.UpdateProfile,
-	//     append(globalMiddleware, middleware.RequireAuth, middleware.ValidateJSON[handler.UpdateProfileRequest])...,
-	// ))
-	// mux.HandleFunc("POST /api/v1/users/avatar", chain(
-	//     userHandler.UploadAvatar,
-	//     append(globalMiddleware, middleware.RequireAuth)...,
-	// ))
+	mux.HandleFunc("GET /api/v1/users/profile", chain(
+		userHandler.GetProfile,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequirePermission("users", "read", authz.ScopeOwn))...,
+	))
+
+	mux.HandleFunc("PUT /api/v1/users/profile", chain(
+		userHandler.UpdateProfile,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.ValidateJSON[handler.UpdateProfileRequest])...,
+	))
+
+	mux.HandleFunc("POST /api/v1/users/avatar", chain(
+		userHandler.UploadAvatar,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession)...,
+	))
+
+	// Account deletion
+	mux.HandleFunc("POST /api/v1/users/me/deletion-confirmation", chain(
+		userHandler.RequestAccountDeletionConfirmation,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	mux.HandleFunc("DELETE /api/v1/users/me", chain(
+		userHandler.DeleteAccount,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation, middleware.ValidateJSON[handler.DeleteAccountRequest])...,
+	))
+
+	// Personal data export endpoints
+	mux.HandleFunc("POST /api/v1/users/me/export", chain(
+		userHandler.RequestDataExport,
+		append(globalMiddleware, middleware.RequireAuth, middleware.RequireSession, middleware.DenyImpersonation)...,
+	))
+
+	mux.HandleFunc("GET /api/v1/users/me/export/{id}", chain(
+		userHandler.GetDataExport,
+		append(globalMiddleware, middleware.RequireAuth, middleware.DenyImpersonation)...,
+	))
+
+	// The emailed token authorises the download, so no login is needed
+	mux.HandleFunc("GET /api/v1/data-exports/download", chain(
+		userHandler.DownloadDataExport,
+		globalMiddleware...,
+	))
 }
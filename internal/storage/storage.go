@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Abdelrahiim/lms/internal/config"
+)
+
+// Storage keeps uploaded files. Keys are slash-separated relative paths.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Provider names the backend, as recorded in file_uploads.storage_provider
+	Provider() string
+	// Put stores data under key, replacing any existing file
+	Put(ctx context.Context, key string, data []byte) error
+	// Open reads the file at key. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file at key, or every file below it
+	Delete(ctx context.Context, key string) error
+	// URL returns the address the file at key is served from
+	URL(key string) string
+}
+
+// New creates the Storage selected by StorageConfig.Driver
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.UploadPath, cfg.BaseURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %q", cfg.Driver)
+	}
+}
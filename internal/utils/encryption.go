@@ -1,82 +1,133 @@
 package utils
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // CustomClaims represents the JWT claims structure following industry standards
 type CustomClaims struct {
-	UserID string `json:"sub"`            // Subject (user ID)
-	Email  string `json:"email"`          // User email
-	Role   string `json:"role,omitempty"` // User role (optional)
-	Type   string `json:"typ"`            // Token type (access_token)
+	UserID        string `json:"sub"`            // Subject (user ID)
+	Email         string `json:"email"`          // User email
+	EmailVerified bool   `json:"email_verified"` // Whether the email address is verified
+	Role          string `json:"role,omitempty"` // User role (optional)
+	SessionID     string `json:"sid,omitempty"`  // Session the token was issued for
+	Type          string `json:"typ"`            // Token type (access_token, mfa_pending)
+	Actor         *Actor `json:"act,omitempty"`  // Admin acting as the subject (RFC 8693)
+	// The user must change their password before doing anything else
+	PasswordChangeRequired bool `json:"pwd_change,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// HashPassword creates a bcrypt hash of the provided password
-func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("error hashing password: %v", err)
-	}
-	return string(hashedBytes), nil
+// Actor identifies who is acting on behalf of the token subject during
+// impersonation
+type Actor struct {
+	UserID string `json:"sub"`
+	Email  string `json:"email,omitempty"`
+}
+
+// TokenSubject describes the user and session an access token is issued for
+type TokenSubject struct {
+	UserID        uuid.UUID
+	Email         string
+	Role          string
+	SessionID     uuid.UUID
+	EmailVerified bool
+	Actor         *Actor // Set for impersonation tokens
+
+	PasswordChangeRequired bool
+}
+
+// TokenKeys signs JWTs and resolves the keys to verify them
+type TokenKeys interface {
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
+}
+
+// TokenOptions describe how tokens are issued and which tokens are accepted
+type TokenOptions struct {
+	Keys     TokenKeys
+	Issuer   string
+	Audience []string
 }
 
-// CheckPasswordHash compares a bcrypt hashed password with its possible plaintext equivalent
-func CheckPasswordHash(hashedPassword, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// GenerateAccessToken creates a JWT access token with standard claims
+func GenerateAccessToken(subject TokenSubject, opts TokenOptions, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+
+	claims := CustomClaims{
+		UserID:        subject.UserID.String(),
+		Email:         subject.Email,
+		EmailVerified: subject.EmailVerified,
+		Role:          subject.Role,
+		SessionID:     subject.SessionID.String(),
+		Type:          "access_token",
+		Actor:         subject.Actor,
+
+		PasswordChangeRequired: subject.PasswordChangeRequired,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.UserID.String(),
+			Issuer:    opts.Issuer,
+			Audience:  opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	tokenString, err := opts.Keys.Sign(claims)
 	if err != nil {
-		return fmt.Errorf("password does not match: %v", err)
+		return "", fmt.Errorf("error generating access token: %w", err)
 	}
-	return nil
+
+	return tokenString, nil
 }
 
-// GenerateAccessToken creates a JWT access token with standard claims
-func GenerateAccessToken(userID uuid.UUID, email string, secretKey string) (string, error) {
+// GenerateMFAToken creates a short-lived token proving that the user passed
+// the password step of a two-factor login. It carries no role or session and
+// is rejected by RequireAuth.
+func GenerateMFAToken(userID uuid.UUID, opts TokenOptions, ttl time.Duration) (string, error) {
 	now := time.Now().UTC()
 
 	claims := CustomClaims{
 		UserID: userID.String(),
-		Email:  email,
-		Type:   "access_token",
+		Type:   "mfa_pending",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   userID.String(),
-			Issuer:    "lms-api",
-			Audience:  jwt.ClaimStrings{"lms-web", "lms-mobile"},
-			ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)),
+			Issuer:    opts.Issuer,
+			Audience:  opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secretKey))
+	tokenString, err := opts.Keys.Sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("error generating access token: %w", err)
+		return "", fmt.Errorf("error generating mfa token: %w", err)
 	}
 
 	return tokenString, nil
 }
 
-// ValidateJWT parses and validates a JWT token, returning the claims if valid
-func ValidateJWT(tokenString string, tokenSecret string) (*CustomClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(tokenSecret), nil
-	})
-
+// ValidateJWT parses and validates a JWT token, returning the claims if valid.
+// The token must be signed by a known key and carry the configured issuer
+// and at least one of the configured audiences.
+func ValidateJWT(tokenString string, opts TokenOptions) (*CustomClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, opts.Keys.Keyfunc)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
@@ -86,6 +137,14 @@ func ValidateJWT(tokenString string, tokenSecret string) (*CustomClaims, error)
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
+	// Validate issuer and audience
+	if !claims.VerifyIssuer(opts.Issuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+	if !slices.ContainsFunc(opts.Audience, func(aud string) bool { return claims.VerifyAudience(aud, true) }) {
+		return nil, fmt.Errorf("invalid token audience")
+	}
+
 	// Validate user ID format
 	if _, err := uuid.Parse(claims.UserID); err != nil {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
@@ -99,6 +158,15 @@ func GetUserIDFromClaims(claims *CustomClaims) (uuid.UUID, error) {
 	return uuid.Parse(claims.UserID)
 }
 
+// GenerateSecureToken creates a URL-safe random token from byteLength random bytes
+func GenerateSecureToken(byteLength int) (string, error) {
+	tokenBytes := make([]byte, byteLength)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("error generating token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
 // GenerateRefreshToken creates a cryptographically secure random refresh token
 func GenerateRefreshToken() (string, error) {
 	// Generate 64 random bytes for stronger security
@@ -112,6 +180,72 @@ func GenerateRefreshToken() (string, error) {
 	return token, nil
 }
 
+// PersonalAccessTokenPrefix marks API keys so they are easy to tell apart from
+// JWTs and to spot in logs or leaked files
+const PersonalAccessTokenPrefix = "lms_pat_"
+
+// GeneratePersonalAccessToken creates a new random API key
+func GeneratePersonalAccessToken() (string, error) {
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
+
+// HashToken returns the hex-encoded SHA-256 digest of a token for storage and lookup
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EncryptSecret encrypts plaintext with AES-256-GCM and returns the base64
+// encoded nonce and ciphertext. key must be 32 bytes long.
+func EncryptSecret(plaintext string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret reverses EncryptSecret
+func DecryptSecret(encrypted string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted secret")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
 // GetBearerToken extracts the bearer token from the Authorization header
 func GetBearerToken(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
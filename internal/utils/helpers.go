@@ -4,6 +4,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/sqlc-dev/pqtype"
 )
 
 func GetClientIP(r *http.Request) string {
@@ -34,6 +36,19 @@ func GetClientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
+// InetFromIP converts a client IP to an INET value holding a single host.
+// The mask must be set: pqtype encodes a mask-less IPNet as "<nil>", which
+// Postgres rejects. A nil IP, e.g. from an unparsable address, is NULL.
+func InetFromIP(ip net.IP) pqtype.Inet {
+	if ip == nil {
+		return pqtype.Inet{}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return pqtype.Inet{IPNet: net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, Valid: true}
+	}
+	return pqtype.Inet{IPNet: net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, Valid: true}
+}
+
 // Helper function to extract browser info
 func getBrowserInfo(userAgent string) (browser, version string) {
 	// Simple browser detection - you might want to use a library like "github.com/mileusna/useragent"
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestInetFromIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+		want any // Value sent to Postgres
+	}{
+		{"IPv4", net.ParseIP("203.0.113.7"), "203.0.113.7/32"},
+		{"IPv4 in 16-byte form", net.IPv4(192, 0, 2, 1), "192.0.2.1/32"},
+		{"IPv6", net.ParseIP("2001:db8::1"), "2001:db8::1/128"},
+		{"IPv4-mapped IPv6", net.ParseIP("::ffff:198.51.100.2"), "198.51.100.2/32"},
+		{"unparsable address", net.ParseIP("not-an-ip"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inet := InetFromIP(tt.ip)
+			if inet.Valid != (tt.want != nil) {
+				t.Fatalf("Valid = %v, want %v", inet.Valid, tt.want != nil)
+			}
+			got, err := inet.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength leaves room for a numeric suffix within VARCHAR(255)
+const maxSlugLength = 200
+
+// letterReplacer spells out letters that do not decompose into an ASCII
+// letter and an accent
+var letterReplacer = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "Æ", "AE", "ø", "o", "Ø", "O",
+	"œ", "oe", "Œ", "OE", "ł", "l", "Ł", "L", "đ", "d", "Đ", "D",
+)
+
+// Slugify turns a title into a URL-friendly slug: lowercase ASCII letters and
+// digits separated by single hyphens. Accents are dropped, so "Café Déjà Vu"
+// becomes "cafe-deja-vu". Titles without any usable characters give "".
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(letterReplacer.Replace(title)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left over from decomposing accented letters
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return b.String()
+}